@@ -0,0 +1,180 @@
+// Package main provides payment reconciliation
+// This command-line tool matches the invoices at the payment gateway with the
+// reservations they bill and reports the ones that disagree
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"diro-be/internal/config"
+	"diro-be/internal/database"
+	"diro-be/internal/logging"
+	"diro-be/internal/repositories"
+	"diro-be/internal/services"
+)
+
+// main is the entry point for the reconcile command
+func main() {
+	var fromDate, toDate, fixture, format, output string
+	var fix bool
+	flag.StringVar(&fromDate, "from", "", "First day of invoices to reconcile, YYYY-MM-DD")
+	flag.StringVar(&toDate, "to", "", "Last day of invoices to reconcile, YYYY-MM-DD (defaults to from)")
+	flag.StringVar(&fixture, "fixture", "", "Read invoices from this JSON file instead of the payment gateway")
+	flag.StringVar(&format, "format", "csv", "Output format: csv or json")
+	flag.StringVar(&output, "output", "", "Write the report to this file instead of stdout")
+	flag.BoolVar(&fix, "fix", false, "Mark pending or expired reservations of paid invoices paid")
+	flag.Parse()
+
+	if fromDate == "" || (format != "csv" && format != "json") {
+		fmt.Println("Usage:")
+		fmt.Println("  go run cmd/reconcile/reconcile.go -from=2023-12-01 -to=2023-12-31             # CSV report from the gateway")
+		fmt.Println("  go run cmd/reconcile/reconcile.go -from=2023-12-01 -format=json              # JSON report")
+		fmt.Println("  go run cmd/reconcile/reconcile.go -from=2023-12-01 -fixture=invoices.json    # Offline, from a file")
+		fmt.Println("  go run cmd/reconcile/reconcile.go -from=2023-12-01 -fix                      # Fix the safe cases")
+		os.Exit(1)
+	}
+	if toDate == "" {
+		toDate = fromDate
+	}
+
+	from, err := time.ParseInLocation("2006-01-02", fromDate, time.Local)
+	if err != nil {
+		log.Fatal("Invalid from date:", err)
+	}
+	to, err := time.ParseInLocation("2006-01-02", toDate, time.Local)
+	if err != nil {
+		log.Fatal("Invalid to date:", err)
+	}
+	to = to.AddDate(0, 0, 1)
+
+	envErr := config.LoadEnvFiles()
+	cfg := config.LoadConfig()
+	logging.Setup(logging.Options{Level: cfg.LogLevel, Format: cfg.LogFormat, Secrets: cfg.Secrets()})
+	if envErr != nil {
+		slog.Warn(".env file not found or could not be loaded")
+	}
+	if err := cfg.Validate(); err != nil {
+		logging.Fatal(err.Error())
+	}
+
+	var lister services.InvoiceLister
+	if fixture != "" {
+		lister = services.NewInvoiceFixture(fixture)
+	} else if cfg.PaymentGateway == "fake" {
+		logging.Fatal("The fake payment gateway keeps its invoices in the server process; use -fixture or PAYMENT_GATEWAY=xendit")
+	}
+
+	db, err := gorm.Open(mysql.Open(cfg.GetDBDSN()), &gorm.Config{Logger: database.NewLogger()})
+	if err != nil {
+		logging.Fatal("failed to connect to database", "error", err.Error())
+	}
+
+	reservationRepo := repositories.NewReservationRepository(db)
+	courtRepo := repositories.NewCourtRepository(db)
+	timeslotRepo := repositories.NewTimeslotRepository(db)
+	paymentGateway := services.NewPaymentService(cfg.XenditUsername, cfg.XenditPassword, services.PaymentSettings{
+		BaseURL:         cfg.XenditBaseURL,
+		APIVersion:      cfg.XenditAPIVersion,
+		Currency:        cfg.PaymentCurrency,
+		InvoiceDuration: cfg.SlotHoldDuration,
+		SuccessURL:      cfg.PaymentSuccessURL,
+		FailureURL:      cfg.PaymentFailureURL,
+		ItemURL:         cfg.FrontendBaseURL,
+		Timeout:         cfg.XenditTimeout,
+	})
+	if lister == nil {
+		lister = paymentGateway
+	}
+
+	pricingService := services.NewPricingService(repositories.NewPricingRepository(db), courtRepo, timeslotRepo, cfg.DefaultSlotPrice)
+	voucherService := services.NewVoucherService(repositories.NewVoucherRepository(db), courtRepo, timeslotRepo)
+	blackoutService := services.NewBlackoutService(repositories.NewBlackoutRepository(db), courtRepo, timeslotRepo, reservationRepo)
+	reservationService := services.NewReservationService(reservationRepo, repositories.NewScheduleRepository(db), timeslotRepo, pricingService, voucherService, blackoutService, repositories.NewWaitlistRepository(db), repositories.NewRefundRepository(db), paymentGateway, services.CancellationPolicy{
+		FullRefundBefore:     cfg.CancelFullRefundBefore,
+		PartialRefundPercent: cfg.CancelPartialRefundPercent,
+	}, cfg.SlotHoldDuration, cfg.WaitlistOfferTTL)
+
+	ctx := logging.WithRequestID(context.Background(), "reconcile-"+logging.NewCorrelationID())
+	report, err := reservationService.Reconcile(ctx, lister, from, to, fix)
+	if err != nil {
+		logging.Fatal("failed to reconcile payments", "error", err.Error())
+	}
+
+	out := io.Writer(os.Stdout)
+	if output != "" {
+		file, err := os.Create(output)
+		if err != nil {
+			logging.Fatal("failed to create output file", "error", err.Error())
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if format == "json" {
+		err = writeJSON(out, report)
+	} else {
+		err = writeCSV(out, report)
+	}
+	if err != nil {
+		logging.Fatal("failed to write report", "error", err.Error())
+	}
+
+	slog.InfoContext(ctx, "reconciliation finished",
+		"invoices", report.InvoicesChecked, "reservations", report.ReservationsChecked, "mismatches", len(report.Mismatches))
+}
+
+// writeJSON writes the whole report as indented JSON
+func writeJSON(out io.Writer, report *services.ReconciliationReport) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// writeCSV writes one row per mismatch
+func writeCSV(out io.Writer, report *services.ReconciliationReport) error {
+	writer := csv.NewWriter(out)
+	if err := writer.Write([]string{
+		"kind", "invoice_id", "external_id", "invoice_status", "invoice_amount",
+		"reservation_ids", "reservation_statuses", "reservation_amount", "fixed", "note",
+	}); err != nil {
+		return err
+	}
+
+	for _, mismatch := range report.Mismatches {
+		ids := make([]string, 0, len(mismatch.ReservationIDs))
+		for _, id := range mismatch.ReservationIDs {
+			ids = append(ids, strconv.FormatUint(uint64(id), 10))
+		}
+		if err := writer.Write([]string{
+			mismatch.Kind,
+			mismatch.InvoiceID,
+			mismatch.ExternalID,
+			mismatch.InvoiceStatus,
+			strconv.FormatFloat(mismatch.InvoiceAmount, 'f', 2, 64),
+			strings.Join(ids, " "),
+			strings.Join(mismatch.ReservationStatuses, " "),
+			strconv.FormatFloat(mismatch.ReservationAmount, 'f', 2, 64),
+			strconv.FormatBool(mismatch.Fixed),
+			mismatch.Note,
+		}); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
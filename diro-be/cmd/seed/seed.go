@@ -9,8 +9,10 @@ import (
 	"os"
 	"time"
 
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"diro-be/internal/config"
 	"diro-be/internal/models"
@@ -61,6 +63,10 @@ func main() {
 
 // seedDatabase populates the database with initial data
 func seedDatabase(db *gorm.DB) error {
+	if err := seedAdmin(db); err != nil {
+		return err
+	}
+
 	// Seed courts
 	courts := []models.Court{
 		{
@@ -191,6 +197,60 @@ func seedDatabase(db *gorm.DB) error {
 	}
 	fmt.Println("Seeded 12 timeslots")
 
+	// Seed schedules: every court opens every day with every timeslot,
+	// except Lapangan C which opens at 10:00 on weekends
+	var schedules []models.CourtSchedule
+	for _, court := range courts {
+		for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+			weekend := weekday == time.Saturday || weekday == time.Sunday
+			for _, timeslot := range timeslots {
+				if court.Name == "Lapangan C" && weekend && timeslot.StartTime < "10:00" {
+					continue
+				}
+				schedules = append(schedules, models.CourtSchedule{
+					CourtID:       court.ID,
+					Weekday:       int(weekday),
+					TimeslotID:    timeslot.ID,
+					EffectiveFrom: time.Now().AddDate(0, 0, -1),
+				})
+			}
+		}
+	}
+
+	if err := db.Omit(clause.Associations).Create(&schedules).Error; err != nil {
+		return fmt.Errorf("failed to seed schedules: %w", err)
+	}
+	fmt.Printf("Seeded %d schedules\n", len(schedules))
+
+	// Seed price rules: evening peak hours and weekends cost more than the
+	// default price, holidays the most
+	saturday, sunday := int(time.Saturday), int(time.Sunday)
+	priceRules := []models.PriceRule{
+		{Name: "Jam sibuk", StartTime: "18:00", EndTime: "22:00", Price: 75000, Priority: 10, IsActive: true},
+		{Name: "Sabtu", Weekday: &saturday, Price: 65000, Priority: 20, IsActive: true},
+		{Name: "Minggu", Weekday: &sunday, Price: 65000, Priority: 20, IsActive: true},
+		{Name: "Hari libur", HolidayOnly: true, Price: 80000, Priority: 30, IsActive: true},
+	}
+
+	if err := db.Create(&priceRules).Error; err != nil {
+		return fmt.Errorf("failed to seed price rules: %w", err)
+	}
+	fmt.Printf("Seeded %d price rules\n", len(priceRules))
+
+	// Seed a voucher for off-peak morning slots
+	voucher := models.Voucher{
+		Code:          "PAGI20",
+		Description:   "Diskon 20% untuk jadwal pagi",
+		DiscountType:  models.DiscountTypePercentage,
+		DiscountValue: 20,
+		EndTime:       "12:00",
+		IsActive:      true,
+	}
+	if err := db.Create(&voucher).Error; err != nil {
+		return fmt.Errorf("failed to seed vouchers: %w", err)
+	}
+	fmt.Println("Seeded voucher", voucher.Code)
+
 	// Get the first court and timeslot IDs to use for reservations
 	var firstCourt models.Court
 	var firstTimeslot models.Timeslot
@@ -208,7 +268,7 @@ func seedDatabase(db *gorm.DB) error {
 			CourtID:    firstCourt.ID,    // First court
 			TimeslotID: firstTimeslot.ID, // First timeslot
 			Date:       tomorrow,
-			Status:     "confirmed",
+			Status:     models.ReservationStatusPaid,
 			TotalPrice: 50000,
 			CreatedAt:  time.Now(),
 			UpdatedAt:  time.Now(),
@@ -217,7 +277,7 @@ func seedDatabase(db *gorm.DB) error {
 			CourtID:    firstCourt.ID,
 			TimeslotID: firstTimeslot.ID + 1, // Second timeslot
 			Date:       tomorrow,
-			Status:     "pending",
+			Status:     models.ReservationStatusPending,
 			TotalPrice: 50000,
 			CreatedAt:  time.Now(),
 			UpdatedAt:  time.Now(),
@@ -226,7 +286,7 @@ func seedDatabase(db *gorm.DB) error {
 			CourtID:    firstCourt.ID + 1,    // Second court
 			TimeslotID: firstTimeslot.ID + 2, // Third timeslot
 			Date:       tomorrow,
-			Status:     "confirmed",
+			Status:     models.ReservationStatusPaid,
 			TotalPrice: 50000,
 			CreatedAt:  time.Now(),
 			UpdatedAt:  time.Now(),
@@ -235,7 +295,7 @@ func seedDatabase(db *gorm.DB) error {
 			CourtID:    firstCourt.ID + 2,           // Third court
 			TimeslotID: firstTimeslot.ID + 4,        // Fifth timeslot
 			Date:       time.Now().AddDate(0, 0, 2), // Day after tomorrow
-			Status:     "cancelled",
+			Status:     models.ReservationStatusCancelled,
 			TotalPrice: 50000,
 			CreatedAt:  time.Now(),
 			UpdatedAt:  time.Now(),
@@ -244,7 +304,7 @@ func seedDatabase(db *gorm.DB) error {
 			CourtID:    firstCourt.ID + 1,           // Second court
 			TimeslotID: firstTimeslot.ID + 6,        // Seventh timeslot
 			Date:       time.Now().AddDate(0, 0, 3), // 3 days from now
-			Status:     "confirmed",
+			Status:     models.ReservationStatusPaid,
 			TotalPrice: 50000,
 			CreatedAt:  time.Now(),
 			UpdatedAt:  time.Now(),
@@ -259,14 +319,82 @@ func seedDatabase(db *gorm.DB) error {
 	return nil
 }
 
+// seedAdmin creates the venue staff account from SEED_ADMIN_EMAIL and
+// SEED_ADMIN_PASSWORD, skipping it when no password is configured
+func seedAdmin(db *gorm.DB) error {
+	password := os.Getenv("SEED_ADMIN_PASSWORD")
+	if password == "" {
+		fmt.Println("SEED_ADMIN_PASSWORD not set, skipping admin account")
+		return nil
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash admin password: %w", err)
+	}
+
+	admin := models.User{
+		Name:         "Admin",
+		Email:        getEnv("SEED_ADMIN_EMAIL", "admin@diro.local"),
+		PasswordHash: string(hash),
+		Role:         models.UserRoleAdmin,
+	}
+	if err := db.Create(&admin).Error; err != nil {
+		return fmt.Errorf("failed to seed admin account: %w", err)
+	}
+	fmt.Println("Seeded admin account", admin.Email)
+
+	return nil
+}
+
 // clearDatabase removes all seeded data
 func clearDatabase(db *gorm.DB) error {
 	// Clear in reverse order due to foreign key constraints
+	if err := db.Exec("DELETE FROM waitlist_entries").Error; err != nil {
+		return fmt.Errorf("failed to clear waitlist entries: %w", err)
+	}
+	fmt.Println("Cleared waitlist entries")
+
+	if err := db.Exec("DELETE FROM refunds").Error; err != nil {
+		return fmt.Errorf("failed to clear refunds: %w", err)
+	}
+	fmt.Println("Cleared refunds")
+
+	if err := db.Exec("DELETE FROM reservation_status_history").Error; err != nil {
+		return fmt.Errorf("failed to clear reservation status history: %w", err)
+	}
+	fmt.Println("Cleared reservation status history")
+
 	if err := db.Exec("DELETE FROM reservations").Error; err != nil {
 		return fmt.Errorf("failed to clear reservations: %w", err)
 	}
 	fmt.Println("Cleared reservations")
 
+	if err := db.Exec("DELETE FROM reservation_series").Error; err != nil {
+		return fmt.Errorf("failed to clear reservation series: %w", err)
+	}
+	fmt.Println("Cleared reservation series")
+
+	if err := db.Exec("DELETE FROM blackouts").Error; err != nil {
+		return fmt.Errorf("failed to clear blackouts: %w", err)
+	}
+	fmt.Println("Cleared blackouts")
+
+	if err := db.Exec("DELETE FROM vouchers").Error; err != nil {
+		return fmt.Errorf("failed to clear vouchers: %w", err)
+	}
+	fmt.Println("Cleared vouchers")
+
+	if err := db.Exec("DELETE FROM price_rules").Error; err != nil {
+		return fmt.Errorf("failed to clear price rules: %w", err)
+	}
+	fmt.Println("Cleared price rules")
+
+	if err := db.Exec("DELETE FROM court_schedules").Error; err != nil {
+		return fmt.Errorf("failed to clear court schedules: %w", err)
+	}
+	fmt.Println("Cleared court schedules")
+
 	if err := db.Exec("DELETE FROM timeslots").Error; err != nil {
 		return fmt.Errorf("failed to clear timeslots: %w", err)
 	}
@@ -279,3 +407,11 @@ func clearDatabase(db *gorm.DB) error {
 
 	return nil
 }
+
+// getEnv retrieves an environment variable value or returns a default value
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,76 @@
+// Package main provides a one-off invoice sweep
+// This command-line tool reconciles pending reservations whose invoice expired
+// with the payment gateway, like the sweep the server runs on a schedule
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+
+	"diro-be/internal/config"
+	"diro-be/internal/database"
+	"diro-be/internal/logging"
+	"diro-be/internal/repositories"
+	"diro-be/internal/services"
+)
+
+// main is the entry point for the sweep command
+func main() {
+	envErr := config.LoadEnvFiles()
+
+	cfg := config.LoadConfig()
+	logging.Setup(logging.Options{Level: cfg.LogLevel, Format: cfg.LogFormat, Secrets: cfg.Secrets()})
+	if envErr != nil {
+		slog.Warn(".env file not found or could not be loaded")
+	}
+	if err := cfg.Validate(); err != nil {
+		logging.Fatal(err.Error())
+	}
+	if cfg.PaymentGateway == "fake" {
+		logging.Fatal("The fake payment gateway keeps its invoices in the server process; run the sweep with PAYMENT_GATEWAY=xendit")
+	}
+
+	db, err := gorm.Open(mysql.Open(cfg.GetDBDSN()), &gorm.Config{Logger: database.NewLogger()})
+	if err != nil {
+		logging.Fatal("failed to connect to database", "error", err.Error())
+	}
+
+	reservationRepo := repositories.NewReservationRepository(db)
+	courtRepo := repositories.NewCourtRepository(db)
+	timeslotRepo := repositories.NewTimeslotRepository(db)
+	paymentGateway := services.NewPaymentService(cfg.XenditUsername, cfg.XenditPassword, services.PaymentSettings{
+		BaseURL:         cfg.XenditBaseURL,
+		APIVersion:      cfg.XenditAPIVersion,
+		Currency:        cfg.PaymentCurrency,
+		InvoiceDuration: cfg.SlotHoldDuration,
+		SuccessURL:      cfg.PaymentSuccessURL,
+		FailureURL:      cfg.PaymentFailureURL,
+		ItemURL:         cfg.FrontendBaseURL,
+		Timeout:         cfg.XenditTimeout,
+	})
+
+	pricingService := services.NewPricingService(repositories.NewPricingRepository(db), courtRepo, timeslotRepo, cfg.DefaultSlotPrice)
+	voucherService := services.NewVoucherService(repositories.NewVoucherRepository(db), courtRepo, timeslotRepo)
+	blackoutService := services.NewBlackoutService(repositories.NewBlackoutRepository(db), courtRepo, timeslotRepo, reservationRepo)
+	reservationService := services.NewReservationService(reservationRepo, repositories.NewScheduleRepository(db), timeslotRepo, pricingService, voucherService, blackoutService, repositories.NewWaitlistRepository(db), repositories.NewRefundRepository(db), paymentGateway, services.CancellationPolicy{
+		FullRefundBefore:     cfg.CancelFullRefundBefore,
+		PartialRefundPercent: cfg.CancelPartialRefundPercent,
+	}, cfg.SlotHoldDuration, cfg.WaitlistOfferTTL)
+
+	ctx, cancel := context.WithTimeout(logging.WithRequestID(context.Background(), "sweep-"+logging.NewCorrelationID()), cfg.SweepTimeout)
+	defer cancel()
+	summary, err := reservationService.SweepLapsedHolds(ctx, time.Now())
+	if summary != nil {
+		slog.InfoContext(ctx, "invoice sweep", "summary", *summary)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to sweep lapsed slot holds", "error", err.Error())
+		cancel()
+		os.Exit(1)
+	}
+}
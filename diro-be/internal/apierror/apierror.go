@@ -0,0 +1,220 @@
+// Package apierror writes JSON error responses carrying a stable error code
+// clients can rely on instead of the message
+package apierror
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"diro-be/internal/logging"
+	"diro-be/internal/repositories"
+	"diro-be/internal/services"
+)
+
+// Generic error codes, used for errors without a code of their own
+const (
+	CodeInvalidRequest = "invalid_request"
+	CodeUnauthorized   = "unauthorized"
+	CodeForbidden      = "forbidden"
+	CodeNotFound       = "not_found"
+	CodeConflict       = "conflict"
+	CodeGatewayError   = "payment_gateway_error"
+	CodeTimeout        = "timeout"
+	CodeCancelled      = "request_cancelled"
+	CodeInternal       = "internal_error"
+)
+
+// StatusClientClosedRequest is reported for requests abandoned because the
+// client disconnected; nobody receives the response, but it is logged
+const StatusClientClosedRequest = 499
+
+// codes maps known errors to their codes. Wrapped errors match too, and the
+// first match wins, so more specific errors come first.
+var codes = []struct {
+	err  error
+	code string
+}{
+	// Auth
+	{services.ErrInvalidCredentials, "invalid_credentials"},
+	{services.ErrInvalidToken, "invalid_token"},
+	{repositories.ErrEmailTaken, "email_taken"},
+
+	// Booking
+	{repositories.ErrSlotTaken, "slot_taken"},
+	{services.ErrSlotNotScheduled, "slot_not_scheduled"},
+	{services.ErrSlotBlackedOut, "slot_blacked_out"},
+	{services.ErrSlotOffered, "slot_offered"},
+	{services.ErrInvalidBooking, "invalid_booking"},
+	{services.ErrInvalidRange, "invalid_range"},
+	{services.ErrInvalidPromoCode, "invalid_promo_code"},
+	{repositories.ErrVoucherUsedUp, "promo_code_used_up"},
+	{services.ErrReservationNotFound, "reservation_not_found"},
+	{services.ErrInvalidTransition, "invalid_status_transition"},
+	{repositories.ErrStaleReservation, "concurrent_modification"},
+
+	// Series and waitlist
+	{services.ErrSeriesNotFound, "series_not_found"},
+	{services.ErrInvalidSeries, "invalid_series"},
+	{services.ErrNothingToBook, "nothing_to_book"},
+	{services.ErrWaitlistEntryNotFound, "waitlist_entry_not_found"},
+	{services.ErrInvalidWaitlistEntry, "invalid_waitlist_entry"},
+	{services.ErrAlreadyWaitlisted, "already_waitlisted"},
+	{services.ErrSlotAvailable, "slot_available"},
+	{services.ErrNoOpenOffer, "no_open_offer"},
+	{repositories.ErrStaleWaitlistEntry, "concurrent_modification"},
+
+	// Payments
+	{services.ErrWebhookMismatch, "webhook_mismatch"},
+	{services.ErrRefundNotFound, "refund_not_found"},
+	{services.ErrInvalidRefund, "invalid_refund"},
+	{services.ErrNotRefundable, "not_refundable"},
+	{repositories.ErrDuplicateRefund, "duplicate_refund"},
+	{services.ErrInvoiceNotFound, "invoice_not_found"},
+	{services.ErrGatewayFailure, CodeGatewayError},
+
+	// Venue management
+	{services.ErrCourtNotFound, "court_not_found"},
+	{services.ErrInvalidCourt, "invalid_court"},
+	{services.ErrTimeslotNotFound, "timeslot_not_found"},
+	{services.ErrInvalidTimeslot, "invalid_timeslot"},
+	{services.ErrScheduleNotFound, "schedule_not_found"},
+	{services.ErrInvalidSchedule, "invalid_schedule"},
+	{services.ErrBlackoutNotFound, "blackout_not_found"},
+	{services.ErrInvalidBlackout, "invalid_blackout"},
+	{services.ErrPriceRuleNotFound, "price_rule_not_found"},
+	{services.ErrInvalidPriceRule, "invalid_price_rule"},
+	{services.ErrHolidayNotFound, "holiday_not_found"},
+	{services.ErrInvalidHoliday, "invalid_holiday"},
+	{repositories.ErrHolidayExists, "holiday_exists"},
+	{services.ErrVoucherNotFound, "voucher_not_found"},
+	{services.ErrInvalidVoucher, "invalid_voucher"},
+	{repositories.ErrVoucherCodeTaken, "voucher_code_taken"},
+
+	{gorm.ErrRecordNotFound, CodeNotFound},
+}
+
+// Code returns the stable code of err, or the generic code of the HTTP status
+// it is reported with
+func Code(status int, err error) string {
+	switch contextStatus(http.StatusInternalServerError, err) {
+	case http.StatusGatewayTimeout:
+		return CodeTimeout
+	case StatusClientClosedRequest:
+		return CodeCancelled
+	}
+
+	var courtInUse *services.CourtInUseError
+	var timeslotInUse *services.TimeslotInUseError
+	switch {
+	case errors.As(err, &courtInUse):
+		return "court_in_use"
+	case errors.As(err, &timeslotInUse):
+		return "timeslot_in_use"
+	}
+
+	for _, known := range codes {
+		if errors.Is(err, known.err) {
+			return known.code
+		}
+	}
+
+	switch {
+	case status == http.StatusUnauthorized:
+		return CodeUnauthorized
+	case status == http.StatusForbidden:
+		return CodeForbidden
+	case status == http.StatusNotFound:
+		return CodeNotFound
+	case status == http.StatusConflict:
+		return CodeConflict
+	case status == http.StatusBadGateway:
+		return CodeGatewayError
+	case status == http.StatusGatewayTimeout:
+		return CodeTimeout
+	case status == StatusClientClosedRequest:
+		return CodeCancelled
+	case status >= http.StatusInternalServerError:
+		return CodeInternal
+	}
+	return CodeInvalidRequest
+}
+
+// Respond aborts the request with err as {"error": message, "code": code}.
+// Server and gateway errors may carry internal details, so they are logged
+// and the client only gets a generic message with a correlation ID, the
+// request ID, to find the log line by.
+func Respond(c *gin.Context, status int, err error) {
+	RespondWith(c, status, err, nil)
+}
+
+// RespondWith responds like Respond, adding the extra fields to the body
+func RespondWith(c *gin.Context, status int, err error, extra gin.H) {
+	status = contextStatus(status, err)
+
+	body := gin.H{}
+	for key, value := range extra {
+		body[key] = value
+	}
+	body["code"] = Code(status, err)
+
+	if status >= http.StatusInternalServerError || status == StatusClientClosedRequest {
+		ctx := c.Request.Context()
+		correlationID := logging.RequestID(ctx)
+		if correlationID == "" {
+			correlationID = logging.NewCorrelationID()
+			ctx = logging.WithRequestID(ctx, correlationID)
+		}
+		level := slog.LevelError
+		if status == StatusClientClosedRequest {
+			level = slog.LevelWarn
+		}
+		slog.Log(ctx, level, "request failed",
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", status,
+			"error", err.Error())
+
+		body["error"] = "internal server error"
+		switch status {
+		case http.StatusBadGateway:
+			body["error"] = services.ErrGatewayFailure.Error()
+		case http.StatusGatewayTimeout:
+			body["error"] = "request timed out"
+		}
+		body["correlation_id"] = correlationID
+	} else {
+		body["error"] = err.Error()
+	}
+
+	c.AbortWithStatusJSON(status, body)
+}
+
+// contextStatus reports server errors caused by the request running out of
+// time as 504 Gateway Timeout, and those caused by the client disconnecting
+// as 499
+func contextStatus(status int, err error) int {
+	if status < http.StatusInternalServerError {
+		return status
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled):
+		return StatusClientClosedRequest
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return http.StatusGatewayTimeout
+	}
+	return status
+}
+
+// Message aborts the request with a message not backed by an error value,
+// coded by its status
+func Message(c *gin.Context, status int, message string) {
+	Respond(c, status, errors.New(message))
+}
@@ -1,24 +1,119 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
 )
 
+// EnvProduction is the AppEnv of production deployments
+const EnvProduction = "production"
+
+// ReservationIDPlaceholder is replaced with the reservation ID in the payment
+// redirect URLs
+const ReservationIDPlaceholder = "{reservation_id}"
+
+// maxSlotHoldDuration is the longest invoice duration Xendit accepts
+const maxSlotHoldDuration = 365 * 24 * time.Hour
+
+// currencyPattern matches ISO 4217 currency codes
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
 // Config holds all configuration for the application
 type Config struct {
+	// AppEnv names the deployment environment, e.g. "development" or
+	// "production"
+	AppEnv string
+
+	// LogLevel is the least severe level logged: debug, info, warn or error.
+	// At debug every SQL query is logged.
+	LogLevel string
+	// LogFormat is json for one JSON object per line, or text
+	LogFormat string
+
 	DBHost         string
 	DBPort         string
 	DBUser         string
-	DBPassword     string
+	DBPassword     string `secret:"true"`
 	DBName         string
-	XenditUsername string
-	XenditPassword string
+	XenditUsername string `secret:"true"` // Xendit secret API key
+	XenditPassword string `secret:"true"`
+	// XenditCallbackToken verifies that webhooks were sent by Xendit
+	XenditCallbackToken string `secret:"true"`
+
+	// PaymentGateway selects the payment provider: "xendit" or "fake"
+	PaymentGateway string
+	// AppBaseURL is the public URL of this server, used by the fake gateway
+	AppBaseURL string
+	// FrontendBaseURL is the public URL of the customer frontend, linked from
+	// invoice items
+	FrontendBaseURL string
+	// PaymentSuccessURL and PaymentFailureURL are where customers land after
+	// paying an invoice or failing to. ReservationIDPlaceholder is replaced
+	// with the ID of the first reservation on the invoice.
+	PaymentSuccessURL string
+	PaymentFailureURL string
+	// PaymentCurrency is the ISO 4217 code invoices are issued in
+	PaymentCurrency string
+	// XenditBaseURL and XenditAPIVersion address the Xendit API
+	XenditBaseURL    string
+	XenditAPIVersion string
+	// XenditTimeout is the deadline of each call to the payment gateway
+	XenditTimeout time.Duration
+
+	// RequestTimeout bounds how long the server works on a request, its
+	// database queries and gateway calls included
+	RequestTimeout time.Duration
+
+	// SlotHoldDuration is how long a pending reservation holds its slot. Its
+	// invoice stays payable for exactly as long.
+	SlotHoldDuration time.Duration
+
+	// HoldExpiryInterval is how often lapsed slot holds are released
+	HoldExpiryInterval time.Duration
+	// SweepTimeout bounds each run of the hold expirer and the sweep command
+	SweepTimeout time.Duration
+	// WaitlistOfferTTL is how long a waitlisted customer has to book a slot
+	// offered to them before it goes to the next in line
+	WaitlistOfferTTL time.Duration
+
+	// CancelFullRefundBefore is how long before the slot a cancellation is
+	// refunded in full; later cancellations get CancelPartialRefundPercent
+	CancelFullRefundBefore     time.Duration
+	CancelPartialRefundPercent float64
+
+	// DefaultSlotPrice is charged for slots no price rule matches
+	DefaultSlotPrice float64
+
+	// JWTSecret signs customer access and refresh tokens
+	JWTSecret       string `secret:"true"`
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// parseErrors lists the settings that could not be parsed, reported by
+	// Validate
+	parseErrors []string
 }
 
 // LoadConfig loads configuration from environment variables
 func LoadConfig() *Config {
-	return &Config{
+	frontendBaseURL := strings.TrimRight(getEnv("FRONTEND_BASE_URL", "http://localhost:3000"), "/")
+	env := &envParser{}
+
+	cfg := &Config{
+		AppEnv: getEnv("APP_ENV", "development"),
+
+		LogLevel:  strings.ToLower(getEnv("LOG_LEVEL", "info")),
+		LogFormat: strings.ToLower(getEnv("LOG_FORMAT", "json")),
+
 		DBHost:         getEnv("DB_HOST", "localhost"),
 		DBPort:         getEnv("DB_PORT", "3306"),
 		DBUser:         getEnv("DB_USER", "root"),
@@ -26,7 +121,170 @@ func LoadConfig() *Config {
 		DBName:         getEnv("DB_NAME", "diro_db"),
 		XenditUsername: getEnv("XENDIT_USERNAME", ""),
 		XenditPassword: getEnv("XENDIT_PASSWORD", ""),
+
+		XenditCallbackToken: getEnv("XENDIT_CALLBACK_TOKEN", ""),
+
+		PaymentGateway: getEnv("PAYMENT_GATEWAY", "xendit"),
+		AppBaseURL:     getEnv("APP_BASE_URL", "http://localhost:8080"),
+
+		FrontendBaseURL:   frontendBaseURL,
+		PaymentSuccessURL: getEnv("PAYMENT_SUCCESS_URL", frontendBaseURL+"/success?reservation_id="+ReservationIDPlaceholder),
+		PaymentFailureURL: getEnv("PAYMENT_FAILURE_URL", frontendBaseURL+"/failed?reservation_id="+ReservationIDPlaceholder),
+		PaymentCurrency:   strings.ToUpper(getEnv("PAYMENT_CURRENCY", "IDR")),
+		XenditBaseURL:     strings.TrimRight(getEnv("XENDIT_BASE_URL", "https://api.xendit.co"), "/"),
+		XenditAPIVersion:  getEnv("XENDIT_API_VERSION", "2020-02-01"),
+		XenditTimeout:     env.duration("XENDIT_TIMEOUT", 15*time.Second),
+
+		RequestTimeout: env.duration("REQUEST_TIMEOUT", 30*time.Second),
+
+		SlotHoldDuration: env.duration("SLOT_HOLD_DURATION", 24*time.Hour),
+
+		HoldExpiryInterval: env.duration("HOLD_EXPIRY_INTERVAL", time.Minute),
+		SweepTimeout:       env.duration("SWEEP_TIMEOUT", 5*time.Minute),
+		WaitlistOfferTTL:   env.duration("WAITLIST_OFFER_TTL", 30*time.Minute),
+
+		CancelFullRefundBefore:     env.duration("CANCEL_FULL_REFUND_BEFORE", 24*time.Hour),
+		CancelPartialRefundPercent: env.float("CANCEL_PARTIAL_REFUND_PERCENT", 50),
+
+		DefaultSlotPrice: env.float("DEFAULT_SLOT_PRICE", 50000),
+
+		JWTSecret:       getEnv("JWT_SECRET", ""),
+		AccessTokenTTL:  env.duration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL: env.duration("REFRESH_TOKEN_TTL", 7*24*time.Hour),
+	}
+	cfg.parseErrors = env.problems
+	return cfg
+}
+
+// Secrets returns the values of the settings tagged secret:"true", which must
+// never be logged or shown to clients
+func (c *Config) Secrets() []string {
+	var secrets []string
+	value := reflect.ValueOf(c).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		if value.Type().Field(i).Tag.Get("secret") == "true" && value.Field(i).String() != "" {
+			secrets = append(secrets, value.Field(i).String())
+		}
+	}
+	return secrets
+}
+
+// LoadEnvFiles loads .env.<APP_ENV> and then .env into the environment.
+// Variables that are already set are never replaced, so the environment
+// overrides .env.<APP_ENV>, which overrides .env. APP_ENV itself may be set
+// in .env. It returns the error of loading .env.
+func LoadEnvFiles() error {
+	env := os.Getenv("APP_ENV")
+	if env == "" {
+		if values, err := godotenv.Read(); err == nil {
+			env = values["APP_ENV"]
+		}
+	}
+
+	if env != "" {
+		if err := godotenv.Load(".env." + env); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to load .env.%s: %w", env, err)
+		}
 	}
+	return godotenv.Load()
+}
+
+// Validate checks that every setting could be parsed, the logging, duration,
+// pricing and payment settings, and that production does not use the fake
+// gateway or send customers to localhost. It reports every problem at once.
+func (c *Config) Validate() error {
+	problems := append([]string(nil), c.parseErrors...)
+	problem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	switch c.LogLevel {
+	case "debug", "info", "warn", "error":
+	default:
+		problem("LOG_LEVEL must be debug, info, warn or error")
+	}
+	if c.LogFormat != "json" && c.LogFormat != "text" {
+		problem("LOG_FORMAT must be json or text")
+	}
+
+	if c.PaymentGateway != "xendit" && c.PaymentGateway != "fake" {
+		problem("PAYMENT_GATEWAY must be xendit or fake")
+	}
+	if !currencyPattern.MatchString(c.PaymentCurrency) {
+		problem("PAYMENT_CURRENCY must be a three letter ISO 4217 code")
+	}
+	if c.XenditAPIVersion == "" {
+		problem("XENDIT_API_VERSION must be set")
+	}
+	durations := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"XENDIT_TIMEOUT", c.XenditTimeout},
+		{"REQUEST_TIMEOUT", c.RequestTimeout},
+		{"HOLD_EXPIRY_INTERVAL", c.HoldExpiryInterval},
+		{"SWEEP_TIMEOUT", c.SweepTimeout},
+		{"WAITLIST_OFFER_TTL", c.WaitlistOfferTTL},
+		{"ACCESS_TOKEN_TTL", c.AccessTokenTTL},
+		{"REFRESH_TOKEN_TTL", c.RefreshTokenTTL},
+	}
+	for _, d := range durations {
+		if d.value <= 0 {
+			problem("%s must be positive", d.name)
+		}
+	}
+	if c.SlotHoldDuration < time.Minute || c.SlotHoldDuration > maxSlotHoldDuration {
+		problem("SLOT_HOLD_DURATION must be between 1m and %s", maxSlotHoldDuration)
+	}
+	if c.CancelFullRefundBefore < 0 {
+		problem("CANCEL_FULL_REFUND_BEFORE must not be negative")
+	}
+	if c.CancelPartialRefundPercent < 0 || c.CancelPartialRefundPercent > 100 {
+		problem("CANCEL_PARTIAL_REFUND_PERCENT must be between 0 and 100")
+	}
+	if c.DefaultSlotPrice <= 0 {
+		problem("DEFAULT_SLOT_PRICE must be positive")
+	}
+
+	urls := []struct {
+		name  string
+		value string
+	}{
+		{"APP_BASE_URL", c.AppBaseURL},
+		{"FRONTEND_BASE_URL", c.FrontendBaseURL},
+		{"PAYMENT_SUCCESS_URL", c.PaymentSuccessURL},
+		{"PAYMENT_FAILURE_URL", c.PaymentFailureURL},
+		{"XENDIT_BASE_URL", c.XenditBaseURL},
+	}
+	for _, u := range urls {
+		parsed, err := url.Parse(strings.ReplaceAll(u.value, ReservationIDPlaceholder, "1"))
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			problem("%s must be an absolute http or https URL", u.name)
+			continue
+		}
+		if c.AppEnv == EnvProduction && isLocalHost(parsed.Hostname()) {
+			problem("%s must not point to %s in production", u.name, parsed.Hostname())
+		}
+	}
+
+	if c.AppEnv == EnvProduction {
+		if c.PaymentGateway == "fake" {
+			problem("PAYMENT_GATEWAY must not be fake in production")
+		}
+		if c.XenditUsername == "" || c.XenditCallbackToken == "" {
+			problem("XENDIT_USERNAME and XENDIT_CALLBACK_TOKEN must be set in production")
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// isLocalHost reports whether a host name only resolves on the local machine
+func isLocalHost(host string) bool {
+	return host == "localhost" || strings.HasPrefix(host, "127.") || host == "::1" || host == "0.0.0.0"
 }
 
 // GetDBDSN returns the Data Source Name for MySQL
@@ -41,3 +299,35 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// envParser reads typed settings from the environment, remembering those
+// that could not be parsed instead of silently using their default
+type envParser struct {
+	problems []string
+}
+
+func (p *envParser) duration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		p.problems = append(p.problems, fmt.Sprintf("%s must be a duration with a unit, such as 30s, 15m or 24h", key))
+		return defaultValue
+	}
+	return d
+}
+
+func (p *envParser) float(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		p.problems = append(p.problems, fmt.Sprintf("%s must be a number", key))
+		return defaultValue
+	}
+	return f
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateReportsUnparsableSettings(t *testing.T) {
+	t.Setenv("SLOT_HOLD_DURATION", "15")
+	t.Setenv("DEFAULT_SLOT_PRICE", "abc")
+
+	err := LoadConfig().Validate()
+	if err == nil {
+		t.Fatal("Validate() error = nil, want the unparsable settings reported")
+	}
+	for _, name := range []string{"SLOT_HOLD_DURATION", "DEFAULT_SLOT_PRICE"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("Validate() error = %q, want it to name %s", err, name)
+		}
+	}
+}
+
+func TestValidateChecksRanges(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		value string
+	}{
+		{"refund percent above 100", "CANCEL_PARTIAL_REFUND_PERCENT", "150"},
+		{"negative refund percent", "CANCEL_PARTIAL_REFUND_PERCENT", "-1"},
+		{"free slots", "DEFAULT_SLOT_PRICE", "0"},
+		{"zero expiry interval", "HOLD_EXPIRY_INTERVAL", "0s"},
+		{"negative expiry interval", "HOLD_EXPIRY_INTERVAL", "-1m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.key, tt.value)
+
+			err := LoadConfig().Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.key) {
+				t.Fatalf("Validate() error = %v, want %s reported", err, tt.key)
+			}
+		})
+	}
+}
+
+func TestValidateDefaults(t *testing.T) {
+	t.Setenv("APP_ENV", "development")
+
+	if err := LoadConfig().Validate(); err != nil {
+		t.Fatalf("Validate() of the defaults error = %v", err)
+	}
+}
@@ -2,7 +2,7 @@ package database
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 
 	mysqlgorm "gorm.io/driver/mysql"
 	"gorm.io/gorm"
@@ -17,23 +17,29 @@ var DB *gorm.DB
 // Connect establishes a connection to the MySQL database
 func Connect(cfg *config.Config) error {
 	dsn := cfg.GetDBDSN()
-	db, err := gorm.Open(mysqlgorm.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(mysqlgorm.Open(dsn), &gorm.Config{Logger: NewLogger()})
 	if err != nil {
 		return fmt.Errorf("failed to connect to database: %w", err)
 	}
 
 	DB = db
-	log.Println("Connected to database successfully")
+	slog.Info("Connected to database")
 
 	// Auto migrate the schema
-	if err := db.AutoMigrate(&models.Court{}, &models.Timeslot{}, &models.Reservation{}); err != nil {
+	if err := Migrate(db); err != nil {
 		return fmt.Errorf("failed to migrate database: %w", err)
 	}
 
-	log.Println("Database migration completed")
+	slog.Info("Database migration completed")
 	return nil
 }
 
+// Migrate creates or updates the tables of every model
+func Migrate(db *gorm.DB) error {
+	return db.AutoMigrate(&models.User{}, &models.Court{}, &models.Timeslot{}, &models.CourtSchedule{}, &models.PriceRule{}, &models.Holiday{},
+		&models.Blackout{}, &models.Voucher{}, &models.ReservationSeries{}, &models.Reservation{}, &models.ReservationStatusHistory{}, &models.WebhookEvent{}, &models.WaitlistEntry{}, &models.Refund{})
+}
+
 // Close closes the database connection
 func Close() error {
 	if DB != nil {
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"diro-be/internal/apierror"
+	"diro-be/internal/middleware"
+	"diro-be/internal/repositories"
+	"diro-be/internal/services"
+)
+
+// AuthHandler handles customer account HTTP requests
+type AuthHandler struct {
+	authService *services.AuthService
+}
+
+// NewAuthHandler creates a new auth handler
+func NewAuthHandler(authService *services.AuthService) *AuthHandler {
+	return &AuthHandler{
+		authService: authService,
+	}
+}
+
+// Register godoc
+// @Summary Register a customer account
+// @Description Create a customer account and return an access and refresh token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param account body object true "name, email, mobile_number, password"
+// @Success 201 {object} map[string]interface{} "user: object, tokens: models.AuthTokens"
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 409 {object} map[string]string "error: message"
+// @Router /api/v1/auth/register [post]
+func (h *AuthHandler) Register(c *gin.Context) {
+	var req struct {
+		Name         string `json:"name" binding:"required"`
+		Email        string `json:"email" binding:"required,email"`
+		MobileNumber string `json:"mobile_number"`
+		Password     string `json:"password" binding:"required,min=8,max=72"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err)
+		return
+	}
+
+	user, tokens, err := h.authService.Register(c.Request.Context(), req.Name, req.Email, req.MobileNumber, req.Password)
+	if errors.Is(err, repositories.ErrEmailTaken) {
+		apierror.Respond(c, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"user":   user,
+		"tokens": tokens,
+	})
+}
+
+// Login godoc
+// @Summary Log in
+// @Description Exchange an email and password for an access and refresh token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param credentials body object true "email, password"
+// @Success 200 {object} map[string]interface{} "user: object, tokens: models.AuthTokens"
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 401 {object} map[string]string "error: message"
+// @Router /api/v1/auth/login [post]
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req struct {
+		Email    string `json:"email" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err)
+		return
+	}
+
+	user, tokens, err := h.authService.Login(c.Request.Context(), req.Email, req.Password)
+	if errors.Is(err, services.ErrInvalidCredentials) {
+		apierror.Respond(c, http.StatusUnauthorized, err)
+		return
+	}
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":   user,
+		"tokens": tokens,
+	})
+}
+
+// Refresh godoc
+// @Summary Refresh tokens
+// @Description Exchange a refresh token for a new access and refresh token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param token body object true "refresh_token"
+// @Success 200 {object} models.AuthTokens
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 401 {object} map[string]string "error: message"
+// @Router /api/v1/auth/refresh [post]
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err)
+		return
+	}
+
+	tokens, err := h.authService.Refresh(c.Request.Context(), req.RefreshToken)
+	if errors.Is(err, services.ErrInvalidToken) {
+		apierror.Respond(c, http.StatusUnauthorized, err)
+		return
+	}
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Me godoc
+// @Summary Get current account
+// @Description Get the account of the authenticated customer
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} models.User
+// @Failure 401 {object} map[string]string "error: message"
+// @Router /api/v1/me [get]
+func (h *AuthHandler) Me(c *gin.Context) {
+	userID, _ := middleware.UserID(c)
+
+	user, err := h.authService.GetUser(c.Request.Context(), userID)
+	if err != nil {
+		apierror.Message(c, http.StatusUnauthorized, "account not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"diro-be/internal/apierror"
+	"diro-be/internal/models"
+	"diro-be/internal/services"
+)
+
+// BlackoutHandler handles court blackout HTTP requests from venue staff
+type BlackoutHandler struct {
+	blackoutService *services.BlackoutService
+}
+
+// NewBlackoutHandler creates a new blackout handler
+func NewBlackoutHandler(blackoutService *services.BlackoutService) *BlackoutHandler {
+	return &BlackoutHandler{
+		blackoutService: blackoutService,
+	}
+}
+
+// blackoutRequest is the body for creating or replacing a blackout. Times
+// use the YYYY-MM-DDTHH:MM format in venue time; a bare YYYY-MM-DD date
+// starts at the beginning of the day for starts_at and runs to the end of the
+// day for ends_at.
+type blackoutRequest struct {
+	CourtID  *uint  `json:"court_id"` // Whole venue when empty
+	StartsAt string `json:"starts_at" binding:"required"`
+	EndsAt   string `json:"ends_at" binding:"required"`
+	Reason   string `json:"reason" binding:"required"`
+}
+
+// ListBlackouts godoc
+// @Summary List blackouts
+// @Description List the blackouts overlapping the days from from to to
+// @Tags admin-blackouts
+// @Produce json
+// @Security ApiKeyAuth
+// @Param from query string false "First date in YYYY-MM-DD format"
+// @Param to query string false "Last date in YYYY-MM-DD format"
+// @Success 200 {array} models.Blackout
+// @Failure 400 {object} map[string]string "error: message"
+// @Router /api/v1/admin/blackouts [get]
+func (h *BlackoutHandler) ListBlackouts(c *gin.Context) {
+	from, ok := parseOptionalDate(c, "from")
+	if !ok {
+		return
+	}
+	to, ok := parseOptionalDate(c, "to")
+	if !ok {
+		return
+	}
+
+	blackouts, err := h.blackoutService.ListBlackouts(c.Request.Context(), from, to)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, blackouts)
+}
+
+// GetBlackout godoc
+// @Summary Get a blackout
+// @Tags admin-blackouts
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Blackout ID"
+// @Success 200 {object} models.Blackout
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 404 {object} map[string]string "error: message"
+// @Router /api/v1/admin/blackouts/{id} [get]
+func (h *BlackoutHandler) GetBlackout(c *gin.Context) {
+	blackoutID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	blackout, err := h.blackoutService.GetBlackout(c.Request.Context(), blackoutID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, blackout)
+}
+
+// CreateBlackout godoc
+// @Summary Create a blackout
+// @Description Close a court, or the whole venue without a court_id, for a period. Its slots are shown as unavailable with the reason and cannot be booked. Paid reservations colliding with the blackout are returned so their customers can be contacted; they are not cancelled.
+// @Tags admin-blackouts
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param blackout body object true "court_id, starts_at, ends_at, reason"
+// @Success 201 {object} map[string]interface{} "blackout: object, conflicting_reservations: array"
+// @Failure 400 {object} map[string]string "error: message"
+// @Router /api/v1/admin/blackouts [post]
+func (h *BlackoutHandler) CreateBlackout(c *gin.Context) {
+	blackout, ok := bindBlackout(c)
+	if !ok {
+		return
+	}
+
+	blackout, conflicts, err := h.blackoutService.CreateBlackout(c.Request.Context(), blackout)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"blackout":                 blackout,
+		"conflicting_reservations": conflicts,
+	})
+}
+
+// UpdateBlackout godoc
+// @Summary Update a blackout
+// @Description Replace the court, period and reason of a blackout. The paid reservations it now collides with are returned.
+// @Tags admin-blackouts
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Blackout ID"
+// @Param blackout body object true "court_id, starts_at, ends_at, reason"
+// @Success 200 {object} map[string]interface{} "blackout: object, conflicting_reservations: array"
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 404 {object} map[string]string "error: message"
+// @Router /api/v1/admin/blackouts/{id} [put]
+func (h *BlackoutHandler) UpdateBlackout(c *gin.Context) {
+	blackoutID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	blackout, ok := bindBlackout(c)
+	if !ok {
+		return
+	}
+
+	blackout, conflicts, err := h.blackoutService.UpdateBlackout(c.Request.Context(), blackoutID, blackout)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"blackout":                 blackout,
+		"conflicting_reservations": conflicts,
+	})
+}
+
+// DeleteBlackout godoc
+// @Summary Delete a blackout
+// @Tags admin-blackouts
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Blackout ID"
+// @Success 200 {object} map[string]string "message: blackout deleted"
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 404 {object} map[string]string "error: message"
+// @Router /api/v1/admin/blackouts/{id} [delete]
+func (h *BlackoutHandler) DeleteBlackout(c *gin.Context) {
+	blackoutID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.blackoutService.DeleteBlackout(c.Request.Context(), blackoutID); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "blackout deleted"})
+}
+
+// ListConflicts godoc
+// @Summary List reservations colliding with a blackout
+// @Description List the paid reservations whose slot overlaps a blackout, with their customers
+// @Tags admin-blackouts
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Blackout ID"
+// @Success 200 {array} models.Reservation
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 404 {object} map[string]string "error: message"
+// @Router /api/v1/admin/blackouts/{id}/conflicts [get]
+func (h *BlackoutHandler) ListConflicts(c *gin.Context) {
+	blackoutID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	conflicts, err := h.blackoutService.ConflictingReservations(c.Request.Context(), blackoutID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, conflicts)
+}
+
+// bindBlackout binds a blackout body, responding with 400 if it is invalid
+func bindBlackout(c *gin.Context) (*models.Blackout, bool) {
+	var req blackoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err)
+		return nil, false
+	}
+
+	startsAt, err := parseBlackoutTime(req.StartsAt, false)
+	if err != nil {
+		apierror.Message(c, http.StatusBadRequest, "invalid starts_at format")
+		return nil, false
+	}
+	endsAt, err := parseBlackoutTime(req.EndsAt, true)
+	if err != nil {
+		apierror.Message(c, http.StatusBadRequest, "invalid ends_at format")
+		return nil, false
+	}
+
+	return &models.Blackout{
+		CourtID:  req.CourtID,
+		StartsAt: startsAt,
+		EndsAt:   endsAt,
+		Reason:   req.Reason,
+	}, true
+}
+
+// parseBlackoutTime parses a blackout bound in venue time. A bare date is the
+// start of the day, or the end of it when end is set.
+func parseBlackoutTime(value string, end bool) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02T15:04", value, time.Local); err == nil {
+		return t, nil
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if end {
+		date = date.AddDate(0, 0, 1)
+	}
+	return date, nil
+}
+
+func (h *BlackoutHandler) respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrBlackoutNotFound):
+		apierror.Respond(c, http.StatusNotFound, err)
+	case errors.Is(err, services.ErrInvalidBlackout):
+		apierror.Respond(c, http.StatusBadRequest, err)
+	default:
+		apierror.Respond(c, http.StatusInternalServerError, err)
+	}
+}
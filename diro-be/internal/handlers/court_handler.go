@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"diro-be/internal/apierror"
+	"diro-be/internal/services"
+)
+
+// CourtHandler handles court management HTTP requests from venue staff
+type CourtHandler struct {
+	courtService *services.CourtService
+}
+
+// NewCourtHandler creates a new court handler
+func NewCourtHandler(courtService *services.CourtService) *CourtHandler {
+	return &CourtHandler{
+		courtService: courtService,
+	}
+}
+
+// ListCourts godoc
+// @Summary List courts
+// @Description List every court that is not deleted, including inactive ones
+// @Tags admin-courts
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.Court
+// @Failure 401 {object} map[string]string "error: message"
+// @Failure 403 {object} map[string]string "error: message"
+// @Failure 500 {object} map[string]string "error: message"
+// @Router /api/v1/admin/courts [get]
+func (h *CourtHandler) ListCourts(c *gin.Context) {
+	courts, err := h.courtService.ListCourts(c.Request.Context())
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, courts)
+}
+
+// GetCourt godoc
+// @Summary Get a court
+// @Tags admin-courts
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Court ID"
+// @Success 200 {object} models.Court
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 404 {object} map[string]string "error: message"
+// @Router /api/v1/admin/courts/{id} [get]
+func (h *CourtHandler) GetCourt(c *gin.Context) {
+	courtID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	court, err := h.courtService.GetCourt(c.Request.Context(), courtID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, court)
+}
+
+// CreateCourt godoc
+// @Summary Create a court
+// @Tags admin-courts
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param court body object true "name, description"
+// @Success 201 {object} models.Court
+// @Failure 400 {object} map[string]string "error: message"
+// @Router /api/v1/admin/courts [post]
+func (h *CourtHandler) CreateCourt(c *gin.Context) {
+	var req struct {
+		Name        string `json:"name" binding:"required"`
+		Description string `json:"description"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err)
+		return
+	}
+
+	court, err := h.courtService.CreateCourt(c.Request.Context(), req.Name, req.Description)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, court)
+}
+
+// UpdateCourt godoc
+// @Summary Update a court
+// @Description Change the name and/or description of a court
+// @Tags admin-courts
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Court ID"
+// @Param court body object true "name, description"
+// @Success 200 {object} models.Court
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 404 {object} map[string]string "error: message"
+// @Router /api/v1/admin/courts/{id} [put]
+func (h *CourtHandler) UpdateCourt(c *gin.Context) {
+	courtID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err)
+		return
+	}
+
+	court, err := h.courtService.UpdateCourt(c.Request.Context(), courtID, req.Name, req.Description)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, court)
+}
+
+// ActivateCourt godoc
+// @Summary Activate a court
+// @Tags admin-courts
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Court ID"
+// @Success 200 {object} map[string]interface{} "court: object"
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 404 {object} map[string]string "error: message"
+// @Router /api/v1/admin/courts/{id}/activate [post]
+func (h *CourtHandler) ActivateCourt(c *gin.Context) {
+	h.setActive(c, true)
+}
+
+// DeactivateCourt godoc
+// @Summary Deactivate a court
+// @Description Deactivate a court. Fails with 409 when future paid reservations exist unless force=true, in which case they are listed as affected.
+// @Tags admin-courts
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Court ID"
+// @Param force query bool false "Deactivate even with future paid reservations"
+// @Success 200 {object} map[string]interface{} "court: object, affected_reservations: array"
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 404 {object} map[string]string "error: message"
+// @Failure 409 {object} map[string]interface{} "error: message, reservations: array"
+// @Router /api/v1/admin/courts/{id}/deactivate [post]
+func (h *CourtHandler) DeactivateCourt(c *gin.Context) {
+	h.setActive(c, false)
+}
+
+// DeleteCourt godoc
+// @Summary Delete a court
+// @Description Soft delete a court. Fails with 409 when future paid reservations exist unless force=true, in which case they are listed as affected.
+// @Tags admin-courts
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Court ID"
+// @Param force query bool false "Delete even with future paid reservations"
+// @Success 200 {object} map[string]interface{} "message: court deleted, affected_reservations: array"
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 404 {object} map[string]string "error: message"
+// @Failure 409 {object} map[string]interface{} "error: message, reservations: array"
+// @Router /api/v1/admin/courts/{id} [delete]
+func (h *CourtHandler) DeleteCourt(c *gin.Context) {
+	courtID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	affected, err := h.courtService.DeleteCourt(c.Request.Context(), courtID, c.Query("force") == "true")
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":               "court deleted",
+		"affected_reservations": affected,
+	})
+}
+
+func (h *CourtHandler) setActive(c *gin.Context, active bool) {
+	courtID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	court, affected, err := h.courtService.SetCourtActive(c.Request.Context(), courtID, active, c.Query("force") == "true")
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"court":                 court,
+		"affected_reservations": affected,
+	})
+}
+
+func (h *CourtHandler) respondError(c *gin.Context, err error) {
+	var inUse *services.CourtInUseError
+	switch {
+	case errors.Is(err, services.ErrCourtNotFound):
+		apierror.Respond(c, http.StatusNotFound, err)
+	case errors.Is(err, services.ErrInvalidCourt):
+		apierror.Respond(c, http.StatusBadRequest, err)
+	case errors.As(err, &inUse):
+		apierror.RespondWith(c, http.StatusConflict, err, gin.H{"reservations": inUse.Reservations})
+	default:
+		apierror.Respond(c, http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"diro-be/internal/apierror"
+	"diro-be/internal/services"
+)
+
+// FakeGatewayHandler serves the invoice pages of the fake payment gateway
+type FakeGatewayHandler struct {
+	gateway *services.FakePaymentGateway
+}
+
+// NewFakeGatewayHandler creates a new fake gateway handler
+func NewFakeGatewayHandler(gateway *services.FakePaymentGateway) *FakeGatewayHandler {
+	return &FakeGatewayHandler{
+		gateway: gateway,
+	}
+}
+
+// GetInvoice godoc
+// @Summary Get fake invoice
+// @Description Get an invoice issued by the offline fake payment gateway
+// @Tags fake-gateway
+// @Produce json
+// @Param id path string true "Invoice ID"
+// @Success 200 {object} models.XenditInvoiceResponse
+// @Failure 404 {object} map[string]string "error: message"
+// @Router /api/v1/fake-gateway/invoices/{id} [get]
+func (h *FakeGatewayHandler) GetInvoice(c *gin.Context) {
+	invoice, err := h.gateway.GetInvoice(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		apierror.Respond(c, http.StatusNotFound, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, invoice)
+}
+
+// PayInvoice godoc
+// @Summary Pay fake invoice
+// @Description Mark a fake invoice as paid and deliver the PAID webhook
+// @Tags fake-gateway
+// @Produce json
+// @Param id path string true "Invoice ID"
+// @Success 200 {object} map[string]string "message: invoice paid"
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 404 {object} map[string]string "error: message"
+// @Router /api/v1/fake-gateway/invoices/{id}/pay [post]
+func (h *FakeGatewayHandler) PayInvoice(c *gin.Context) {
+	if err := h.gateway.SimulatePayment(c.Request.Context(), c.Param("id")); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "invoice paid"})
+}
+
+// ExpireInvoice godoc
+// @Summary Expire fake invoice
+// @Description Expire a fake invoice and deliver the EXPIRED webhook
+// @Tags fake-gateway
+// @Produce json
+// @Param id path string true "Invoice ID"
+// @Success 200 {object} map[string]string "message: invoice expired"
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 404 {object} map[string]string "error: message"
+// @Router /api/v1/fake-gateway/invoices/{id}/expire [post]
+func (h *FakeGatewayHandler) ExpireInvoice(c *gin.Context) {
+	if err := h.gateway.SimulateExpiry(c.Request.Context(), c.Param("id")); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "invoice expired"})
+}
+
+func (h *FakeGatewayHandler) respondError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrInvoiceNotFound) {
+		apierror.Respond(c, http.StatusNotFound, err)
+		return
+	}
+	apierror.Respond(c, http.StatusBadRequest, err)
+}
@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"diro-be/internal/apierror"
+	"diro-be/internal/models"
+	"diro-be/internal/repositories"
+	"diro-be/internal/services"
+)
+
+// PricingHandler handles price rule and holiday HTTP requests from venue staff
+type PricingHandler struct {
+	pricingService *services.PricingService
+}
+
+// NewPricingHandler creates a new pricing handler
+func NewPricingHandler(pricingService *services.PricingService) *PricingHandler {
+	return &PricingHandler{
+		pricingService: pricingService,
+	}
+}
+
+// priceRuleRequest is the body for creating or replacing a price rule
+type priceRuleRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	CourtID     *uint    `json:"court_id"`
+	Weekday     *int     `json:"weekday"`
+	StartTime   string   `json:"start_time"`
+	EndTime     string   `json:"end_time"`
+	DateFrom    string   `json:"date_from"` // YYYY-MM-DD
+	DateTo      string   `json:"date_to"`   // YYYY-MM-DD
+	HolidayOnly bool     `json:"holiday_only"`
+	Price       *float64 `json:"price" binding:"required"`
+	Priority    int      `json:"priority"`
+	IsActive    *bool    `json:"is_active"` // Defaults to true
+}
+
+// ListPriceRules godoc
+// @Summary List price rules
+// @Description List every price rule, including inactive ones, in the order they are evaluated
+// @Tags admin-pricing
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.PriceRule
+// @Failure 500 {object} map[string]string "error: message"
+// @Router /api/v1/admin/price-rules [get]
+func (h *PricingHandler) ListPriceRules(c *gin.Context) {
+	rules, err := h.pricingService.ListPriceRules(c.Request.Context())
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// CreatePriceRule godoc
+// @Summary Create a price rule
+// @Description Create a rule pricing the slots that match all of its conditions: court, weekday (0 is Sunday), slot start time window, date range and holidays. The matching rule with the highest priority wins; slots no rule matches use the default price.
+// @Tags admin-pricing
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param rule body object true "name, price, court_id, weekday, start_time, end_time, date_from, date_to, holiday_only, priority, is_active"
+// @Success 201 {object} models.PriceRule
+// @Failure 400 {object} map[string]string "error: message"
+// @Router /api/v1/admin/price-rules [post]
+func (h *PricingHandler) CreatePriceRule(c *gin.Context) {
+	rule, ok := bindPriceRule(c)
+	if !ok {
+		return
+	}
+
+	rule, err := h.pricingService.CreatePriceRule(c.Request.Context(), rule)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, rule)
+}
+
+// UpdatePriceRule godoc
+// @Summary Update a price rule
+// @Description Replace the conditions, price and priority of a price rule. Prices of existing reservations are not changed.
+// @Tags admin-pricing
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Price rule ID"
+// @Param rule body object true "name, price, court_id, weekday, start_time, end_time, date_from, date_to, holiday_only, priority, is_active"
+// @Success 200 {object} models.PriceRule
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 404 {object} map[string]string "error: message"
+// @Router /api/v1/admin/price-rules/{id} [put]
+func (h *PricingHandler) UpdatePriceRule(c *gin.Context) {
+	ruleID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	rule, ok := bindPriceRule(c)
+	if !ok {
+		return
+	}
+
+	rule, err := h.pricingService.UpdatePriceRule(c.Request.Context(), ruleID, rule)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeletePriceRule godoc
+// @Summary Delete a price rule
+// @Tags admin-pricing
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Price rule ID"
+// @Success 200 {object} map[string]string "message: price rule deleted"
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 404 {object} map[string]string "error: message"
+// @Router /api/v1/admin/price-rules/{id} [delete]
+func (h *PricingHandler) DeletePriceRule(c *gin.Context) {
+	ruleID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.pricingService.DeletePriceRule(c.Request.Context(), ruleID); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "price rule deleted"})
+}
+
+// ListHolidays godoc
+// @Summary List holidays
+// @Tags admin-pricing
+// @Produce json
+// @Security ApiKeyAuth
+// @Param from query string false "First date in YYYY-MM-DD format"
+// @Param to query string false "Last date in YYYY-MM-DD format"
+// @Success 200 {array} models.Holiday
+// @Failure 400 {object} map[string]string "error: message"
+// @Router /api/v1/admin/holidays [get]
+func (h *PricingHandler) ListHolidays(c *gin.Context) {
+	from, ok := parseOptionalDate(c, "from")
+	if !ok {
+		return
+	}
+	to, ok := parseOptionalDate(c, "to")
+	if !ok {
+		return
+	}
+
+	holidays, err := h.pricingService.ListHolidays(c.Request.Context(), from, to)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, holidays)
+}
+
+// CreateHoliday godoc
+// @Summary Create a holiday
+// @Description Mark a date as a holiday for holiday_only price rules
+// @Tags admin-pricing
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param holiday body object true "date, name"
+// @Success 201 {object} models.Holiday
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 409 {object} map[string]string "error: message"
+// @Router /api/v1/admin/holidays [post]
+func (h *PricingHandler) CreateHoliday(c *gin.Context) {
+	var req struct {
+		Date string `json:"date" binding:"required"`
+		Name string `json:"name" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err)
+		return
+	}
+
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		apierror.Message(c, http.StatusBadRequest, "invalid date format")
+		return
+	}
+
+	holiday, err := h.pricingService.CreateHoliday(c.Request.Context(), date, req.Name)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, holiday)
+}
+
+// DeleteHoliday godoc
+// @Summary Delete a holiday
+// @Tags admin-pricing
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Holiday ID"
+// @Success 200 {object} map[string]string "message: holiday deleted"
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 404 {object} map[string]string "error: message"
+// @Router /api/v1/admin/holidays/{id} [delete]
+func (h *PricingHandler) DeleteHoliday(c *gin.Context) {
+	holidayID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.pricingService.DeleteHoliday(c.Request.Context(), holidayID); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "holiday deleted"})
+}
+
+// bindPriceRule binds a price rule body, responding with 400 if it is invalid
+func bindPriceRule(c *gin.Context) (*models.PriceRule, bool) {
+	var req priceRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err)
+		return nil, false
+	}
+
+	rule := &models.PriceRule{
+		Name:        req.Name,
+		CourtID:     req.CourtID,
+		Weekday:     req.Weekday,
+		StartTime:   req.StartTime,
+		EndTime:     req.EndTime,
+		HolidayOnly: req.HolidayOnly,
+		Price:       *req.Price,
+		Priority:    req.Priority,
+		IsActive:    req.IsActive == nil || *req.IsActive,
+	}
+
+	if req.DateFrom != "" {
+		date, err := time.Parse("2006-01-02", req.DateFrom)
+		if err != nil {
+			apierror.Message(c, http.StatusBadRequest, "invalid date_from date format")
+			return nil, false
+		}
+		rule.DateFrom = &date
+	}
+	if req.DateTo != "" {
+		date, err := time.Parse("2006-01-02", req.DateTo)
+		if err != nil {
+			apierror.Message(c, http.StatusBadRequest, "invalid date_to date format")
+			return nil, false
+		}
+		rule.DateTo = &date
+	}
+
+	return rule, true
+}
+
+func (h *PricingHandler) respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrPriceRuleNotFound), errors.Is(err, services.ErrHolidayNotFound):
+		apierror.Respond(c, http.StatusNotFound, err)
+	case errors.Is(err, services.ErrInvalidPriceRule), errors.Is(err, services.ErrInvalidHoliday):
+		apierror.Respond(c, http.StatusBadRequest, err)
+	case errors.Is(err, repositories.ErrHolidayExists):
+		apierror.Respond(c, http.StatusConflict, err)
+	default:
+		apierror.Respond(c, http.StatusInternalServerError, err)
+	}
+}
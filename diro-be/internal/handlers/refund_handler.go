@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"diro-be/internal/apierror"
+	"diro-be/internal/middleware"
+	"diro-be/internal/repositories"
+	"diro-be/internal/services"
+)
+
+// RefundReservation godoc
+// @Summary Refund a reservation
+// @Description Refund part or all of what was paid for a reservation through the payment gateway. Without an amount everything not refunded yet is refunded. reason is one of REQUESTED_BY_CUSTOMER, CANCELLATION, DUPLICATE, FRAUDULENT or OTHERS. Once the gateway confirms the refund the reservation becomes partially_refunded, or refunded when nothing is left, which releases its slot. Sending the same reference_id again does not refund twice.
+// @Tags admin-reservations
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Reservation ID"
+// @Param refund body object true "reason, amount, note, reference_id"
+// @Success 201 {object} map[string]interface{} "refund: models.Refund, reservation: models.Reservation"
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 404 {object} map[string]string "error: message"
+// @Failure 409 {object} map[string]string "error: message"
+// @Failure 502 {object} map[string]string "error: message"
+// @Router /api/v1/admin/reservations/{id}/refunds [post]
+func (h *ReservationHandler) RefundReservation(c *gin.Context) {
+	reservationID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Amount      float64 `json:"amount"`
+		Reason      string  `json:"reason" binding:"required"`
+		Note        string  `json:"note"`
+		ReferenceID string  `json:"reference_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err)
+		return
+	}
+
+	userID, _ := middleware.UserID(c)
+	refund, reservation, err := h.reservationService.RefundReservation(c.Request.Context(), userID, reservationID, services.RefundRequest{
+		Amount:      req.Amount,
+		Reason:      req.Reason,
+		Note:        req.Note,
+		ReferenceID: req.ReferenceID,
+	})
+	if err != nil {
+		respondRefundError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"refund":      refund,
+		"reservation": reservation,
+	})
+}
+
+// ListRefunds godoc
+// @Summary List the refunds of a reservation
+// @Description List the refunds of one of the authenticated customer's reservations with their gateway status, oldest first. Admins may list the refunds of any reservation.
+// @Tags reservations
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Reservation ID"
+// @Success 200 {array} models.Refund
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 401 {object} map[string]string "error: message"
+// @Failure 404 {object} map[string]string "error: message"
+// @Router /api/v1/reservations/{id}/refunds [get]
+func (h *ReservationHandler) ListRefunds(c *gin.Context) {
+	reservationID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.UserID(c)
+	refunds, err := h.reservationService.ListRefunds(c.Request.Context(), userID, middleware.UserRole(c), reservationID)
+	if err != nil {
+		respondRefundError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, refunds)
+}
+
+func respondRefundError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrReservationNotFound):
+		apierror.Respond(c, http.StatusNotFound, err)
+	case errors.Is(err, services.ErrInvalidRefund):
+		apierror.Respond(c, http.StatusBadRequest, err)
+	case errors.Is(err, services.ErrNotRefundable), errors.Is(err, repositories.ErrDuplicateRefund),
+		errors.Is(err, services.ErrInvalidTransition), errors.Is(err, repositories.ErrStaleReservation):
+		apierror.Respond(c, http.StatusConflict, err)
+	case errors.Is(err, services.ErrGatewayFailure):
+		apierror.Respond(c, http.StatusBadGateway, err)
+	default:
+		apierror.Respond(c, http.StatusInternalServerError, err)
+	}
+}
@@ -1,12 +1,18 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"diro-be/internal/apierror"
+	"diro-be/internal/middleware"
 	"diro-be/internal/models"
+	"diro-be/internal/repositories"
 	"diro-be/internal/services"
 )
 
@@ -24,48 +30,46 @@ func NewReservationHandler(reservationService *services.ReservationService) *Res
 
 // CreateReservation godoc
 // @Summary Create a new reservation
-// @Description Create a new reservation for a court at specific date and timeslot
+// @Description Create a new reservation for a court at specific date and timeslot. With a bearer token the reservation is linked to the customer account. An optional promo_code discounts the price.
 // @Tags reservations
 // @Accept json
 // @Produce json
 // @Param reservation body object true "Reservation data"
 // @Success 201 {object} map[string]interface{} "reservation: object, invoice_url: string"
 // @Failure 400 {object} map[string]string "error: message"
+// @Failure 409 {object} map[string]string "error: slot is already reserved, court is closed or promo code used up"
+// @Failure 500 {object} map[string]string "error: message, code, correlation_id"
+// @Failure 502 {object} map[string]string "error: message, code, correlation_id"
+// @Failure 504 {object} map[string]string "error: request timed out, code, correlation_id"
 // @Router /api/reservations [post]
 func (h *ReservationHandler) CreateReservation(c *gin.Context) {
 	var req struct {
-		CourtID    uint   `json:"court_id" binding:"required"`
-		TimeslotID uint   `json:"timeslot_id" binding:"required"`
-		Date       string `json:"date" binding:"required"`
-		Customer   struct {
-			GivenNames   string `json:"given_names" binding:"required"`
-			Surname      string `json:"surname"`
-			Email        string `json:"email" binding:"required"`
-			MobileNumber string `json:"mobile_number" binding:"required"`
-		} `json:"customer" binding:"required"`
+		CourtID    uint            `json:"court_id" binding:"required"`
+		TimeslotID uint            `json:"timeslot_id" binding:"required"`
+		Date       string          `json:"date" binding:"required"`
+		Customer   customerRequest `json:"customer" binding:"required"`
+		PromoCode  string          `json:"promo_code"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Respond(c, http.StatusBadRequest, err)
 		return
 	}
 
 	date, err := time.Parse("2006-01-02", req.Date)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date format"})
+		apierror.Message(c, http.StatusBadRequest, "invalid date format")
 		return
 	}
 
-	customer := models.XenditCustomer{
-		GivenNames:   req.Customer.GivenNames,
-		Surname:      req.Customer.Surname,
-		Email:        req.Customer.Email,
-		MobileNumber: req.Customer.MobileNumber,
+	var userID *uint
+	if id, ok := middleware.UserID(c); ok {
+		userID = &id
 	}
 
-	reservation, invoiceURL, err := h.reservationService.CreateReservation(req.CourtID, req.TimeslotID, date, customer)
+	reservation, invoiceURL, err := h.reservationService.CreateReservation(c.Request.Context(), userID, req.CourtID, req.TimeslotID, date, req.Customer.toXendit(), req.PromoCode)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondBookingError(c, err)
 		return
 	}
 
@@ -75,35 +79,400 @@ func (h *ReservationHandler) CreateReservation(c *gin.Context) {
 	})
 }
 
+// CreateBooking godoc
+// @Summary Book several slots at once
+// @Description Reserve up to 10 court slots in a single checkout. Either every slot is reserved or none is, and all of them are billed on one invoice with one item per slot. With a bearer token the reservations are linked to the customer account. An optional promo_code discounts the slots it is valid for.
+// @Tags reservations
+// @Accept json
+// @Produce json
+// @Param booking body object true "items: [{court_id, timeslot_id, date}], customer, promo_code"
+// @Success 201 {object} map[string]interface{} "reservations: array, total_price: number, invoice_url: string"
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 409 {object} map[string]string "error: slot is already reserved, court is closed or promo code used up"
+// @Failure 500 {object} map[string]string "error: message, code, correlation_id"
+// @Failure 502 {object} map[string]string "error: message, code, correlation_id"
+// @Failure 504 {object} map[string]string "error: request timed out, code, correlation_id"
+// @Router /api/v1/bookings [post]
+func (h *ReservationHandler) CreateBooking(c *gin.Context) {
+	var req struct {
+		Items []struct {
+			CourtID    uint   `json:"court_id" binding:"required"`
+			TimeslotID uint   `json:"timeslot_id" binding:"required"`
+			Date       string `json:"date" binding:"required"`
+		} `json:"items" binding:"required,dive"`
+		Customer  customerRequest `json:"customer" binding:"required"`
+		PromoCode string          `json:"promo_code"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err)
+		return
+	}
+
+	items := make([]services.BookingItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		date, err := time.Parse("2006-01-02", item.Date)
+		if err != nil {
+			apierror.Message(c, http.StatusBadRequest, "invalid date format")
+			return
+		}
+		items = append(items, services.BookingItem{
+			CourtID:    item.CourtID,
+			TimeslotID: item.TimeslotID,
+			Date:       date,
+		})
+	}
+
+	var userID *uint
+	if id, ok := middleware.UserID(c); ok {
+		userID = &id
+	}
+
+	reservations, invoiceURL, err := h.reservationService.CreateBooking(c.Request.Context(), userID, items, req.Customer.toXendit(), req.PromoCode)
+	if err != nil {
+		respondBookingError(c, err)
+		return
+	}
+
+	var total float64
+	for _, reservation := range reservations {
+		total += reservation.TotalPrice
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"reservations": reservations,
+		"total_price":  total,
+		"invoice_url":  invoiceURL,
+	})
+}
+
+// customerRequest is the customer billed for a booking
+type customerRequest struct {
+	GivenNames   string `json:"given_names" binding:"required"`
+	Surname      string `json:"surname"`
+	Email        string `json:"email" binding:"required"`
+	MobileNumber string `json:"mobile_number" binding:"required"`
+}
+
+func (r customerRequest) toXendit() models.XenditCustomer {
+	return models.XenditCustomer{
+		GivenNames:   r.GivenNames,
+		Surname:      r.Surname,
+		Email:        r.Email,
+		MobileNumber: r.MobileNumber,
+	}
+}
+
+// respondBookingError maps errors from creating reservations to responses.
+// A request that ran out of time or whose client went away is reported as
+// such, even when it failed in the gateway. Errors it does not know are
+// internal, so their details are not shown.
+func respondBookingError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		apierror.Respond(c, http.StatusGatewayTimeout, err)
+	case errors.Is(err, context.Canceled):
+		apierror.Respond(c, apierror.StatusClientClosedRequest, err)
+	case errors.Is(err, repositories.ErrSlotTaken), errors.Is(err, repositories.ErrVoucherUsedUp),
+		errors.Is(err, services.ErrSlotBlackedOut), errors.Is(err, services.ErrSlotOffered):
+		apierror.Respond(c, http.StatusConflict, err)
+	case errors.Is(err, services.ErrInvalidBooking), errors.Is(err, services.ErrSlotNotScheduled),
+		errors.Is(err, services.ErrInvalidPromoCode):
+		apierror.Respond(c, http.StatusBadRequest, err)
+	case errors.Is(err, services.ErrGatewayFailure):
+		apierror.Respond(c, http.StatusBadGateway, err)
+	default:
+		apierror.Respond(c, http.StatusInternalServerError, err)
+	}
+}
+
 // GetDayAvailability godoc
-// @Summary Get day availability
-// @Description Get availability for a specific day including courts and available timeslots
+// @Summary Get availability
+// @Description Get availability for a specific day, or for every day from from to to (at most 31 days) with a summary of the free slots of each day, including courts and available timeslots
 // @Tags reservations
 // @Accept json
 // @Produce json
-// @Param date query string true "Date in YYYY-MM-DD format"
+// @Param date query string false "Date in YYYY-MM-DD format"
+// @Param from query string false "First date of a range in YYYY-MM-DD format"
+// @Param to query string false "Last date of a range in YYYY-MM-DD format"
+// @Param court_id query int false "Only this court, for a range"
 // @Success 200 {object} models.DayAvailability
+// @Success 200 {object} models.AvailabilityRange
 // @Failure 400 {object} map[string]string "error: message"
 // @Failure 500 {object} map[string]string "error: message"
 // @Router /api/reservations/availability [get]
 func (h *ReservationHandler) GetDayAvailability(c *gin.Context) {
+	if c.Query("date") == "" && (c.Query("from") != "" || c.Query("to") != "") {
+		h.getAvailabilityRange(c)
+		return
+	}
+
 	dateStr := c.Query("date")
 	if dateStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "date parameter is required"})
+		apierror.Message(c, http.StatusBadRequest, "date parameter is required")
 		return
 	}
 
 	date, err := time.Parse("2006-01-02", dateStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid date format"})
+		apierror.Message(c, http.StatusBadRequest, "invalid date format")
 		return
 	}
 
-	availability, err := h.reservationService.GetDayAvailability(date)
+	availability, err := h.reservationService.GetDayAvailability(c.Request.Context(), date)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apierror.Respond(c, http.StatusInternalServerError, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, availability)
 }
+
+// getAvailabilityRange responds with the availability of a date range
+func (h *ReservationHandler) getAvailabilityRange(c *gin.Context) {
+	from, ok := parseOptionalDate(c, "from")
+	if !ok {
+		return
+	}
+	to, ok := parseOptionalDate(c, "to")
+	if !ok {
+		return
+	}
+	if from == nil || to == nil {
+		apierror.Message(c, http.StatusBadRequest, "from and to parameters are required")
+		return
+	}
+
+	var courtID *uint
+	if value := c.Query("court_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			apierror.Message(c, http.StatusBadRequest, "invalid court_id")
+			return
+		}
+		court := uint(id)
+		courtID = &court
+	}
+
+	availability, err := h.reservationService.GetAvailability(c.Request.Context(), *from, *to, courtID)
+	if errors.Is(err, services.ErrInvalidRange) {
+		apierror.Respond(c, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, availability)
+}
+
+// ListMyReservations godoc
+// @Summary List my reservations
+// @Description List the authenticated customer's reservations with court, timeslot and payment details
+// @Tags reservations
+// @Produce json
+// @Security ApiKeyAuth
+// @Param scope query string false "upcoming or past"
+// @Param status query string false "Reservation status"
+// @Param from query string false "Earliest slot date in YYYY-MM-DD format"
+// @Param to query string false "Latest slot date in YYYY-MM-DD format"
+// @Param page query int false "Page number, starting at 1"
+// @Param page_size query int false "Page size, at most 100"
+// @Success 200 {object} map[string]interface{} "reservations: array, page: int, page_size: int, total: int"
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 401 {object} map[string]string "error: message"
+// @Failure 500 {object} map[string]string "error: message"
+// @Router /api/v1/me/reservations [get]
+func (h *ReservationHandler) ListMyReservations(c *gin.Context) {
+	userID, _ := middleware.UserID(c)
+
+	var ok bool
+	filter := repositories.ReservationFilter{
+		Scope:  c.Query("scope"),
+		Status: models.ReservationStatus(c.Query("status")),
+	}
+	if filter.Scope != "" && filter.Scope != repositories.ScopeUpcoming && filter.Scope != repositories.ScopePast {
+		apierror.Message(c, http.StatusBadRequest, "scope must be upcoming or past")
+		return
+	}
+
+	if filter.From, ok = parseOptionalDate(c, "from"); !ok {
+		return
+	}
+	if filter.To, ok = parseOptionalDate(c, "to"); !ok {
+		return
+	}
+
+	if filter.Page, filter.PageSize, ok = parsePagination(c); !ok {
+		return
+	}
+
+	reservations, total, err := h.reservationService.ListCustomerReservations(c.Request.Context(), userID, filter)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reservations": reservations,
+		"page":         filter.Page,
+		"page_size":    filter.PageSize,
+		"total":        total,
+	})
+}
+
+// GetReservation godoc
+// @Summary Get a reservation
+// @Description Get one of the authenticated customer's reservations with court, timeslot, payment status and invoice URL
+// @Tags reservations
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Reservation ID"
+// @Success 200 {object} models.Reservation
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 401 {object} map[string]string "error: message"
+// @Failure 404 {object} map[string]string "error: message"
+// @Failure 500 {object} map[string]string "error: message"
+// @Router /api/v1/reservations/{id} [get]
+func (h *ReservationHandler) GetReservation(c *gin.Context) {
+	reservationID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	userID, _ := middleware.UserID(c)
+
+	reservation, err := h.reservationService.GetCustomerReservation(c.Request.Context(), userID, middleware.UserRole(c), reservationID)
+	if errors.Is(err, services.ErrReservationNotFound) {
+		apierror.Respond(c, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reservation)
+}
+
+// CancelReservation godoc
+// @Summary Cancel a reservation
+// @Description Cancel one of the authenticated customer's reservations. Unpaid invoices are expired; paid reservations are refunded according to the cancellation policy.
+// @Tags reservations
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Reservation ID"
+// @Param cancellation body object false "reason: string"
+// @Success 200 {object} models.Reservation
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 401 {object} map[string]string "error: message"
+// @Failure 404 {object} map[string]string "error: message"
+// @Failure 409 {object} map[string]string "error: message"
+// @Failure 502 {object} map[string]string "error: message"
+// @Router /api/v1/reservations/{id}/cancel [post]
+func (h *ReservationHandler) CancelReservation(c *gin.Context) {
+	reservationID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apierror.Respond(c, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	userID, _ := middleware.UserID(c)
+	reservation, err := h.reservationService.CancelReservation(c.Request.Context(), userID, middleware.UserRole(c), reservationID, req.Reason)
+	switch {
+	case errors.Is(err, services.ErrReservationNotFound):
+		apierror.Respond(c, http.StatusNotFound, err)
+		return
+	case errors.Is(err, services.ErrInvalidTransition), errors.Is(err, repositories.ErrStaleReservation), errors.Is(err, repositories.ErrDuplicateRefund):
+		apierror.Respond(c, http.StatusConflict, err)
+		return
+	case errors.Is(err, services.ErrGatewayFailure):
+		apierror.Respond(c, http.StatusBadGateway, err)
+		return
+	case err != nil:
+		apierror.Respond(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reservation)
+}
+
+// GetReservationHistory godoc
+// @Summary Get reservation status history
+// @Description Get every status transition of a reservation with what triggered it, oldest first
+// @Tags admin-reservations
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Reservation ID"
+// @Success 200 {array} models.ReservationStatusHistory
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 500 {object} map[string]string "error: message"
+// @Router /api/v1/admin/reservations/{id}/history [get]
+func (h *ReservationHandler) GetReservationHistory(c *gin.Context) {
+	reservationID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	history, err := h.reservationService.GetStatusHistory(c.Request.Context(), reservationID)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// parseIDParam parses a numeric path parameter, responding with 400 if invalid
+func parseIDParam(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil || id == 0 {
+		apierror.Message(c, http.StatusBadRequest, "invalid "+name)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// parseOptionalDate parses an optional YYYY-MM-DD query parameter, responding
+// with 400 if it is invalid
+func parseOptionalDate(c *gin.Context, name string) (*time.Time, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, true
+	}
+
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		apierror.Message(c, http.StatusBadRequest, "invalid "+name+" date format")
+		return nil, false
+	}
+	return &date, true
+}
+
+// parsePagination reads the page and page_size query parameters, responding
+// with 400 if they are invalid
+func parsePagination(c *gin.Context) (int, int, bool) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		apierror.Message(c, http.StatusBadRequest, "page must be a positive number")
+		return 0, 0, false
+	}
+
+	pageSize, err := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+	if err != nil || pageSize < 1 || pageSize > 100 {
+		apierror.Message(c, http.StatusBadRequest, "page_size must be between 1 and 100")
+		return 0, 0, false
+	}
+
+	return page, pageSize, true
+}
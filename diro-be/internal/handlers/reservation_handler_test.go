@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"diro-be/internal/apierror"
+	"diro-be/internal/repositories"
+	"diro-be/internal/services"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// bookingErrorResponse responds to a booking request failing with err
+func bookingErrorResponse(t *testing.T, err error) (int, map[string]string) {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/api/v1/bookings", nil)
+	respondBookingError(c, err)
+
+	var body map[string]string
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode response %q: %v", recorder.Body.String(), err)
+	}
+	return recorder.Code, body
+}
+
+func TestRespondBookingError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+		leaked     string // Must not appear in the response
+	}{
+		{
+			name:       "slot taken",
+			err:        repositories.ErrSlotTaken,
+			wantStatus: http.StatusConflict,
+			wantCode:   "slot_taken",
+		},
+		{
+			name:       "invalid booking",
+			err:        fmt.Errorf("%w: slot 1:1:2030-01-01 is listed twice", services.ErrInvalidBooking),
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "invalid_booking",
+		},
+		{
+			name:       "invoice creation failed",
+			err:        fmt.Errorf("%w: %w", services.ErrGatewayFailure, &services.GatewayError{StatusCode: http.StatusBadRequest, CorrelationID: "abc"}),
+			wantStatus: http.StatusBadGateway,
+			wantCode:   apierror.CodeGatewayError,
+			leaked:     "xendit API returned status",
+		},
+		{
+			name:       "request timed out",
+			err:        context.DeadlineExceeded,
+			wantStatus: http.StatusGatewayTimeout,
+			wantCode:   apierror.CodeTimeout,
+			leaked:     "context deadline exceeded",
+		},
+		{
+			name:       "gateway call timed out",
+			err:        fmt.Errorf("%w: %w", services.ErrGatewayFailure, fmt.Errorf("failed to send request: %w", context.DeadlineExceeded)),
+			wantStatus: http.StatusGatewayTimeout,
+			wantCode:   apierror.CodeTimeout,
+			leaked:     "failed to send request",
+		},
+		{
+			name:       "client disconnected",
+			err:        fmt.Errorf("failed to reserve: %w", context.Canceled),
+			wantStatus: apierror.StatusClientClosedRequest,
+			wantCode:   apierror.CodeCancelled,
+			leaked:     "context canceled",
+		},
+		{
+			name:       "database error",
+			err:        errors.New("Error 1054 (42S22): Unknown column 'slot_key' in 'field list'"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   apierror.CodeInternal,
+			leaked:     "Unknown column",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := bookingErrorResponse(t, tt.err)
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+			if body["code"] != tt.wantCode {
+				t.Errorf("code = %q, want %q", body["code"], tt.wantCode)
+			}
+			if tt.leaked != "" {
+				if strings.Contains(body["error"], tt.leaked) {
+					t.Errorf("error = %q reveals %q", body["error"], tt.leaked)
+				}
+				if body["correlation_id"] == "" {
+					t.Error("correlation_id is missing")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"diro-be/internal/apierror"
+	"diro-be/internal/services"
+)
+
+// ScheduleHandler handles court schedule HTTP requests from venue staff
+type ScheduleHandler struct {
+	scheduleService *services.ScheduleService
+}
+
+// NewScheduleHandler creates a new schedule handler
+func NewScheduleHandler(scheduleService *services.ScheduleService) *ScheduleHandler {
+	return &ScheduleHandler{
+		scheduleService: scheduleService,
+	}
+}
+
+// scheduleRequest is the body for creating or replacing a court schedule
+type scheduleRequest struct {
+	Weekday       *int   `json:"weekday" binding:"required"` // 0 is Sunday
+	TimeslotID    uint   `json:"timeslot_id" binding:"required"`
+	EffectiveFrom string `json:"effective_from" binding:"required"` // YYYY-MM-DD
+	EffectiveTo   string `json:"effective_to"`                      // YYYY-MM-DD, open ended when empty
+}
+
+// ListCourtSchedules godoc
+// @Summary List court schedules
+// @Description List the weekday schedules of a court, including ended ones
+// @Tags admin-schedules
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Court ID"
+// @Success 200 {array} models.CourtSchedule
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 404 {object} map[string]string "error: message"
+// @Router /api/v1/admin/courts/{id}/schedules [get]
+func (h *ScheduleHandler) ListCourtSchedules(c *gin.Context) {
+	courtID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	schedules, err := h.scheduleService.ListCourtSchedules(c.Request.Context(), courtID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, schedules)
+}
+
+// CreateCourtSchedule godoc
+// @Summary Create a court schedule
+// @Description Make a timeslot bookable on a court on one weekday (0 is Sunday) from effective_from until effective_to, or indefinitely when effective_to is empty
+// @Tags admin-schedules
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Court ID"
+// @Param schedule body object true "weekday, timeslot_id, effective_from, effective_to"
+// @Success 201 {object} models.CourtSchedule
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 404 {object} map[string]string "error: message"
+// @Router /api/v1/admin/courts/{id}/schedules [post]
+func (h *ScheduleHandler) CreateCourtSchedule(c *gin.Context) {
+	courtID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	req, from, to, ok := bindScheduleRequest(c)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.scheduleService.CreateSchedule(c.Request.Context(), courtID, *req.Weekday, req.TimeslotID, from, to)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, schedule)
+}
+
+// UpdateSchedule godoc
+// @Summary Update a court schedule
+// @Description Replace the weekday, timeslot and effective dates of a schedule. Set effective_to to end a schedule.
+// @Tags admin-schedules
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Schedule ID"
+// @Param schedule body object true "weekday, timeslot_id, effective_from, effective_to"
+// @Success 200 {object} models.CourtSchedule
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 404 {object} map[string]string "error: message"
+// @Router /api/v1/admin/schedules/{id} [put]
+func (h *ScheduleHandler) UpdateSchedule(c *gin.Context) {
+	scheduleID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	req, from, to, ok := bindScheduleRequest(c)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.scheduleService.UpdateSchedule(c.Request.Context(), scheduleID, *req.Weekday, req.TimeslotID, from, to)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// DeleteSchedule godoc
+// @Summary Delete a court schedule
+// @Description Delete a schedule. Existing reservations are kept.
+// @Tags admin-schedules
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Schedule ID"
+// @Success 200 {object} map[string]string "message: schedule deleted"
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 404 {object} map[string]string "error: message"
+// @Router /api/v1/admin/schedules/{id} [delete]
+func (h *ScheduleHandler) DeleteSchedule(c *gin.Context) {
+	scheduleID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.scheduleService.DeleteSchedule(c.Request.Context(), scheduleID); err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "schedule deleted"})
+}
+
+// bindScheduleRequest binds a schedule body and parses its dates, responding
+// with 400 if it is invalid
+func bindScheduleRequest(c *gin.Context) (*scheduleRequest, time.Time, *time.Time, bool) {
+	var req scheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err)
+		return nil, time.Time{}, nil, false
+	}
+
+	from, err := time.Parse("2006-01-02", req.EffectiveFrom)
+	if err != nil {
+		apierror.Message(c, http.StatusBadRequest, "invalid effective_from date format")
+		return nil, time.Time{}, nil, false
+	}
+
+	var to *time.Time
+	if req.EffectiveTo != "" {
+		date, err := time.Parse("2006-01-02", req.EffectiveTo)
+		if err != nil {
+			apierror.Message(c, http.StatusBadRequest, "invalid effective_to date format")
+			return nil, time.Time{}, nil, false
+		}
+		to = &date
+	}
+
+	return &req, from, to, true
+}
+
+func (h *ScheduleHandler) respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrScheduleNotFound), errors.Is(err, services.ErrCourtNotFound):
+		apierror.Respond(c, http.StatusNotFound, err)
+	case errors.Is(err, services.ErrInvalidSchedule):
+		apierror.Respond(c, http.StatusBadRequest, err)
+	default:
+		apierror.Respond(c, http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"diro-be/internal/apierror"
+	"diro-be/internal/middleware"
+	"diro-be/internal/services"
+)
+
+// CreateSeries godoc
+// @Summary Book a recurring series
+// @Description Book the same court slot every week or every other week until an end date or for a number of occurrences. Occurrences that cannot be booked are reported as conflicts and the rest are booked, paid on one invoice or one invoice per occurrence. With dry_run=true only the occurrences are checked.
+// @Tags reservations
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param dry_run query bool false "Only check the occurrences"
+// @Param series body object true "court_id, timeslot_id, frequency (weekly|biweekly), start_date, end_date or occurrences, billing_mode (single|per_occurrence), customer, promo_code"
+// @Success 200 {object} map[string]interface{} "occurrences: array"
+// @Success 201 {object} services.SeriesBooking
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 401 {object} map[string]string "error: message"
+// @Failure 409 {object} map[string]string "error: no occurrence can be booked, slot is already reserved, court is closed or promo code used up"
+// @Router /api/v1/series [post]
+func (h *ReservationHandler) CreateSeries(c *gin.Context) {
+	var req struct {
+		CourtID     uint            `json:"court_id" binding:"required"`
+		TimeslotID  uint            `json:"timeslot_id" binding:"required"`
+		Frequency   string          `json:"frequency" binding:"required"`
+		StartDate   string          `json:"start_date" binding:"required"`
+		EndDate     string          `json:"end_date"`
+		Occurrences int             `json:"occurrences"`
+		BillingMode string          `json:"billing_mode"`
+		Customer    customerRequest `json:"customer"`
+		PromoCode   string          `json:"promo_code"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err)
+		return
+	}
+
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		apierror.Message(c, http.StatusBadRequest, "invalid start_date format")
+		return
+	}
+	series := services.SeriesRequest{
+		CourtID:     req.CourtID,
+		TimeslotID:  req.TimeslotID,
+		Frequency:   req.Frequency,
+		StartDate:   startDate,
+		Occurrences: req.Occurrences,
+		BillingMode: req.BillingMode,
+	}
+	if req.EndDate != "" {
+		endDate, err := time.Parse("2006-01-02", req.EndDate)
+		if err != nil {
+			apierror.Message(c, http.StatusBadRequest, "invalid end_date format")
+			return
+		}
+		series.EndDate = &endDate
+	}
+
+	if c.Query("dry_run") == "true" {
+		occurrences, err := h.reservationService.CheckSeries(c.Request.Context(), series)
+		if err != nil {
+			respondSeriesError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"occurrences": occurrences})
+		return
+	}
+
+	if req.Customer.GivenNames == "" || req.Customer.Email == "" || req.Customer.MobileNumber == "" {
+		apierror.Message(c, http.StatusBadRequest, "customer given_names, email and mobile_number are required")
+		return
+	}
+
+	userID, _ := middleware.UserID(c)
+	booking, err := h.reservationService.CreateSeries(c.Request.Context(), userID, series, req.Customer.toXendit(), req.PromoCode)
+	if err != nil {
+		respondSeriesError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, booking)
+}
+
+// GetSeries godoc
+// @Summary Get a recurring series
+// @Description Get one of the authenticated customer's series with its reservations
+// @Tags reservations
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Series ID"
+// @Success 200 {object} models.ReservationSeries
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 401 {object} map[string]string "error: message"
+// @Failure 404 {object} map[string]string "error: message"
+// @Failure 500 {object} map[string]string "error: message"
+// @Router /api/v1/series/{id} [get]
+func (h *ReservationHandler) GetSeries(c *gin.Context) {
+	seriesID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	userID, _ := middleware.UserID(c)
+
+	series, err := h.reservationService.GetCustomerSeries(c.Request.Context(), userID, middleware.UserRole(c), seriesID)
+	if errors.Is(err, services.ErrSeriesNotFound) {
+		apierror.Respond(c, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
+}
+
+// CancelSeries godoc
+// @Summary Cancel the rest of a recurring series
+// @Description Cancel the occurrences of a series that have not started, from an optional date on. Each occurrence is cancelled according to the cancellation policy; cancel a single occurrence through its reservation.
+// @Tags reservations
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Series ID"
+// @Param cancellation body object false "reason: string, from: YYYY-MM-DD"
+// @Success 200 {object} map[string]interface{} "cancelled: array"
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 401 {object} map[string]string "error: message"
+// @Failure 404 {object} map[string]string "error: message"
+// @Failure 502 {object} map[string]string "error: message"
+// @Router /api/v1/series/{id}/cancel [post]
+func (h *ReservationHandler) CancelSeries(c *gin.Context) {
+	seriesID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+		From   string `json:"from"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apierror.Respond(c, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	var from *time.Time
+	if req.From != "" {
+		date, err := time.Parse("2006-01-02", req.From)
+		if err != nil {
+			apierror.Message(c, http.StatusBadRequest, "invalid from format")
+			return
+		}
+		from = &date
+	}
+
+	userID, _ := middleware.UserID(c)
+	cancelled, err := h.reservationService.CancelSeries(c.Request.Context(), userID, middleware.UserRole(c), seriesID, from, req.Reason)
+	switch {
+	case errors.Is(err, services.ErrSeriesNotFound):
+		apierror.Respond(c, http.StatusNotFound, err)
+		return
+	case errors.Is(err, services.ErrGatewayFailure):
+		apierror.RespondWith(c, http.StatusBadGateway, err, gin.H{"cancelled": cancelled})
+		return
+	case err != nil:
+		apierror.RespondWith(c, http.StatusInternalServerError, err, gin.H{"cancelled": cancelled})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"cancelled": cancelled})
+}
+
+// respondSeriesError maps errors from booking a series to responses
+func respondSeriesError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrNothingToBook):
+		apierror.Respond(c, http.StatusConflict, err)
+	case errors.Is(err, services.ErrInvalidSeries):
+		apierror.Respond(c, http.StatusBadRequest, err)
+	default:
+		respondBookingError(c, err)
+	}
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"diro-be/internal/apierror"
+	"diro-be/internal/services"
+)
+
+// TimeslotHandler handles timeslot management HTTP requests from venue staff
+type TimeslotHandler struct {
+	timeslotService *services.TimeslotService
+}
+
+// NewTimeslotHandler creates a new timeslot handler
+func NewTimeslotHandler(timeslotService *services.TimeslotService) *TimeslotHandler {
+	return &TimeslotHandler{
+		timeslotService: timeslotService,
+	}
+}
+
+// ListTimeslots godoc
+// @Summary List timeslots
+// @Description List every timeslot, including retired ones
+// @Tags admin-timeslots
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.Timeslot
+// @Failure 500 {object} map[string]string "error: message"
+// @Router /api/v1/admin/timeslots [get]
+func (h *TimeslotHandler) ListTimeslots(c *gin.Context) {
+	timeslots, err := h.timeslotService.ListTimeslots(c.Request.Context())
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, timeslots)
+}
+
+// CreateTimeslot godoc
+// @Summary Create a timeslot
+// @Description Create a timeslot. Times use the HH:MM format, the end must be after the start and the slot must not overlap another active timeslot.
+// @Tags admin-timeslots
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param timeslot body object true "start_time, end_time"
+// @Success 201 {object} models.Timeslot
+// @Failure 400 {object} map[string]string "error: message"
+// @Router /api/v1/admin/timeslots [post]
+func (h *TimeslotHandler) CreateTimeslot(c *gin.Context) {
+	var req struct {
+		StartTime string `json:"start_time" binding:"required"`
+		EndTime   string `json:"end_time" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err)
+		return
+	}
+
+	timeslot, err := h.timeslotService.CreateTimeslot(c.Request.Context(), req.StartTime, req.EndTime)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, timeslot)
+}
+
+// UpdateTimeslot godoc
+// @Summary Update a timeslot
+// @Description Change the times of a timeslot. Fails with 409 listing the affected future reservations unless force=true.
+// @Tags admin-timeslots
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Timeslot ID"
+// @Param force query bool false "Apply even with future reservations"
+// @Param timeslot body object true "start_time, end_time"
+// @Success 200 {object} map[string]interface{} "timeslot: object, affected_reservations: array"
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 404 {object} map[string]string "error: message"
+// @Failure 409 {object} map[string]interface{} "error: message, reservations: array"
+// @Router /api/v1/admin/timeslots/{id} [put]
+func (h *TimeslotHandler) UpdateTimeslot(c *gin.Context) {
+	timeslotID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		StartTime string `json:"start_time" binding:"required"`
+		EndTime   string `json:"end_time" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err)
+		return
+	}
+
+	timeslot, affected, err := h.timeslotService.UpdateTimeslot(c.Request.Context(), timeslotID, req.StartTime, req.EndTime, c.Query("force") == "true")
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"timeslot":              timeslot,
+		"affected_reservations": affected,
+	})
+}
+
+// RetireTimeslot godoc
+// @Summary Retire a timeslot
+// @Description Deactivate a timeslot so it can no longer be booked. Fails with 409 listing the affected future reservations unless force=true.
+// @Tags admin-timeslots
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Timeslot ID"
+// @Param force query bool false "Retire even with future reservations"
+// @Success 200 {object} map[string]interface{} "timeslot: object, affected_reservations: array"
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 404 {object} map[string]string "error: message"
+// @Failure 409 {object} map[string]interface{} "error: message, reservations: array"
+// @Router /api/v1/admin/timeslots/{id}/retire [post]
+func (h *TimeslotHandler) RetireTimeslot(c *gin.Context) {
+	timeslotID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	timeslot, affected, err := h.timeslotService.RetireTimeslot(c.Request.Context(), timeslotID, c.Query("force") == "true")
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"timeslot":              timeslot,
+		"affected_reservations": affected,
+	})
+}
+
+func (h *TimeslotHandler) respondError(c *gin.Context, err error) {
+	var inUse *services.TimeslotInUseError
+	switch {
+	case errors.Is(err, services.ErrTimeslotNotFound):
+		apierror.Respond(c, http.StatusNotFound, err)
+	case errors.Is(err, services.ErrInvalidTimeslot):
+		apierror.Respond(c, http.StatusBadRequest, err)
+	case errors.As(err, &inUse):
+		apierror.RespondWith(c, http.StatusConflict, err, gin.H{"reservations": inUse.Reservations})
+	default:
+		apierror.Respond(c, http.StatusInternalServerError, err)
+	}
+}
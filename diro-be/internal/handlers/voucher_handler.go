@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"diro-be/internal/apierror"
+	"diro-be/internal/models"
+	"diro-be/internal/repositories"
+	"diro-be/internal/services"
+)
+
+// VoucherHandler handles promo code voucher HTTP requests from venue staff
+type VoucherHandler struct {
+	voucherService *services.VoucherService
+}
+
+// NewVoucherHandler creates a new voucher handler
+func NewVoucherHandler(voucherService *services.VoucherService) *VoucherHandler {
+	return &VoucherHandler{
+		voucherService: voucherService,
+	}
+}
+
+// voucherRequest is the body for creating or replacing a voucher
+type voucherRequest struct {
+	Code               string     `json:"code" binding:"required"`
+	Description        string     `json:"description"`
+	DiscountType       string     `json:"discount_type" binding:"required"` // percentage, fixed
+	DiscountValue      float64    `json:"discount_value" binding:"required"`
+	MaxDiscount        float64    `json:"max_discount"`
+	ValidFrom          *time.Time `json:"valid_from"`  // RFC 3339
+	ValidUntil         *time.Time `json:"valid_until"` // RFC 3339
+	MaxUses            int        `json:"max_uses"`
+	MaxUsesPerCustomer int        `json:"max_uses_per_customer"`
+	CourtID            *uint      `json:"court_id"`
+	StartTime          string     `json:"start_time"`
+	EndTime            string     `json:"end_time"`
+	IsActive           *bool      `json:"is_active"` // Defaults to true
+}
+
+// ListVouchers godoc
+// @Summary List vouchers
+// @Tags admin-vouchers
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.Voucher
+// @Failure 500 {object} map[string]string "error: message"
+// @Router /api/v1/admin/vouchers [get]
+func (h *VoucherHandler) ListVouchers(c *gin.Context) {
+	vouchers, err := h.voucherService.ListVouchers(c.Request.Context())
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, vouchers)
+}
+
+// GetVoucher godoc
+// @Summary Get a voucher
+// @Tags admin-vouchers
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Voucher ID"
+// @Success 200 {object} models.Voucher
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 404 {object} map[string]string "error: message"
+// @Router /api/v1/admin/vouchers/{id} [get]
+func (h *VoucherHandler) GetVoucher(c *gin.Context) {
+	voucherID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	voucher, err := h.voucherService.GetVoucher(c.Request.Context(), voucherID)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, voucher)
+}
+
+// CreateVoucher godoc
+// @Summary Create a voucher
+// @Description Create a promo code with a percentage or fixed discount, an optional validity window, usage caps per code and per customer (0 is unlimited), and court and slot start time restrictions
+// @Tags admin-vouchers
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param voucher body object true "code, discount_type, discount_value, max_discount, valid_from, valid_until, max_uses, max_uses_per_customer, court_id, start_time, end_time, is_active"
+// @Success 201 {object} models.Voucher
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 409 {object} map[string]string "error: message"
+// @Router /api/v1/admin/vouchers [post]
+func (h *VoucherHandler) CreateVoucher(c *gin.Context) {
+	voucher, ok := bindVoucher(c)
+	if !ok {
+		return
+	}
+
+	voucher, err := h.voucherService.CreateVoucher(c.Request.Context(), voucher)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, voucher)
+}
+
+// UpdateVoucher godoc
+// @Summary Update a voucher
+// @Description Replace the details of a voucher. Set is_active to false to stop a promotion.
+// @Tags admin-vouchers
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Voucher ID"
+// @Param voucher body object true "code, discount_type, discount_value, max_discount, valid_from, valid_until, max_uses, max_uses_per_customer, court_id, start_time, end_time, is_active"
+// @Success 200 {object} models.Voucher
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 404 {object} map[string]string "error: message"
+// @Failure 409 {object} map[string]string "error: message"
+// @Router /api/v1/admin/vouchers/{id} [put]
+func (h *VoucherHandler) UpdateVoucher(c *gin.Context) {
+	voucherID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	voucher, ok := bindVoucher(c)
+	if !ok {
+		return
+	}
+
+	voucher, err := h.voucherService.UpdateVoucher(c.Request.Context(), voucherID, voucher)
+	if err != nil {
+		h.respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, voucher)
+}
+
+// bindVoucher binds a voucher body, responding with 400 if it is invalid
+func bindVoucher(c *gin.Context) (*models.Voucher, bool) {
+	var req voucherRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err)
+		return nil, false
+	}
+
+	return &models.Voucher{
+		Code:               req.Code,
+		Description:        req.Description,
+		DiscountType:       req.DiscountType,
+		DiscountValue:      req.DiscountValue,
+		MaxDiscount:        req.MaxDiscount,
+		ValidFrom:          req.ValidFrom,
+		ValidUntil:         req.ValidUntil,
+		MaxUses:            req.MaxUses,
+		MaxUsesPerCustomer: req.MaxUsesPerCustomer,
+		CourtID:            req.CourtID,
+		StartTime:          req.StartTime,
+		EndTime:            req.EndTime,
+		IsActive:           req.IsActive == nil || *req.IsActive,
+	}, true
+}
+
+func (h *VoucherHandler) respondError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrVoucherNotFound):
+		apierror.Respond(c, http.StatusNotFound, err)
+	case errors.Is(err, services.ErrInvalidVoucher):
+		apierror.Respond(c, http.StatusBadRequest, err)
+	case errors.Is(err, repositories.ErrVoucherCodeTaken):
+		apierror.Respond(c, http.StatusConflict, err)
+	default:
+		apierror.Respond(c, http.StatusInternalServerError, err)
+	}
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"diro-be/internal/apierror"
+	"diro-be/internal/middleware"
+	"diro-be/internal/repositories"
+	"diro-be/internal/services"
+)
+
+// JoinWaitlist godoc
+// @Summary Join the waitlist for a slot
+// @Description Get in line for a fully booked slot on a court, or on any court at that time without a court_id. When the slot is cancelled or its payment expires, the first customer in line is offered it for a limited time.
+// @Tags waitlist
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param entry body object true "court_id, timeslot_id, date"
+// @Success 201 {object} models.WaitlistEntry
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 401 {object} map[string]string "error: message"
+// @Failure 409 {object} map[string]string "error: slot is available or already on the waitlist"
+// @Router /api/v1/waitlist [post]
+func (h *ReservationHandler) JoinWaitlist(c *gin.Context) {
+	var req struct {
+		CourtID    *uint  `json:"court_id"`
+		TimeslotID uint   `json:"timeslot_id" binding:"required"`
+		Date       string `json:"date" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err)
+		return
+	}
+
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		apierror.Message(c, http.StatusBadRequest, "invalid date format")
+		return
+	}
+
+	userID, _ := middleware.UserID(c)
+	entry, err := h.reservationService.JoinWaitlist(c.Request.Context(), userID, req.CourtID, req.TimeslotID, date)
+	if err != nil {
+		respondWaitlistError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+// ListMyWaitlist godoc
+// @Summary List my waitlist entries
+// @Description List the authenticated customer's waitlist entries with their offers, newest first
+// @Tags waitlist
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.WaitlistEntry
+// @Failure 401 {object} map[string]string "error: message"
+// @Failure 500 {object} map[string]string "error: message"
+// @Router /api/v1/me/waitlist [get]
+func (h *ReservationHandler) ListMyWaitlist(c *gin.Context) {
+	userID, _ := middleware.UserID(c)
+
+	entries, err := h.reservationService.ListCustomerWaitlist(c.Request.Context(), userID)
+	if err != nil {
+		apierror.Respond(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// AcceptWaitlistOffer godoc
+// @Summary Book a slot offered from the waitlist
+// @Description Book the slot offered to the authenticated customer before the offer expires
+// @Tags waitlist
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Waitlist entry ID"
+// @Param booking body object true "customer, promo_code"
+// @Success 201 {object} map[string]interface{} "reservation: object, invoice_url: string"
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 401 {object} map[string]string "error: message"
+// @Failure 404 {object} map[string]string "error: message"
+// @Failure 409 {object} map[string]string "error: no open offer or slot is already reserved"
+// @Router /api/v1/waitlist/{id}/accept [post]
+func (h *ReservationHandler) AcceptWaitlistOffer(c *gin.Context) {
+	entryID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Customer  customerRequest `json:"customer" binding:"required"`
+		PromoCode string          `json:"promo_code"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err)
+		return
+	}
+
+	userID, _ := middleware.UserID(c)
+	reservations, invoiceURL, err := h.reservationService.AcceptWaitlistOffer(c.Request.Context(), userID, entryID, req.Customer.toXendit(), req.PromoCode)
+	if err != nil {
+		respondWaitlistError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"reservation": reservations[0],
+		"invoice_url": invoiceURL,
+	})
+}
+
+// LeaveWaitlist godoc
+// @Summary Leave the waitlist
+// @Description Take the authenticated customer out of line for a slot. A slot on offer to them goes to the next customer in line.
+// @Tags waitlist
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path int true "Waitlist entry ID"
+// @Success 200 {object} models.WaitlistEntry
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 401 {object} map[string]string "error: message"
+// @Failure 404 {object} map[string]string "error: message"
+// @Failure 409 {object} map[string]string "error: message"
+// @Router /api/v1/waitlist/{id} [delete]
+func (h *ReservationHandler) LeaveWaitlist(c *gin.Context) {
+	entryID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.UserID(c)
+	entry, err := h.reservationService.LeaveWaitlist(c.Request.Context(), userID, entryID)
+	if err != nil {
+		respondWaitlistError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entry)
+}
+
+// respondWaitlistError maps errors from the waitlist to responses
+func respondWaitlistError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrWaitlistEntryNotFound):
+		apierror.Respond(c, http.StatusNotFound, err)
+	case errors.Is(err, services.ErrSlotAvailable), errors.Is(err, services.ErrAlreadyWaitlisted),
+		errors.Is(err, services.ErrNoOpenOffer), errors.Is(err, repositories.ErrStaleWaitlistEntry):
+		apierror.Respond(c, http.StatusConflict, err)
+	case errors.Is(err, services.ErrInvalidWaitlistEntry):
+		apierror.Respond(c, http.StatusBadRequest, err)
+	default:
+		respondBookingError(c, err)
+	}
+}
@@ -1,24 +1,32 @@
 package handlers
 
 import (
+	"crypto/subtle"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 
+	"diro-be/internal/apierror"
 	"diro-be/internal/models"
+	"diro-be/internal/repositories"
 	"diro-be/internal/services"
 )
 
 // WebhookHandler handles webhook HTTP requests
 type WebhookHandler struct {
 	reservationService *services.ReservationService
+	callbackToken      string
 }
 
-// NewWebhookHandler creates a new webhook handler
-func NewWebhookHandler(reservationService *services.ReservationService) *WebhookHandler {
+// NewWebhookHandler creates a new webhook handler that only accepts callbacks
+// carrying the given Xendit callback verification token
+func NewWebhookHandler(reservationService *services.ReservationService, callbackToken string) *WebhookHandler {
 	return &WebhookHandler{
 		reservationService: reservationService,
+		callbackToken:      callbackToken,
 	}
 }
 
@@ -28,32 +36,109 @@ func NewWebhookHandler(reservationService *services.ReservationService) *Webhook
 // @Tags webhooks
 // @Accept json
 // @Produce json
+// @Param x-callback-token header string true "Xendit callback verification token"
 // @Param payload body models.XenditWebhookPayload true "Xendit webhook payload"
 // @Success 200 {object} map[string]string "message: webhook received"
 // @Failure 400 {object} map[string]string "error: message"
+// @Failure 401 {object} map[string]string "error: message"
+// @Failure 404 {object} map[string]string "error: message"
 // @Failure 500 {object} map[string]string "error: message"
 // @Router /api/v1/webhooks/xendit [post]
 func (h *WebhookHandler) XenditWebhook(c *gin.Context) {
+	if !h.verifyCallbackToken(c.GetHeader("x-callback-token")) {
+		apierror.Message(c, http.StatusUnauthorized, "invalid callback token")
+		return
+	}
+
 	var payload models.XenditWebhookPayload
 
 	if err := c.ShouldBindJSON(&payload); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apierror.Respond(c, http.StatusBadRequest, err)
 		return
 	}
 
 	// Assuming external_id is the reservation ID
 	reservationID, err := strconv.ParseUint(payload.ExternalID, 10, 32)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid external_id"})
+		apierror.Message(c, http.StatusBadRequest, "invalid external_id")
 		return
 	}
 
 	// Update reservation status based on payment status
-	err = h.reservationService.UpdatePaymentStatus(uint(reservationID), payload.Status)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	err = h.reservationService.HandleInvoiceWebhook(c.Request.Context(), uint(reservationID), payload)
+	switch {
+	case errors.Is(err, repositories.ErrDuplicateEvent):
+		c.JSON(http.StatusOK, gin.H{"message": "webhook already processed"})
+		return
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		apierror.Message(c, http.StatusNotFound, "reservation not found")
+		return
+	case errors.Is(err, services.ErrWebhookMismatch):
+		apierror.Respond(c, http.StatusBadRequest, err)
+		return
+	case err != nil:
+		apierror.Respond(c, http.StatusInternalServerError, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "webhook received"})
 }
+
+// XenditRefundWebhook godoc
+// @Summary Handle Xendit refund webhook
+// @Description Handle refund status callbacks from Xendit, moving the refunded reservation to partially_refunded or refunded once the refund succeeded
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param x-callback-token header string true "Xendit callback verification token"
+// @Param payload body models.XenditRefundWebhookPayload true "Xendit refund webhook payload"
+// @Success 200 {object} map[string]string "message: webhook received"
+// @Failure 400 {object} map[string]string "error: message"
+// @Failure 401 {object} map[string]string "error: message"
+// @Failure 404 {object} map[string]string "error: message"
+// @Failure 500 {object} map[string]string "error: message"
+// @Router /api/v1/webhooks/xendit/refunds [post]
+func (h *WebhookHandler) XenditRefundWebhook(c *gin.Context) {
+	if !h.verifyCallbackToken(c.GetHeader("x-callback-token")) {
+		apierror.Message(c, http.StatusUnauthorized, "invalid callback token")
+		return
+	}
+
+	var payload models.XenditRefundWebhookPayload
+
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		apierror.Respond(c, http.StatusBadRequest, err)
+		return
+	}
+	if payload.Data.ReferenceID == "" {
+		apierror.Message(c, http.StatusBadRequest, "missing reference_id")
+		return
+	}
+
+	err := h.reservationService.HandleRefundWebhook(c.Request.Context(), payload)
+	switch {
+	case errors.Is(err, repositories.ErrDuplicateEvent):
+		c.JSON(http.StatusOK, gin.H{"message": "webhook already processed"})
+		return
+	case errors.Is(err, services.ErrRefundNotFound), errors.Is(err, gorm.ErrRecordNotFound):
+		apierror.Message(c, http.StatusNotFound, "refund not found")
+		return
+	case errors.Is(err, services.ErrWebhookMismatch):
+		apierror.Respond(c, http.StatusBadRequest, err)
+		return
+	case err != nil:
+		apierror.Respond(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "webhook received"})
+}
+
+// verifyCallbackToken compares the token Xendit sends with every callback
+// against the configured one. Without a configured token nothing is accepted.
+func (h *WebhookHandler) verifyCallbackToken(token string) bool {
+	if h.callbackToken == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(h.callbackToken)) == 1
+}
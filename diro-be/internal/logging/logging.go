@@ -0,0 +1,129 @@
+// Package logging sets up the structured logger of the backend, keeps secrets
+// out of everything it logs and ties log lines to the request they belong to
+package logging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces secrets in log output
+const Redacted = "[REDACTED]"
+
+// minSecretLength is the shortest value redacted; shorter values would match
+// too much ordinary text to be useful
+const minSecretLength = 4
+
+var (
+	mu       sync.RWMutex
+	secrets  = make(map[string]bool)
+	replacer = strings.NewReplacer()
+)
+
+// RegisterSecrets marks values that must never appear in log output, such as
+// passwords, API keys and tokens. Values shorter than four characters are
+// ignored.
+func RegisterSecrets(values ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, value := range values {
+		if len(value) >= minSecretLength {
+			secrets[value] = true
+		}
+	}
+
+	// Replace longer secrets first so one containing another is fully redacted
+	sorted := make([]string, 0, len(secrets))
+	for secret := range secrets {
+		sorted = append(sorted, secret)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+
+	pairs := make([]string, 0, 2*len(sorted))
+	for _, secret := range sorted {
+		pairs = append(pairs, secret, Redacted)
+	}
+	replacer = strings.NewReplacer(pairs...)
+}
+
+// Redact returns s with every registered secret replaced
+func Redact(s string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return replacer.Replace(s)
+}
+
+// redactingWriter redacts registered secrets from everything written through it
+type redactingWriter struct {
+	w io.Writer
+}
+
+// NewWriter wraps w so registered secrets are redacted from what is written to it
+func NewWriter(w io.Writer) io.Writer {
+	return &redactingWriter{w: w}
+}
+
+func (rw *redactingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(rw.w, Redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Log formats
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Options configures the logger Setup installs
+type Options struct {
+	Level   string   // debug, info, warn or error; info when empty or unknown
+	Format  string   // FormatJSON or FormatText
+	Secrets []string // Redacted from every log line
+}
+
+// Setup registers the secrets and makes a structured logger writing to stderr
+// the default slog logger. The standard log package writes through it too.
+// Every line logged with a context carrying a request ID includes that ID.
+func Setup(opts Options) {
+	RegisterSecrets(opts.Secrets...)
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(opts.Level)); err != nil {
+		level = slog.LevelInfo
+	}
+	handlerOpts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	if opts.Format == FormatText {
+		handler = slog.NewTextHandler(NewWriter(os.Stderr), handlerOpts)
+	} else {
+		handler = slog.NewJSONHandler(NewWriter(os.Stderr), handlerOpts)
+	}
+	slog.SetDefault(slog.New(contextHandler{handler}))
+}
+
+// Fatal logs msg at error level and exits
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// NewCorrelationID returns a random ID that ties an error shown to a client
+// or returned by a command to the detailed log entry written for it. Requests
+// without an ID of their own are given one too.
+func NewCorrelationID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
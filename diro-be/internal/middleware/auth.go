@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"diro-be/internal/apierror"
+	"diro-be/internal/services"
+)
+
+// Context keys set by the auth middleware
+const (
+	ContextUserID   = "userID"
+	ContextUserRole = "userRole"
+)
+
+// RequireAuth rejects requests without a valid bearer access token and stores
+// the authenticated user ID and role in the context
+func RequireAuth(authService *services.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := bearerToken(c)
+		if !ok {
+			apierror.Message(c, http.StatusUnauthorized, "authorization header is required")
+			return
+		}
+
+		if !authenticate(c, authService, token) {
+			apierror.Respond(c, http.StatusUnauthorized, services.ErrInvalidToken)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// OptionalAuth authenticates the request when it carries a bearer token but
+// lets anonymous requests through. An invalid token is still rejected.
+func OptionalAuth(authService *services.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := bearerToken(c)
+		if ok && !authenticate(c, authService, token) {
+			apierror.Respond(c, http.StatusUnauthorized, services.ErrInvalidToken)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireRole rejects authenticated requests whose user does not have the
+// given role. It must run after RequireAuth.
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if UserRole(c) != role {
+			apierror.Message(c, http.StatusForbidden, "insufficient permissions")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// UserID returns the authenticated user ID, if any
+func UserID(c *gin.Context) (uint, bool) {
+	userID, ok := c.Get(ContextUserID)
+	if !ok {
+		return 0, false
+	}
+	id, ok := userID.(uint)
+	return id, ok
+}
+
+// UserRole returns the role of the authenticated user, if any
+func UserRole(c *gin.Context) string {
+	return c.GetString(ContextUserRole)
+}
+
+func authenticate(c *gin.Context, authService *services.AuthService, token string) bool {
+	claims, err := authService.ParseAccessToken(token)
+	if err != nil {
+		return false
+	}
+
+	userID, err := claims.UserID()
+	if err != nil {
+		return false
+	}
+
+	c.Set(ContextUserID, userID)
+	c.Set(ContextUserRole, claims.Role)
+	return true
+}
+
+func bearerToken(c *gin.Context) (string, bool) {
+	header := c.GetHeader("Authorization")
+	token, found := strings.CutPrefix(header, "Bearer ")
+	if !found || token == "" {
+		return "", false
+	}
+	return token, true
+}
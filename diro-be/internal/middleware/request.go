@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"diro-be/internal/apierror"
+	"diro-be/internal/logging"
+)
+
+// requestIDPattern matches the request IDs accepted from clients; others are
+// replaced so they cannot break up or flood log lines
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,64}$`)
+
+// RequestID takes the request ID from the X-Request-ID header, or generates
+// one, echoes it in the response and stores it in the request context so
+// every layer logs it
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(logging.RequestIDHeader)
+		if !requestIDPattern.MatchString(id) {
+			id = logging.NewCorrelationID()
+		}
+
+		c.Header(logging.RequestIDHeader, id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// Timeout gives the request context a deadline, so the database queries and
+// gateway calls of a request that takes too long are abandoned. The request
+// context is also cancelled when the client disconnects.
+func Timeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// RequestLogger logs one line for every request once it was handled. The
+// query string is left out as it may carry personal data.
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("route", c.FullPath()),
+			slog.Int("status", status),
+			slog.Int64("duration_ms", time.Since(start).Milliseconds()),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if userID, ok := UserID(c); ok {
+			attrs = append(attrs, slog.Uint64("user_id", uint64(userID)))
+		}
+		slog.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// Recovery turns a panicking handler into an internal server error, logging
+// the panic with its stack trace
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, recovered any) {
+		slog.ErrorContext(c.Request.Context(), "handler panicked",
+			"panic", fmt.Sprint(recovered),
+			"stack", string(debug.Stack()))
+		apierror.Respond(c, http.StatusInternalServerError, fmt.Errorf("panic: %v", recovered))
+	})
+}
@@ -1,17 +1,47 @@
 package models
 
 import (
+	"fmt"
 	"time"
+
+	"gorm.io/gorm"
+)
+
+// User roles
+const (
+	UserRoleCustomer = "customer"
+	UserRoleAdmin    = "admin"
 )
 
+// User represents a customer or staff account
+type User struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	Name         string    `json:"name" gorm:"not null"`
+	Email        string    `json:"email" gorm:"size:191;not null;uniqueIndex"`
+	MobileNumber string    `json:"mobile_number"`
+	PasswordHash string    `json:"-" gorm:"not null"`
+	Role         string    `json:"role" gorm:"size:20;default:'customer'"` // customer, admin
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// AuthTokens is the token pair issued on registration, login and refresh
+type AuthTokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"` // Access token lifetime in seconds
+}
+
 // Court represents a badminton court
 type Court struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	Name        string    `json:"name" gorm:"not null"`
-	Description string    `json:"description"`
-	IsActive    bool      `json:"is_active" gorm:"default:true"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	Name        string         `json:"name" gorm:"not null"`
+	Description string         `json:"description"`
+	IsActive    bool           `json:"is_active" gorm:"default:true"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at,omitempty" gorm:"index" swaggertype:"string"`
 }
 
 // Timeslot represents available time slots
@@ -24,23 +54,314 @@ type Timeslot struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// CourtSchedule makes a timeslot bookable on a court on one day of the week
+// while the schedule is in effect
+type CourtSchedule struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	CourtID       uint       `json:"court_id" gorm:"not null;index:idx_court_schedules_court_weekday"`
+	Weekday       int        `json:"weekday" gorm:"not null;index:idx_court_schedules_court_weekday"` // 0 is Sunday, as in time.Weekday
+	TimeslotID    uint       `json:"timeslot_id" gorm:"not null"`
+	EffectiveFrom time.Time  `json:"effective_from" gorm:"type:date;not null"`
+	EffectiveTo   *time.Time `json:"effective_to,omitempty" gorm:"type:date"` // Open ended when empty
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+
+	// Relations
+	Timeslot Timeslot `json:"timeslot" gorm:"foreignKey:TimeslotID"`
+}
+
+// PriceRule sets the price of the slots it matches. Empty conditions match
+// everything; when several active rules match a slot the one with the highest
+// priority wins.
+type PriceRule struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	Name        string     `json:"name" gorm:"not null"`
+	CourtID     *uint      `json:"court_id,omitempty" gorm:"index"` // All courts when empty
+	Weekday     *int       `json:"weekday,omitempty"`               // 0 is Sunday, every day when empty
+	StartTime   string     `json:"start_time"`                      // Format: "HH:MM", matches slots starting at or after it
+	EndTime     string     `json:"end_time"`                        // Format: "HH:MM", matches slots starting before it
+	DateFrom    *time.Time `json:"date_from,omitempty" gorm:"type:date"`
+	DateTo      *time.Time `json:"date_to,omitempty" gorm:"type:date"`
+	HolidayOnly bool       `json:"holiday_only" gorm:"default:false"` // Only matches dates in the holidays table
+	Price       float64    `json:"price" gorm:"not null"`
+	Priority    int        `json:"priority" gorm:"default:0"`
+	IsActive    bool       `json:"is_active"` // No default, which would store false as true
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// Blackout closes a court, or the whole venue when CourtID is empty, from
+// StartsAt until EndsAt for maintenance, tournaments or holidays
+type Blackout struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CourtID   *uint     `json:"court_id,omitempty" gorm:"index"` // Whole venue when empty
+	StartsAt  time.Time `json:"starts_at" gorm:"not null;index"`
+	EndsAt    time.Time `json:"ends_at" gorm:"not null"`
+	Reason    string    `json:"reason" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Holiday is a date that price rules can single out
+type Holiday struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Date      time.Time `json:"date" gorm:"type:date;not null;uniqueIndex"`
+	Name      string    `json:"name" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Voucher discount types
+const (
+	DiscountTypePercentage = "percentage"
+	DiscountTypeFixed      = "fixed"
+)
+
+// Voucher is a promo code customers can apply at checkout. Restrictions left
+// empty do not apply; usage caps of 0 are unlimited.
+type Voucher struct {
+	ID                 uint       `json:"id" gorm:"primaryKey"`
+	Code               string     `json:"code" gorm:"size:64;not null;uniqueIndex"` // Stored upper case
+	Description        string     `json:"description"`
+	DiscountType       string     `json:"discount_type" gorm:"size:20;not null"` // percentage, fixed
+	DiscountValue      float64    `json:"discount_value" gorm:"not null"`        // Percent off or amount off
+	MaxDiscount        float64    `json:"max_discount" gorm:"default:0"`         // Caps percentage discounts when set
+	ValidFrom          *time.Time `json:"valid_from,omitempty"`
+	ValidUntil         *time.Time `json:"valid_until,omitempty"`
+	MaxUses            int        `json:"max_uses" gorm:"default:0"`
+	MaxUsesPerCustomer int        `json:"max_uses_per_customer" gorm:"default:0"` // Requires a signed in customer when set
+	CourtID            *uint      `json:"court_id,omitempty"`
+	StartTime          string     `json:"start_time"` // Format: "HH:MM", applies to slots starting at or after it
+	EndTime            string     `json:"end_time"`   // Format: "HH:MM", applies to slots starting before it
+	IsActive           bool       `json:"is_active"`  // No default, which would store false as true
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+// Reservation series frequencies
+const (
+	SeriesFrequencyWeekly   = "weekly"
+	SeriesFrequencyBiweekly = "biweekly"
+)
+
+// Reservation series billing modes
+const (
+	SeriesBillingSingle        = "single"         // One invoice for every occurrence
+	SeriesBillingPerOccurrence = "per_occurrence" // One invoice per occurrence
+)
+
+// ReservationSeries is a recurring booking of the same court slot, such as a
+// club playing every Tuesday at 19:00 for a season
+type ReservationSeries struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	UserID      uint      `json:"user_id" gorm:"not null;index"`
+	CourtID     uint      `json:"court_id" gorm:"not null"`
+	TimeslotID  uint      `json:"timeslot_id" gorm:"not null"`
+	Frequency   string    `json:"frequency" gorm:"size:20;not null"` // weekly, biweekly
+	StartDate   time.Time `json:"start_date" gorm:"type:date;not null"`
+	EndDate     time.Time `json:"end_date" gorm:"type:date;not null"`   // Date of the last occurrence
+	BillingMode string    `json:"billing_mode" gorm:"size:20;not null"` // single, per_occurrence
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Relations
+	Court        Court         `json:"court" gorm:"foreignKey:CourtID"`
+	Timeslot     Timeslot      `json:"timeslot" gorm:"foreignKey:TimeslotID"`
+	Reservations []Reservation `json:"reservations,omitempty" gorm:"foreignKey:SeriesID"`
+}
+
+// TableName overrides the pluralized table name
+func (ReservationSeries) TableName() string {
+	return "reservation_series"
+}
+
+// SeriesOccurrence is one date of a recurring series and whether it could be
+// booked
+type SeriesOccurrence struct {
+	Date          string `json:"date"`
+	Available     bool   `json:"available"`
+	Conflict      string `json:"conflict,omitempty"` // Why the date cannot be booked
+	ReservationID *uint  `json:"reservation_id,omitempty"`
+}
+
+// ReservationStatus is the lifecycle state of a reservation
+type ReservationStatus string
+
+// Reservation statuses
+const (
+	ReservationStatusPending   ReservationStatus = "pending"   // Waiting for payment, slot is held
+	ReservationStatusPaid      ReservationStatus = "paid"      // Paid, slot is booked
+	ReservationStatusExpired   ReservationStatus = "expired"   // Not paid before the hold expired
+	ReservationStatusFailed    ReservationStatus = "failed"    // Payment failed at the gateway
+	ReservationStatusCancelled ReservationStatus = "cancelled" // Cancelled before the slot started
+
+	ReservationStatusPartiallyRefunded ReservationStatus = "partially_refunded" // Paid and partly refunded, slot is still booked
+	ReservationStatusRefunded          ReservationStatus = "refunded"           // Paid and fully refunded, slot is released
+)
+
+// BookedStatuses are the statuses of reservations that were paid for and
+// still occupy their slot
+var BookedStatuses = []ReservationStatus{ReservationStatusPaid, ReservationStatusPartiallyRefunded}
+
+// IsBooked reports whether a reservation with this status was paid for and
+// still occupies its slot
+func (s ReservationStatus) IsBooked() bool {
+	return s == ReservationStatusPaid || s == ReservationStatusPartiallyRefunded
+}
+
+// Payment statuses, mirroring the Xendit invoice statuses
+const (
+	PaymentStatusPending = "PENDING"
+	PaymentStatusPaid    = "PAID"
+	PaymentStatusSettled = "SETTLED"
+	PaymentStatusExpired = "EXPIRED"
+	PaymentStatusFailed  = "FAILED"
+)
+
 // Reservation represents a booking reservation
 type Reservation struct {
-	ID            uint      `json:"id" gorm:"primaryKey"`
-	CourtID       uint      `json:"court_id" gorm:"not null"`
-	TimeslotID    uint      `json:"timeslot_id" gorm:"not null"`
-	Date          time.Time `json:"date" gorm:"type:date;not null"`
-	Status        string    `json:"status" gorm:"default:'pending'"` // pending, confirmed, cancelled, paid
-	TotalPrice    float64   `json:"total_price" gorm:"default:0"`
-	PaymentID     string    `json:"payment_id" gorm:"default:''"`     // Xendit invoice ID
-	InvoiceURL    string    `json:"invoice_url" gorm:"default:''"`    // Xendit invoice URL
-	PaymentStatus string    `json:"payment_status" gorm:"default:''"` // PENDING, PAID, FAILED, EXPIRED
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID             uint              `json:"id" gorm:"primaryKey"`
+	UserID         *uint             `json:"user_id,omitempty" gorm:"index"` // Empty for guest bookings
+	CourtID        uint              `json:"court_id" gorm:"not null"`
+	TimeslotID     uint              `json:"timeslot_id" gorm:"not null"`
+	Date           time.Time         `json:"date" gorm:"type:date;not null"`
+	Status         ReservationStatus `json:"status" gorm:"size:20;default:'pending'"`
+	TotalPrice     float64           `json:"total_price" gorm:"default:0"`      // Amount billed, after any discount
+	VoucherID      *uint             `json:"voucher_id,omitempty" gorm:"index"` // Voucher applied at checkout
+	PromoCode      string            `json:"promo_code,omitempty" gorm:"size:64;default:''"`
+	DiscountAmount float64           `json:"discount_amount" gorm:"default:0"`
+	SeriesID       *uint             `json:"series_id,omitempty" gorm:"index"`       // Recurring series the reservation belongs to
+	PaymentID      string            `json:"payment_id" gorm:"default:''"`           // Xendit invoice ID
+	InvoiceURL     string            `json:"invoice_url" gorm:"default:''"`          // Xendit invoice URL
+	PaymentStatus  string            `json:"payment_status" gorm:"default:''"`       // PENDING, PAID, FAILED, EXPIRED
+	HoldExpiresAt  *time.Time        `json:"hold_expires_at,omitempty" gorm:"index"` // Slot is held until this time
+	SlotKey        *string           `json:"-" gorm:"size:64;uniqueIndex"`           // Set while the reservation occupies its slot
+	RefundAmount   float64           `json:"refund_amount" gorm:"default:0"`         // Refunded or being refunded
+	CancelledAt    *time.Time        `json:"cancelled_at,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
 
 	// Relations
 	Court    Court    `json:"court" gorm:"foreignKey:CourtID"`
 	Timeslot Timeslot `json:"timeslot" gorm:"foreignKey:TimeslotID"`
+	User     *User    `json:"user,omitempty" gorm:"foreignKey:UserID"` // Only loaded where the customer is listed
+}
+
+// IsActive reports whether the reservation occupies its court slot: it is
+// booked, or pending with a hold that has not been released yet
+func (r *Reservation) IsActive() bool {
+	return r.Status.IsBooked() ||
+		(r.Status == ReservationStatusPending && r.HoldExpiresAt != nil)
+}
+
+// BeforeSave keeps SlotKey in sync with the status so the unique index on it
+// lets at most one active reservation exist per court, timeslot and date
+func (r *Reservation) BeforeSave(tx *gorm.DB) error {
+	if r.IsActive() {
+		key := SlotKey(r.CourtID, r.TimeslotID, r.Date)
+		r.SlotKey = &key
+	} else {
+		r.SlotKey = nil
+	}
+	return nil
+}
+
+// SlotKey identifies a court slot on a specific date
+func SlotKey(courtID, timeslotID uint, date time.Time) string {
+	return fmt.Sprintf("%d:%d:%s", courtID, timeslotID, date.Format("2006-01-02"))
+}
+
+// Waitlist entry statuses
+const (
+	WaitlistStatusWaiting   = "waiting"   // In line for the slot
+	WaitlistStatusOffered   = "offered"   // The slot is held for the customer until the offer expires
+	WaitlistStatusBooked    = "booked"    // The customer booked the slot
+	WaitlistStatusExpired   = "expired"   // The offer lapsed or the date passed
+	WaitlistStatusCancelled = "cancelled" // The customer left the waitlist
+)
+
+// WaitlistEntry puts a customer in line for a fully booked slot, on one
+// court or on any court at that time. When the slot is released the first
+// customer in line is offered it for a limited time.
+type WaitlistEntry struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	UserID         uint       `json:"user_id" gorm:"not null;index"`
+	CourtID        *uint      `json:"court_id,omitempty"` // Any court at this time when empty
+	TimeslotID     uint       `json:"timeslot_id" gorm:"not null;index:idx_waitlist_entries_slot"`
+	Date           time.Time  `json:"date" gorm:"type:date;not null;index:idx_waitlist_entries_slot"`
+	Status         string     `json:"status" gorm:"size:20;not null;default:'waiting'"`
+	OfferedCourtID *uint      `json:"offered_court_id,omitempty"` // Court of the slot on offer
+	OfferExpiresAt *time.Time `json:"offer_expires_at,omitempty"`
+	ReservationID  *uint      `json:"reservation_id,omitempty"` // Reservation booked from the offer
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Relations
+	Court    *Court   `json:"court,omitempty" gorm:"foreignKey:CourtID"`
+	Timeslot Timeslot `json:"timeslot" gorm:"foreignKey:TimeslotID"`
+}
+
+// Refund statuses, mirroring the Xendit refund statuses
+const (
+	RefundStatusPending   = "PENDING"
+	RefundStatusSucceeded = "SUCCEEDED"
+	RefundStatusFailed    = "FAILED"
+)
+
+// Refund reasons accepted by Xendit
+const (
+	RefundReasonRequestedByCustomer = "REQUESTED_BY_CUSTOMER"
+	RefundReasonCancellation        = "CANCELLATION"
+	RefundReasonDuplicate           = "DUPLICATE"
+	RefundReasonFraudulent          = "FRAUDULENT"
+	RefundReasonOthers              = "OTHERS"
+)
+
+// Refund is a full or partial refund of a paid reservation through the
+// payment gateway. Its status follows the gateway's refund callbacks.
+type Refund struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	ReservationID   uint      `json:"reservation_id" gorm:"not null;index"`
+	GatewayRefundID string    `json:"gateway_refund_id" gorm:"size:64;index;default:''"` // Xendit refund ID
+	ReferenceID     string    `json:"reference_id" gorm:"size:100;not null;uniqueIndex"` // Sent to Xendit to make the request idempotent
+	Amount          float64   `json:"amount" gorm:"not null"`
+	Reason          string    `json:"reason" gorm:"size:32;not null"` // One of the Xendit refund reasons
+	Note            string    `json:"note,omitempty" gorm:"size:255;default:''"`
+	Status          string    `json:"status" gorm:"size:20;not null;default:'PENDING'"` // PENDING, SUCCEEDED, FAILED
+	FailureCode     string    `json:"failure_code,omitempty" gorm:"size:64;default:''"`
+	Actor           string    `json:"actor" gorm:"size:100"` // Who requested it, e.g. "user:1"
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// ReservationStatusHistory records a status transition of a reservation
+type ReservationStatusHistory struct {
+	ID            uint              `json:"id" gorm:"primaryKey"`
+	ReservationID uint              `json:"reservation_id" gorm:"not null;index"`
+	FromStatus    ReservationStatus `json:"from_status" gorm:"size:20"`
+	ToStatus      ReservationStatus `json:"to_status" gorm:"size:20;not null"`
+	PaymentStatus string            `json:"payment_status"`
+	Trigger       string            `json:"trigger" gorm:"size:50;not null"` // What caused the transition, e.g. "booking", "webhook", "hold_expiry"
+	Actor         string            `json:"actor" gorm:"size:100"`           // Who caused it, e.g. "xendit", "system"
+	Reason        string            `json:"reason"`
+	CreatedAt     time.Time         `json:"created_at"`
+}
+
+// TableName overrides the pluralized table name
+func (ReservationStatusHistory) TableName() string {
+	return "reservation_status_history"
+}
+
+// WebhookEvent records a processed payment webhook so redeliveries are ignored
+type WebhookEvent struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	EventID       string    `json:"event_id" gorm:"size:191;not null;uniqueIndex"` // Invoice ID and status, e.g. "inv-123:PAID"
+	InvoiceID     string    `json:"invoice_id" gorm:"size:191;index"`
+	ReservationID uint      `json:"reservation_id" gorm:"index"`
+	Status        string    `json:"status"`  // Invoice status reported by the webhook
+	Applied       bool      `json:"applied"` // False when the event was out of order and left the reservation unchanged
+	Payload       string    `json:"payload" gorm:"type:text"`
+	CreatedAt     time.Time `json:"created_at"`
 }
 
 // DayAvailability represents availability for a specific day
@@ -49,6 +370,21 @@ type DayAvailability struct {
 	Courts []CourtAvailability `json:"courts"`
 }
 
+// DaySummary counts the slots of a day that can still be booked
+type DaySummary struct {
+	Date       string `json:"date"`
+	FreeSlots  int    `json:"free_slots"`
+	TotalSlots int    `json:"total_slots"`
+}
+
+// AvailabilityRange represents availability for every day of a date range
+type AvailabilityRange struct {
+	From    string            `json:"from"`
+	To      string            `json:"to"`
+	Summary []DaySummary      `json:"summary"`
+	Days    []DayAvailability `json:"days"`
+}
+
 // CourtAvailability represents availability for a specific court on a day
 type CourtAvailability struct {
 	Court     Court                `json:"court"`
@@ -59,6 +395,11 @@ type CourtAvailability struct {
 type TimeslotWithStatus struct {
 	Timeslot Timeslot `json:"timeslot"`
 	IsBooked bool     `json:"is_booked"`
+	IsHeld   bool     `json:"is_held"` // Held by an unpaid reservation whose hold has not expired yet
+	Price    float64  `json:"price"`   // Price quoted for booking the slot now
+
+	IsBlackedOut   bool   `json:"is_blacked_out"`            // The court is closed during the slot
+	BlackoutReason string `json:"blackout_reason,omitempty"` // Why the court is closed
 }
 
 // XenditWebhookPayload represents the payload from Xendit webhook
@@ -106,9 +447,17 @@ type XenditInvoiceRequest struct {
 	FailureRedirectURL string                 `json:"failure_redirect_url"`
 	Currency           string                 `json:"currency"`
 	Items              []XenditInvoiceItem    `json:"items"`
+	Fees               []XenditInvoiceFee     `json:"fees,omitempty"`
 	Metadata           map[string]interface{} `json:"metadata,omitempty"`
 }
 
+// XenditInvoiceFee represents a fee or, with a negative value, a discount on
+// an invoice
+type XenditInvoiceFee struct {
+	Type  string  `json:"type"`
+	Value float64 `json:"value"`
+}
+
 // XenditCustomer represents customer info for Xendit invoice
 type XenditCustomer struct {
 	GivenNames   string `json:"given_names"`
@@ -152,6 +501,7 @@ type XenditInvoiceResponse struct {
 	Updated                   string                 `json:"updated"`
 	Currency                  string                 `json:"currency"`
 	Items                     []XenditInvoiceItem    `json:"items"`
+	Fees                      []XenditInvoiceFee     `json:"fees"`
 	Customer                  XenditCustomer         `json:"customer"`
 	Metadata                  map[string]interface{} `json:"metadata"`
 }
@@ -185,3 +535,35 @@ type XenditQRCode struct {
 type XenditDirectDebit struct {
 	DirectDebitType string `json:"direct_debit_type"`
 }
+
+// XenditRefundRequest represents the request payload to refund a Xendit invoice
+type XenditRefundRequest struct {
+	ReferenceID string                 `json:"reference_id"`
+	InvoiceID   string                 `json:"invoice_id"`
+	Amount      float64                `json:"amount"`
+	Reason      string                 `json:"reason"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// XenditRefundResponse represents the response from Xendit refund creation
+type XenditRefundResponse struct {
+	ID          string                 `json:"id"`
+	InvoiceID   string                 `json:"invoice_id"`
+	ReferenceID string                 `json:"reference_id"`
+	Amount      float64                `json:"amount"`
+	Currency    string                 `json:"currency"`
+	Status      string                 `json:"status"` // PENDING, SUCCEEDED, FAILED
+	Reason      string                 `json:"reason"`
+	FailureCode string                 `json:"failure_code"`
+	Created     string                 `json:"created"`
+	Updated     string                 `json:"updated"`
+	Metadata    map[string]interface{} `json:"metadata"`
+}
+
+// XenditRefundWebhookPayload represents a refund callback from Xendit
+type XenditRefundWebhookPayload struct {
+	Event      string               `json:"event"` // refund.succeeded or refund.failed
+	BusinessID string               `json:"business_id"`
+	Created    string               `json:"created"`
+	Data       XenditRefundResponse `json:"data"`
+}
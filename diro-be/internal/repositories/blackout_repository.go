@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"diro-be/internal/models"
+)
+
+// BlackoutRepository handles database operations for court blackouts
+type BlackoutRepository struct {
+	db *gorm.DB
+}
+
+// NewBlackoutRepository creates a new blackout repository
+func NewBlackoutRepository(db *gorm.DB) *BlackoutRepository {
+	return &BlackoutRepository{db: db}
+}
+
+// ListBlackouts returns the blackouts overlapping the period from from until
+// to, ordered by start. A nil bound leaves that side open.
+func (r *BlackoutRepository) ListBlackouts(ctx context.Context, from, to *time.Time) ([]models.Blackout, error) {
+	query := r.db.WithContext(ctx).Order("starts_at, id")
+	if from != nil {
+		query = query.Where("ends_at > ?", *from)
+	}
+	if to != nil {
+		query = query.Where("starts_at < ?", *to)
+	}
+
+	var blackouts []models.Blackout
+	err := query.Find(&blackouts).Error
+	return blackouts, err
+}
+
+// GetBlackoutByID gets a blackout by ID
+func (r *BlackoutRepository) GetBlackoutByID(ctx context.Context, id uint) (*models.Blackout, error) {
+	var blackout models.Blackout
+	err := r.db.WithContext(ctx).First(&blackout, id).Error
+	return &blackout, err
+}
+
+// CreateBlackout creates a new blackout
+func (r *BlackoutRepository) CreateBlackout(ctx context.Context, blackout *models.Blackout) error {
+	return r.db.WithContext(ctx).Create(blackout).Error
+}
+
+// UpdateBlackout updates a blackout
+func (r *BlackoutRepository) UpdateBlackout(ctx context.Context, blackout *models.Blackout) error {
+	return r.db.WithContext(ctx).Save(blackout).Error
+}
+
+// DeleteBlackout deletes a blackout
+func (r *BlackoutRepository) DeleteBlackout(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.Blackout{}, id).Error
+}
@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"context"
+	"gorm.io/gorm"
+
+	"diro-be/internal/models"
+)
+
+// CourtRepository handles database operations for courts
+type CourtRepository struct {
+	db *gorm.DB
+}
+
+// NewCourtRepository creates a new court repository
+func NewCourtRepository(db *gorm.DB) *CourtRepository {
+	return &CourtRepository{db: db}
+}
+
+// ListCourts returns all courts that are not deleted, ordered by name
+func (r *CourtRepository) ListCourts(ctx context.Context) ([]models.Court, error) {
+	var courts []models.Court
+	err := r.db.WithContext(ctx).Order("name").Find(&courts).Error
+	return courts, err
+}
+
+// GetCourtByID gets a court by ID
+func (r *CourtRepository) GetCourtByID(ctx context.Context, id uint) (*models.Court, error) {
+	var court models.Court
+	err := r.db.WithContext(ctx).First(&court, id).Error
+	return &court, err
+}
+
+// CreateCourt creates a new court
+func (r *CourtRepository) CreateCourt(ctx context.Context, court *models.Court) error {
+	return r.db.WithContext(ctx).Create(court).Error
+}
+
+// UpdateCourt updates a court
+func (r *CourtRepository) UpdateCourt(ctx context.Context, court *models.Court) error {
+	return r.db.WithContext(ctx).Save(court).Error
+}
+
+// DeleteCourt soft deletes a court
+func (r *CourtRepository) DeleteCourt(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.Court{}, id).Error
+}
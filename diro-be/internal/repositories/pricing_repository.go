@@ -0,0 +1,100 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"diro-be/internal/models"
+)
+
+// ErrHolidayExists is returned when a holiday is already defined for a date
+var ErrHolidayExists = errors.New("holiday already exists for this date")
+
+// PricingRepository handles database operations for price rules and holidays
+type PricingRepository struct {
+	db *gorm.DB
+}
+
+// NewPricingRepository creates a new pricing repository
+func NewPricingRepository(db *gorm.DB) *PricingRepository {
+	return &PricingRepository{db: db}
+}
+
+// ListPriceRules returns price rules, highest priority first and newest first
+// among equal priorities. Inactive rules are only included when
+// includeInactive is set.
+func (r *PricingRepository) ListPriceRules(ctx context.Context, includeInactive bool) ([]models.PriceRule, error) {
+	query := r.db.WithContext(ctx).Order("priority DESC, id DESC")
+	if !includeInactive {
+		query = query.Where("is_active = ?", true)
+	}
+
+	var rules []models.PriceRule
+	err := query.Find(&rules).Error
+	return rules, err
+}
+
+// GetPriceRuleByID gets a price rule by ID
+func (r *PricingRepository) GetPriceRuleByID(ctx context.Context, id uint) (*models.PriceRule, error) {
+	var rule models.PriceRule
+	err := r.db.WithContext(ctx).First(&rule, id).Error
+	return &rule, err
+}
+
+// CreatePriceRule creates a new price rule
+func (r *PricingRepository) CreatePriceRule(ctx context.Context, rule *models.PriceRule) error {
+	return r.db.WithContext(ctx).Create(rule).Error
+}
+
+// UpdatePriceRule updates a price rule
+func (r *PricingRepository) UpdatePriceRule(ctx context.Context, rule *models.PriceRule) error {
+	return r.db.WithContext(ctx).Save(rule).Error
+}
+
+// DeletePriceRule deletes a price rule
+func (r *PricingRepository) DeletePriceRule(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.PriceRule{}, id).Error
+}
+
+// ListHolidays returns the holidays between from and to inclusive, ordered
+// by date. A nil bound leaves that side open.
+func (r *PricingRepository) ListHolidays(ctx context.Context, from, to *time.Time) ([]models.Holiday, error) {
+	query := r.db.WithContext(ctx).Order("date")
+	if from != nil {
+		query = query.Where("date >= ?", from.Format("2006-01-02"))
+	}
+	if to != nil {
+		query = query.Where("date <= ?", to.Format("2006-01-02"))
+	}
+
+	var holidays []models.Holiday
+	err := query.Find(&holidays).Error
+	return holidays, err
+}
+
+// GetHolidayByID gets a holiday by ID
+func (r *PricingRepository) GetHolidayByID(ctx context.Context, id uint) (*models.Holiday, error) {
+	var holiday models.Holiday
+	err := r.db.WithContext(ctx).First(&holiday, id).Error
+	return &holiday, err
+}
+
+// CreateHoliday creates a new holiday, returning ErrHolidayExists if the date
+// already has one
+func (r *PricingRepository) CreateHoliday(ctx context.Context, holiday *models.Holiday) error {
+	if err := r.db.WithContext(ctx).Create(holiday).Error; err != nil {
+		if isDuplicateEntry(err) {
+			return ErrHolidayExists
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteHoliday deletes a holiday
+func (r *PricingRepository) DeleteHoliday(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.Holiday{}, id).Error
+}
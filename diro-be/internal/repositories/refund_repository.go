@@ -0,0 +1,131 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"diro-be/internal/models"
+)
+
+// ErrRefundExceedsPayment is returned when a refund would bring the amount
+// refunded on a reservation above what was paid for it
+var ErrRefundExceedsPayment = errors.New("refund exceeds the amount paid")
+
+// ErrDuplicateRefund is returned when a refund with the same reference ID exists
+var ErrDuplicateRefund = errors.New("refund with this reference already exists")
+
+// RefundRepository handles database operations for refunds
+type RefundRepository struct {
+	db *gorm.DB
+}
+
+// NewRefundRepository creates a new refund repository
+func NewRefundRepository(db *gorm.DB) *RefundRepository {
+	return &RefundRepository{db: db}
+}
+
+// IssueRefund records a new refund of a reservation together with the
+// reservation's transition, if history is not nil, and hands the refund to
+// send, which sends it to the payment gateway and fills in its answer. The
+// reservation row stays locked until the refund is saved, so it is sent at
+// most once and never after the reservation changed status. If send fails,
+// nothing is saved. It returns ErrDuplicateRefund if the reference ID was
+// used before and ErrRefundExceedsPayment if the refunds that did not fail
+// would exceed what was paid.
+func (r *RefundRepository) IssueRefund(ctx context.Context, refund *models.Refund, reservation *models.Reservation, history *models.ReservationStatusHistory, send func(*models.Refund) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var locked models.Reservation
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, refund.ReservationID).Error; err != nil {
+			return err
+		}
+
+		if history != nil {
+			if err := saveTransition(tx, reservation, history); err != nil {
+				return err
+			}
+		}
+
+		var existing int64
+		if err := tx.Model(&models.Refund{}).Where("reference_id = ?", refund.ReferenceID).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return ErrDuplicateRefund
+		}
+
+		var refunded float64
+		if err := tx.Model(&models.Refund{}).
+			Select("COALESCE(SUM(amount), 0)").
+			Where("reservation_id = ? AND status <> ?", refund.ReservationID, models.RefundStatusFailed).
+			Scan(&refunded).Error; err != nil {
+			return err
+		}
+		if refunded+refund.Amount > locked.TotalPrice {
+			return ErrRefundExceedsPayment
+		}
+
+		if err := tx.Create(refund).Error; err != nil {
+			if isDuplicateEntry(err) {
+				return ErrDuplicateRefund
+			}
+			return err
+		}
+		if err := send(refund); err != nil {
+			return err
+		}
+		return tx.Save(refund).Error
+	})
+}
+
+// GetRefundByID gets a refund by ID
+func (r *RefundRepository) GetRefundByID(ctx context.Context, id uint) (*models.Refund, error) {
+	var refund models.Refund
+	err := r.db.WithContext(ctx).First(&refund, id).Error
+	return &refund, err
+}
+
+// GetRefundByReferenceID gets a refund by the reference ID sent to the gateway
+func (r *RefundRepository) GetRefundByReferenceID(ctx context.Context, referenceID string) (*models.Refund, error) {
+	var refund models.Refund
+	err := r.db.WithContext(ctx).Where("reference_id = ?", referenceID).First(&refund).Error
+	return &refund, err
+}
+
+// ListReservationRefunds returns the refunds of a reservation, oldest first
+func (r *RefundRepository) ListReservationRefunds(ctx context.Context, reservationID uint) ([]models.Refund, error) {
+	var refunds []models.Refund
+	err := r.db.WithContext(ctx).Where("reservation_id = ?", reservationID).
+		Order("id").
+		Find(&refunds).Error
+	return refunds, err
+}
+
+// SaveRefund persists a refund status change in one transaction: the webhook
+// event that caused it (if any), the refund, and the reservation with its
+// history entry. With a nil history the reservation is saved as is, and with
+// a nil reservation only the refund is. The reservation is only updated if
+// its status is still history.FromStatus, otherwise ErrStaleReservation is
+// returned. It returns ErrDuplicateEvent if the event was recorded before.
+func (r *RefundRepository) SaveRefund(ctx context.Context, refund *models.Refund, reservation *models.Reservation, history *models.ReservationStatusHistory, event *models.WebhookEvent) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if event != nil {
+			if err := tx.Create(event).Error; err != nil {
+				if isDuplicateEntry(err) {
+					return ErrDuplicateEvent
+				}
+				return err
+			}
+		}
+
+		if err := tx.Save(refund).Error; err != nil {
+			return err
+		}
+		if reservation == nil {
+			return nil
+		}
+		return saveTransition(tx, reservation, history)
+	})
+}
@@ -1,14 +1,51 @@
 package repositories
 
 import (
+	"context"
+	"errors"
+	"sort"
 	"time"
 
+	"github.com/go-sql-driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"diro-be/internal/models"
 )
 
-// ReservationRepository handles database operations for reservations
+// ErrSlotTaken is returned when a court slot already has an active reservation
+var ErrSlotTaken = errors.New("slot is already reserved")
+
+// ErrDuplicateEvent is returned when a webhook event was already processed
+var ErrDuplicateEvent = errors.New("webhook event already processed")
+
+// ErrStaleReservation is returned when a reservation changed status since it was loaded
+var ErrStaleReservation = errors.New("reservation was modified concurrently")
+
+// ErrVoucherUsedUp is returned when a voucher reached its usage cap
+var ErrVoucherUsedUp = errors.New("promo code usage limit reached")
+
+// mysqlErrDuplicateEntry is the MySQL error number for unique key violations
+const mysqlErrDuplicateEntry = 1062
+
+// Reservation list scopes
+const (
+	ScopeUpcoming = "upcoming"
+	ScopePast     = "past"
+)
+
+// ReservationFilter narrows down a reservation listing
+type ReservationFilter struct {
+	Scope    string // ScopeUpcoming, ScopePast or empty for all
+	Status   models.ReservationStatus
+	From     *time.Time // Slot date on or after
+	To       *time.Time // Slot date on or before
+	Page     int
+	PageSize int
+}
+
+// ReservationRepository handles database operations for reservations. Queries
+// run with the context they are given, so they log its request ID.
 type ReservationRepository struct {
 	db *gorm.DB
 }
@@ -19,87 +56,478 @@ func NewReservationRepository(db *gorm.DB) *ReservationRepository {
 }
 
 // CreateReservation creates a new reservation in the database
-func (r *ReservationRepository) CreateReservation(reservation *models.Reservation) error {
-	return r.db.Create(reservation).Error
+func (r *ReservationRepository) CreateReservation(ctx context.Context, reservation *models.Reservation) error {
+	return r.db.WithContext(ctx).Create(reservation).Error
 }
 
 // GetReservationByID gets a reservation by ID with relations
-func (r *ReservationRepository) GetReservationByID(id uint) (*models.Reservation, error) {
+func (r *ReservationRepository) GetReservationByID(ctx context.Context, id uint) (*models.Reservation, error) {
 	var reservation models.Reservation
-	err := r.db.Preload("Court").Preload("Timeslot").First(&reservation, id).Error
+	err := r.db.WithContext(ctx).Preload("Court", withDeletedCourts).Preload("Timeslot").First(&reservation, id).Error
 	return &reservation, err
 }
 
+// ListUserReservations returns one page of a user's reservations matching the
+// filter, with relations, and the total number of matches. Upcoming
+// reservations are listed soonest first, everything else most recent first.
+func (r *ReservationRepository) ListUserReservations(ctx context.Context, userID uint, filter ReservationFilter, now time.Time) ([]models.Reservation, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.Reservation{}).
+		Joins("JOIN timeslots ON timeslots.id = reservations.timeslot_id").
+		Where("reservations.user_id = ?", userID)
+
+	today, clock := now.Format("2006-01-02"), now.Format("15:04")
+	switch filter.Scope {
+	case ScopeUpcoming:
+		query = query.Where("(reservations.date > ? OR (reservations.date = ? AND timeslots.end_time > ?))", today, today, clock)
+	case ScopePast:
+		query = query.Where("(reservations.date < ? OR (reservations.date = ? AND timeslots.end_time <= ?))", today, today, clock)
+	}
+	if filter.Status != "" {
+		query = query.Where("reservations.status = ?", filter.Status)
+	}
+	if filter.From != nil {
+		query = query.Where("reservations.date >= ?", filter.From.Format("2006-01-02"))
+	}
+	if filter.To != nil {
+		query = query.Where("reservations.date <= ?", filter.To.Format("2006-01-02"))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	order := "reservations.date DESC, timeslots.start_time DESC"
+	if filter.Scope == ScopeUpcoming {
+		order = "reservations.date ASC, timeslots.start_time ASC"
+	}
+
+	var reservations []models.Reservation
+	err := query.Preload("Court", withDeletedCourts).Preload("Timeslot").
+		Order(order).
+		Offset((filter.Page - 1) * filter.PageSize).
+		Limit(filter.PageSize).
+		Find(&reservations).Error
+	return reservations, total, err
+}
+
+// FindFuturePaidReservations returns booked reservations on a court from today on
+func (r *ReservationRepository) FindFuturePaidReservations(ctx context.Context, courtID uint, now time.Time) ([]models.Reservation, error) {
+	var reservations []models.Reservation
+	err := r.db.WithContext(ctx).Preload("Timeslot").
+		Where("court_id = ? AND status IN ? AND date >= ?", courtID, models.BookedStatuses, now.Format("2006-01-02")).
+		Order("date, timeslot_id").
+		Find(&reservations).Error
+	return reservations, err
+}
+
+// FindReservationsCreatedBetween returns the reservations created from from
+// until to
+func (r *ReservationRepository) FindReservationsCreatedBetween(ctx context.Context, from, to time.Time) ([]models.Reservation, error) {
+	var reservations []models.Reservation
+	err := r.db.WithContext(ctx).Where("created_at >= ? AND created_at < ?", from, to).
+		Order("id").
+		Find(&reservations).Error
+	return reservations, err
+}
+
+// FindReservationsByPaymentIDs returns the reservations billed on any of the
+// invoices
+func (r *ReservationRepository) FindReservationsByPaymentIDs(ctx context.Context, paymentIDs []string) ([]models.Reservation, error) {
+	var reservations []models.Reservation
+	if len(paymentIDs) == 0 {
+		return reservations, nil
+	}
+	err := r.db.WithContext(ctx).Where("payment_id IN ?", paymentIDs).
+		Order("id").
+		Find(&reservations).Error
+	return reservations, err
+}
+
+// FindPaidReservationsBetween returns booked reservations from the date of from
+// to the date of to, on one court or on every court when courtID is nil, with
+// their court, timeslot and customer
+func (r *ReservationRepository) FindPaidReservationsBetween(ctx context.Context, courtID *uint, from, to time.Time) ([]models.Reservation, error) {
+	query := r.db.WithContext(ctx).Preload("Court", withDeletedCourts).Preload("Timeslot").Preload("User").
+		Where("status IN ? AND date BETWEEN ? AND ?", models.BookedStatuses, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if courtID != nil {
+		query = query.Where("court_id = ?", *courtID)
+	}
+
+	var reservations []models.Reservation
+	err := query.Order("date, court_id, timeslot_id").Find(&reservations).Error
+	return reservations, err
+}
+
+// FindFutureActiveReservationsForTimeslot returns paid and held reservations
+// of a timeslot from today on
+func (r *ReservationRepository) FindFutureActiveReservationsForTimeslot(ctx context.Context, timeslotID uint, now time.Time) ([]models.Reservation, error) {
+	var reservations []models.Reservation
+	err := r.db.WithContext(ctx).Preload("Court", withDeletedCourts).
+		Where("timeslot_id = ? AND date >= ?", timeslotID, now.Format("2006-01-02")).
+		Scopes(activeReservations(now)).
+		Order("date, court_id").
+		Find(&reservations).Error
+	return reservations, err
+}
+
 // UpdateReservation updates a reservation
-func (r *ReservationRepository) UpdateReservation(reservation *models.Reservation) error {
-	return r.db.Save(reservation).Error
+func (r *ReservationRepository) UpdateReservation(ctx context.Context, reservation *models.Reservation) error {
+	return r.db.WithContext(ctx).Save(reservation).Error
+}
+
+// UpdateReservations updates reservations booked together in one transaction
+func (r *ReservationRepository) UpdateReservations(ctx context.Context, reservations []models.Reservation) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range reservations {
+			if err := tx.Omit(clause.Associations).Save(&reservations[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// FindReservationsByPaymentID returns the reservations billed by an invoice
+// with relations, in booking order
+func (r *ReservationRepository) FindReservationsByPaymentID(ctx context.Context, paymentID string) ([]models.Reservation, error) {
+	var reservations []models.Reservation
+	err := r.db.WithContext(ctx).Preload("Court", withDeletedCourts).Preload("Timeslot").
+		Where("payment_id = ?", paymentID).
+		Order("id").
+		Find(&reservations).Error
+	return reservations, err
+}
+
+// SaveTransition persists a status transition in one transaction: the webhook
+// event that caused it (if any), the reservation and its history entry. The
+// reservation is only updated if its status is still history.FromStatus,
+// otherwise ErrStaleReservation is returned. With a nil history the
+// reservation is saved as is, and with a nil reservation only the event is
+// recorded. It returns ErrDuplicateEvent if the event was recorded before and
+// ErrSlotTaken if the new status would double book the slot.
+func (r *ReservationRepository) SaveTransition(ctx context.Context, reservation *models.Reservation, history *models.ReservationStatusHistory, event *models.WebhookEvent) error {
+	if reservation == nil {
+		return r.SaveTransitions(ctx, nil, nil, event)
+	}
+	return r.SaveTransitions(ctx, []*models.Reservation{reservation}, []*models.ReservationStatusHistory{history}, event)
+}
+
+// SaveTransitions persists the transitions of reservations booked together
+// like SaveTransition, all or none of them. histories[i] belongs to
+// reservations[i].
+func (r *ReservationRepository) SaveTransitions(ctx context.Context, reservations []*models.Reservation, histories []*models.ReservationStatusHistory, event *models.WebhookEvent) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if event != nil {
+			if err := tx.Create(event).Error; err != nil {
+				if isDuplicateEntry(err) {
+					return ErrDuplicateEvent
+				}
+				return err
+			}
+		}
+
+		for i, reservation := range reservations {
+			if err := saveTransition(tx, reservation, histories[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// saveTransition updates a reservation whose status is still
+// history.FromStatus and records the history entry
+func saveTransition(tx *gorm.DB, reservation *models.Reservation, history *models.ReservationStatusHistory) error {
+	if history == nil {
+		return tx.Omit(clause.Associations).Save(reservation).Error
+	}
+
+	result := tx.Model(reservation).
+		Omit(clause.Associations).
+		Select("*").
+		Where("status = ?", history.FromStatus).
+		Updates(reservation)
+	if result.Error != nil {
+		if isDuplicateEntry(result.Error) {
+			return ErrSlotTaken
+		}
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrStaleReservation
+	}
+
+	return tx.Create(history).Error
+}
+
+// CreateSeries creates a new reservation series
+func (r *ReservationRepository) CreateSeries(ctx context.Context, series *models.ReservationSeries) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(series).Error
+}
+
+// DeleteSeries deletes a reservation series, unlinking any reservations that
+// still refer to it, such as those whose invoice could not be created
+func (r *ReservationRepository) DeleteSeries(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Reservation{}).Where("series_id = ?", id).Update("series_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.ReservationSeries{}, id).Error
+	})
+}
+
+// GetSeriesByID gets a reservation series with its court, timeslot and
+// reservations, earliest first
+func (r *ReservationRepository) GetSeriesByID(ctx context.Context, id uint) (*models.ReservationSeries, error) {
+	var series models.ReservationSeries
+	err := r.db.WithContext(ctx).Preload("Court", withDeletedCourts).Preload("Timeslot").
+		Preload("Reservations", func(db *gorm.DB) *gorm.DB {
+			return db.Order("date, id")
+		}).
+		Preload("Reservations.Court", withDeletedCourts).
+		Preload("Reservations.Timeslot").
+		First(&series, id).Error
+	return &series, err
+}
+
+// GetStatusHistory returns the status transitions of a reservation, oldest first
+func (r *ReservationRepository) GetStatusHistory(ctx context.Context, reservationID uint) ([]models.ReservationStatusHistory, error) {
+	var history []models.ReservationStatusHistory
+	err := r.db.WithContext(ctx).Where("reservation_id = ?", reservationID).Order("created_at, id").Find(&history).Error
+	return history, err
 }
 
 // DeleteReservation deletes a reservation
-func (r *ReservationRepository) DeleteReservation(id uint) error {
-	return r.db.Delete(&models.Reservation{}, id).Error
+func (r *ReservationRepository) DeleteReservation(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.Reservation{}, id).Error
 }
 
 // CheckSlotAvailability checks if a slot is available
-func (r *ReservationRepository) CheckSlotAvailability(courtID, timeslotID uint, date time.Time) (bool, error) {
+func (r *ReservationRepository) CheckSlotAvailability(ctx context.Context, courtID, timeslotID uint, date time.Time) (bool, error) {
 	var count int64
-	err := r.db.Model(&models.Reservation{}).
-		Where("court_id = ? AND timeslot_id = ? AND date = ? AND status = ?",
-			courtID, timeslotID, date.Format("2006-01-02"), "paid").
+	err := r.db.WithContext(ctx).Model(&models.Reservation{}).
+		Where("court_id = ? AND timeslot_id = ? AND date = ?", courtID, timeslotID, date.Format("2006-01-02")).
+		Scopes(activeReservations(time.Now())).
 		Count(&count).Error
 	return count == 0, err
 }
 
-// GetDayAvailability returns availability for a specific day
-func (r *ReservationRepository) GetDayAvailability(date time.Time) (*models.DayAvailability, error) {
+// ReserveSlots inserts reservations booked together, each with a copy of the
+// history entry of its creation, in one transaction: either every slot is
+// reserved or none is. It relies on the unique index on slot_key so the
+// database rejects a second active reservation for the same court slot, and
+// returns ErrSlotTaken if any slot is already occupied and ErrVoucherUsedUp if
+// a reservation's voucher reached its usage cap. The reservations count as a
+// single use of each voucher they apply.
+func (r *ReservationRepository) ReserveSlots(ctx context.Context, reservations []*models.Reservation, history models.ReservationStatusHistory) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		redeemed := make(map[uint]bool)
+		for _, reservation := range reservations {
+			if reservation.VoucherID == nil || redeemed[*reservation.VoucherID] {
+				continue
+			}
+			if err := redeemVoucher(tx, *reservation.VoucherID, reservation.UserID); err != nil {
+				return err
+			}
+			redeemed[*reservation.VoucherID] = true
+		}
+
+		for _, reservation := range reservations {
+			if err := tx.Create(reservation).Error; err != nil {
+				if isDuplicateEntry(err) {
+					return ErrSlotTaken
+				}
+				return err
+			}
+
+			entry := history
+			entry.ReservationID = reservation.ID
+			if err := tx.Create(&entry).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// redeemVoucher locks the voucher row until the transaction ends and checks
+// its usage caps against the pending and paid bookings that used it. The
+// reservations billed on one invoice are one use; a reservation without an
+// invoice yet counts on its own.
+func redeemVoucher(tx *gorm.DB, voucherID uint, userID *uint) error {
+	var voucher models.Voucher
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&voucher, voucherID).Error; err != nil {
+		return err
+	}
+
+	uses := func(userID *uint) (int64, error) {
+		query := tx.Model(&models.Reservation{}).
+			Select("COUNT(DISTINCT COALESCE(NULLIF(payment_id, ''), CONCAT('reservation:', id)))").
+			Where("voucher_id = ? AND status IN ?", voucherID,
+				append([]models.ReservationStatus{models.ReservationStatusPending}, models.BookedStatuses...))
+		if userID != nil {
+			query = query.Where("user_id = ?", *userID)
+		}
+		var count int64
+		err := query.Count(&count).Error
+		return count, err
+	}
+
+	if voucher.MaxUses > 0 {
+		count, err := uses(nil)
+		if err != nil {
+			return err
+		}
+		if count >= int64(voucher.MaxUses) {
+			return ErrVoucherUsedUp
+		}
+	}
+	if voucher.MaxUsesPerCustomer > 0 && userID != nil {
+		count, err := uses(userID)
+		if err != nil {
+			return err
+		}
+		if count >= int64(voucher.MaxUsesPerCustomer) {
+			return ErrVoucherUsedUp
+		}
+	}
+	return nil
+}
+
+// FindLapsedHolds returns pending reservations whose hold expired before now
+// or was released, except those already paid at the gateway whose slot was
+// taken meanwhile
+func (r *ReservationRepository) FindLapsedHolds(ctx context.Context, now time.Time) ([]models.Reservation, error) {
+	var reservations []models.Reservation
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND (hold_expires_at IS NULL OR hold_expires_at <= ?)", models.ReservationStatusPending, now).
+		Where("COALESCE(payment_status, '') NOT IN ?", []string{models.PaymentStatusPaid, models.PaymentStatusSettled}).
+		Find(&reservations).Error
+	return reservations, err
+}
+
+// ReleaseLapsedHolds frees a court slot held by a pending reservation whose
+// hold expired before now, so it can be booked again. The reservation stays
+// pending until the sweep settles its invoice. It returns how many holds
+// were released.
+func (r *ReservationRepository) ReleaseLapsedHolds(ctx context.Context, now time.Time, slotKey string) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&models.Reservation{}).
+		Where("slot_key = ? AND status = ? AND hold_expires_at <= ?", slotKey, models.ReservationStatusPending, now).
+		Updates(map[string]interface{}{"hold_expires_at": nil, "slot_key": nil})
+	return result.RowsAffected, result.Error
+}
+
+// withDeletedCourts preloads courts even after they were soft deleted, so
+// past reservations keep showing where they were played
+func withDeletedCourts(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
+// isDuplicateEntry reports whether err is a MySQL unique key violation
+func isDuplicateEntry(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrDuplicateEntry
+}
+
+// activeReservations limits a query to reservations that occupy their slot:
+// booked ones and pending ones whose hold has not expired yet
+func activeReservations(now time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("(status IN ? OR (status = ? AND hold_expires_at > ?))",
+			models.BookedStatuses, models.ReservationStatusPending, now)
+	}
+}
+
+// GetAvailability returns the availability of the active courts, or of one
+// court, for each day from from to to inclusive. It runs the same queries
+// however many courts and days the range covers.
+func (r *ReservationRepository) GetAvailability(ctx context.Context, from, to time.Time, courtID *uint) ([]models.DayAvailability, error) {
+	fromDay, toDay := from.Format("2006-01-02"), to.Format("2006-01-02")
+
+	courtQuery := r.db.WithContext(ctx).Where("is_active = ?", true).Order("id")
+	if courtID != nil {
+		courtQuery = courtQuery.Where("id = ?", *courtID)
+	}
 	var courts []models.Court
-	if err := r.db.Where("is_active = ?", true).Find(&courts).Error; err != nil {
+	if err := courtQuery.Find(&courts).Error; err != nil {
 		return nil, err
 	}
 
-	var courtAvailabilities []models.CourtAvailability
-
+	courtIDs := make([]uint, 0, len(courts))
 	for _, court := range courts {
-		// Get all active timeslots
-		var allTimeslots []models.Timeslot
-		if err := r.db.Where("is_active = ?", true).Find(&allTimeslots).Error; err != nil {
+		courtIDs = append(courtIDs, court.ID)
+	}
+
+	// Get the schedules of active timeslots in effect during the range
+	var schedules []models.CourtSchedule
+	if len(courtIDs) > 0 {
+		if err := r.db.WithContext(ctx).Select("court_schedules.*").Preload("Timeslot").
+			Joins("JOIN timeslots ON timeslots.id = court_schedules.timeslot_id").
+			Where("court_schedules.court_id IN ? AND timeslots.is_active = ?", courtIDs, true).
+			Where("court_schedules.effective_from <= ? AND (court_schedules.effective_to IS NULL OR court_schedules.effective_to >= ?)", toDay, fromDay).
+			Find(&schedules).Error; err != nil {
 			return nil, err
 		}
+	}
 
-		// Get reserved timeslot IDs for this court and date
-		var reservedTimeslotIDs []uint
-		r.db.Model(&models.Reservation{}).
-			Where("court_id = ? AND date = ? AND status = ?",
-				court.ID, date.Format("2006-01-02"), "paid").
-			Pluck("timeslot_id", &reservedTimeslotIDs)
-
-		// Create timeslots with status
-		var timeslotsWithStatus []models.TimeslotWithStatus
-		for _, ts := range allTimeslots {
-			isBooked := false
-			for _, reservedID := range reservedTimeslotIDs {
-				if ts.ID == reservedID {
-					isBooked = true
-					break
+	// Get the reserved and held slots during the range
+	var reservations []models.Reservation
+	if len(courtIDs) > 0 {
+		if err := r.db.WithContext(ctx).Select("court_id", "timeslot_id", "date", "status").
+			Where("court_id IN ? AND date BETWEEN ? AND ?", courtIDs, fromDay, toDay).
+			Scopes(activeReservations(time.Now())).
+			Find(&reservations).Error; err != nil {
+			return nil, err
+		}
+	}
+	statuses := make(map[string]models.ReservationStatus, len(reservations))
+	for _, reservation := range reservations {
+		slotKey := models.SlotKey(reservation.CourtID, reservation.TimeslotID, reservation.Date)
+		if !statuses[slotKey].IsBooked() {
+			statuses[slotKey] = reservation.Status
+		}
+	}
+
+	var days []models.DayAvailability
+	for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+		day := date.Format("2006-01-02")
+		courtAvailabilities := make([]models.CourtAvailability, 0, len(courts))
+		for _, court := range courts {
+			// Collect the timeslots scheduled for this court on this day
+			timeslotsWithStatus := make([]models.TimeslotWithStatus, 0)
+			seen := make(map[uint]bool)
+			for _, schedule := range schedules {
+				if schedule.CourtID != court.ID || schedule.Weekday != int(date.Weekday()) || seen[schedule.TimeslotID] {
+					continue
 				}
+				if schedule.EffectiveFrom.Format("2006-01-02") > day || (schedule.EffectiveTo != nil && schedule.EffectiveTo.Format("2006-01-02") < day) {
+					continue
+				}
+				seen[schedule.TimeslotID] = true
+
+				status := statuses[models.SlotKey(court.ID, schedule.TimeslotID, date)]
+				timeslotsWithStatus = append(timeslotsWithStatus, models.TimeslotWithStatus{
+					Timeslot: schedule.Timeslot,
+					IsBooked: status.IsBooked(),
+					IsHeld:   status == models.ReservationStatusPending,
+				})
 			}
-			timeslotsWithStatus = append(timeslotsWithStatus, models.TimeslotWithStatus{
-				Timeslot: ts,
-				IsBooked: isBooked,
+			sort.Slice(timeslotsWithStatus, func(i, j int) bool {
+				return timeslotsWithStatus[i].Timeslot.StartTime < timeslotsWithStatus[j].Timeslot.StartTime
 			})
-		}
 
-		courtAvailability := models.CourtAvailability{
-			Court:     court,
-			Timeslots: timeslotsWithStatus,
+			courtAvailabilities = append(courtAvailabilities, models.CourtAvailability{
+				Court:     court,
+				Timeslots: timeslotsWithStatus,
+			})
 		}
-		courtAvailabilities = append(courtAvailabilities, courtAvailability)
-	}
 
-	dayAvailability := &models.DayAvailability{
-		Date:   date.Format("2006-01-02"),
-		Courts: courtAvailabilities,
+		days = append(days, models.DayAvailability{
+			Date:   day,
+			Courts: courtAvailabilities,
+		})
 	}
 
-	return dayAvailability, nil
+	return days, nil
 }
@@ -0,0 +1,206 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"diro-be/internal/models"
+	"diro-be/internal/testdb"
+)
+
+// heldReservation returns a pending reservation holding a court slot
+func heldReservation(courtID, timeslotID uint, date time.Time) *models.Reservation {
+	holdExpiresAt := time.Now().Add(time.Hour)
+	return &models.Reservation{
+		CourtID:       courtID,
+		TimeslotID:    timeslotID,
+		Date:          date,
+		Status:        models.ReservationStatusPending,
+		HoldExpiresAt: &holdExpiresAt,
+	}
+}
+
+func bookingHistory() models.ReservationStatusHistory {
+	return models.ReservationStatusHistory{
+		ToStatus: models.ReservationStatusPending,
+		Trigger:  "booking",
+		Actor:    "test",
+	}
+}
+
+func TestReserveSlotsConcurrently(t *testing.T) {
+	db := testdb.Open(t)
+	repo := NewReservationRepository(db)
+	court, timeslot := testdb.CreateSlot(t, db)
+	date := testdb.Date()
+
+	const attempts = 10
+	errs := make(chan error, attempts)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for range attempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			reservation := heldReservation(court.ID, timeslot.ID, date)
+			errs <- repo.ReserveSlots(context.Background(), []*models.Reservation{reservation}, bookingHistory())
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, ErrSlotTaken):
+			t.Errorf("ReserveSlots() error = %v, want ErrSlotTaken", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("%d of %d concurrent bookings succeeded, want 1", succeeded, attempts)
+	}
+}
+
+func TestReserveSlotsAfterRelease(t *testing.T) {
+	db := testdb.Open(t)
+	repo := NewReservationRepository(db)
+	court, timeslot := testdb.CreateSlot(t, db)
+	date := testdb.Date()
+	ctx := context.Background()
+
+	first := heldReservation(court.ID, timeslot.ID, date)
+	if err := repo.ReserveSlots(ctx, []*models.Reservation{first}, bookingHistory()); err != nil {
+		t.Fatalf("ReserveSlots() error = %v", err)
+	}
+	if first.SlotKey == nil || *first.SlotKey != models.SlotKey(court.ID, timeslot.ID, date) {
+		t.Fatalf("SlotKey = %v, want the key of the slot", first.SlotKey)
+	}
+
+	second := heldReservation(court.ID, timeslot.ID, date)
+	if err := repo.ReserveSlots(ctx, []*models.Reservation{second}, bookingHistory()); !errors.Is(err, ErrSlotTaken) {
+		t.Fatalf("ReserveSlots() on a held slot error = %v, want ErrSlotTaken", err)
+	}
+
+	// Cancelling clears the slot key, which frees the slot
+	first.Status = models.ReservationStatusCancelled
+	if err := repo.SaveTransition(ctx, first, &models.ReservationStatusHistory{
+		ReservationID: first.ID,
+		FromStatus:    models.ReservationStatusPending,
+		ToStatus:      models.ReservationStatusCancelled,
+		Trigger:       "cancellation",
+		Actor:         "test",
+	}, nil); err != nil {
+		t.Fatalf("SaveTransition() error = %v", err)
+	}
+	if first.SlotKey != nil {
+		t.Fatalf("SlotKey = %q after cancelling, want nil", *first.SlotKey)
+	}
+
+	third := heldReservation(court.ID, timeslot.ID, date)
+	if err := repo.ReserveSlots(ctx, []*models.Reservation{third}, bookingHistory()); err != nil {
+		t.Fatalf("ReserveSlots() after the slot was released error = %v", err)
+	}
+}
+
+func TestReserveSlotsRedeemsVoucherOncePerBooking(t *testing.T) {
+	db := testdb.Open(t)
+	repo := NewReservationRepository(db)
+	court, timeslot := testdb.CreateSlot(t, db)
+	date := testdb.Date()
+	ctx := context.Background()
+
+	stamp := time.Now().Format("20060102150405.000000000")
+	user := &models.User{Name: "Voucher tester", Email: "voucher-" + stamp + "@example.com", PasswordHash: "x"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	voucher := &models.Voucher{Code: "ONCE" + stamp, DiscountType: models.DiscountTypeFixed, DiscountValue: 1000, MaxUsesPerCustomer: 1, IsActive: true}
+	if err := db.Create(voucher).Error; err != nil {
+		t.Fatalf("failed to create voucher: %v", err)
+	}
+
+	booking := func(dates ...time.Time) []*models.Reservation {
+		reservations := make([]*models.Reservation, 0, len(dates))
+		for _, date := range dates {
+			reservation := heldReservation(court.ID, timeslot.ID, date)
+			reservation.UserID = &user.ID
+			reservation.VoucherID = &voucher.ID
+			reservations = append(reservations, reservation)
+		}
+		return reservations
+	}
+
+	if err := repo.ReserveSlots(ctx, booking(date, date.AddDate(0, 0, 1)), bookingHistory()); err != nil {
+		t.Fatalf("ReserveSlots() of a two slot booking with a single use voucher error = %v", err)
+	}
+	if err := repo.ReserveSlots(ctx, booking(date.AddDate(0, 0, 2)), bookingHistory()); !errors.Is(err, ErrVoucherUsedUp) {
+		t.Fatalf("ReserveSlots() of a second booking error = %v, want ErrVoucherUsedUp", err)
+	}
+}
+
+func TestReserveSlotsWithCancelledContext(t *testing.T) {
+	db := testdb.Open(t)
+	repo := NewReservationRepository(db)
+	court, timeslot := testdb.CreateSlot(t, db)
+	date := testdb.Date()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	reservation := heldReservation(court.ID, timeslot.ID, date)
+	if err := repo.ReserveSlots(ctx, []*models.Reservation{reservation}, bookingHistory()); !errors.Is(err, context.Canceled) {
+		t.Fatalf("ReserveSlots() error = %v, want context.Canceled", err)
+	}
+
+	available, err := repo.CheckSlotAvailability(context.Background(), court.ID, timeslot.ID, date)
+	if err != nil {
+		t.Fatalf("CheckSlotAvailability() error = %v", err)
+	}
+	if !available {
+		t.Fatal("slot is taken after a cancelled booking, want it free")
+	}
+}
+
+func TestFindPaidReservationsBetween(t *testing.T) {
+	db := testdb.Open(t)
+	repo := NewReservationRepository(db)
+	court, timeslot := testdb.CreateSlot(t, db)
+	date := testdb.Date()
+	ctx := context.Background()
+
+	user := &models.User{Name: "Paid customer", Email: "paid-" + time.Now().Format("20060102150405.000000000") + "@example.com", PasswordHash: "x"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	paid := heldReservation(court.ID, timeslot.ID, date)
+	paid.UserID = &user.ID
+	held := heldReservation(court.ID, timeslot.ID, date.AddDate(0, 0, 1))
+	if err := repo.ReserveSlots(ctx, []*models.Reservation{paid, held}, bookingHistory()); err != nil {
+		t.Fatalf("ReserveSlots() error = %v", err)
+	}
+	paid.Status = models.ReservationStatusPaid
+	if err := repo.UpdateReservation(ctx, paid); err != nil {
+		t.Fatalf("UpdateReservation() error = %v", err)
+	}
+
+	reservations, err := repo.FindPaidReservationsBetween(ctx, &court.ID, date, date.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("FindPaidReservationsBetween() error = %v", err)
+	}
+	if len(reservations) != 1 || reservations[0].ID != paid.ID {
+		t.Fatalf("FindPaidReservationsBetween() returned %d reservations, want only the paid one", len(reservations))
+	}
+	found := reservations[0]
+	if found.User == nil || found.User.Email != user.Email {
+		t.Fatalf("User = %+v, want the customer who booked", found.User)
+	}
+	if found.Court.ID != court.ID || found.Timeslot.ID != timeslot.ID {
+		t.Fatal("court and timeslot are not loaded")
+	}
+}
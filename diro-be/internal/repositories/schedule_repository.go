@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"diro-be/internal/models"
+)
+
+// ScheduleRepository handles database operations for court schedules
+type ScheduleRepository struct {
+	db *gorm.DB
+}
+
+// NewScheduleRepository creates a new schedule repository
+func NewScheduleRepository(db *gorm.DB) *ScheduleRepository {
+	return &ScheduleRepository{db: db}
+}
+
+// ListCourtSchedules returns the schedules of a court with their timeslots,
+// ordered by weekday and start time
+func (r *ScheduleRepository) ListCourtSchedules(ctx context.Context, courtID uint) ([]models.CourtSchedule, error) {
+	var schedules []models.CourtSchedule
+	err := r.db.WithContext(ctx).Preload("Timeslot").
+		Joins("JOIN timeslots ON timeslots.id = court_schedules.timeslot_id").
+		Where("court_schedules.court_id = ?", courtID).
+		Order("court_schedules.weekday, timeslots.start_time, court_schedules.effective_from").
+		Find(&schedules).Error
+	return schedules, err
+}
+
+// GetScheduleByID gets a schedule by ID with its timeslot
+func (r *ScheduleRepository) GetScheduleByID(ctx context.Context, id uint) (*models.CourtSchedule, error) {
+	var schedule models.CourtSchedule
+	err := r.db.WithContext(ctx).Preload("Timeslot").First(&schedule, id).Error
+	return &schedule, err
+}
+
+// CreateSchedule creates a new schedule
+func (r *ScheduleRepository) CreateSchedule(ctx context.Context, schedule *models.CourtSchedule) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(schedule).Error
+}
+
+// UpdateSchedule updates a schedule
+func (r *ScheduleRepository) UpdateSchedule(ctx context.Context, schedule *models.CourtSchedule) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(schedule).Error
+}
+
+// DeleteSchedule deletes a schedule
+func (r *ScheduleRepository) DeleteSchedule(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.CourtSchedule{}, id).Error
+}
+
+// IsScheduled reports whether the timeslot can be booked on the court on the
+// given date: a schedule covers that weekday and date, and both the court and
+// the timeslot are active
+func (r *ScheduleRepository) IsScheduled(ctx context.Context, courtID, timeslotID uint, date time.Time) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.CourtSchedule{}).
+		Joins("JOIN courts ON courts.id = court_schedules.court_id AND courts.deleted_at IS NULL").
+		Joins("JOIN timeslots ON timeslots.id = court_schedules.timeslot_id").
+		Where("court_schedules.court_id = ? AND court_schedules.timeslot_id = ?", courtID, timeslotID).
+		Where("courts.is_active = ? AND timeslots.is_active = ?", true, true).
+		Scopes(scheduledOn(date)).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// scheduledOn limits a query joined with court_schedules to schedules in
+// effect on the date's weekday
+func scheduledOn(date time.Time) func(*gorm.DB) *gorm.DB {
+	day := date.Format("2006-01-02")
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("court_schedules.weekday = ? AND court_schedules.effective_from <= ? AND (court_schedules.effective_to IS NULL OR court_schedules.effective_to >= ?)",
+			int(date.Weekday()), day, day)
+	}
+}
@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"diro-be/internal/models"
+	"diro-be/internal/testdb"
+)
+
+func TestIsScheduled(t *testing.T) {
+	db := testdb.Open(t)
+	repo := NewScheduleRepository(db)
+	ctx := context.Background()
+
+	court, timeslot := testdb.CreateSlot(t, db)
+	date := testdb.Date()
+	effectiveTo := date.AddDate(0, 0, 14)
+	if err := repo.CreateSchedule(ctx, &models.CourtSchedule{
+		CourtID:       court.ID,
+		Weekday:       int(date.Weekday()),
+		TimeslotID:    timeslot.ID,
+		EffectiveFrom: date,
+		EffectiveTo:   &effectiveTo,
+	}); err != nil {
+		t.Fatalf("CreateSchedule() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		date time.Time
+		want bool
+	}{
+		{name: "first day in effect", date: date, want: true},
+		{name: "last day in effect", date: effectiveTo, want: true},
+		{name: "other weekday", date: date.AddDate(0, 0, 1), want: false},
+		{name: "week before it takes effect", date: date.AddDate(0, 0, -7), want: false},
+		{name: "week after it ends", date: effectiveTo.AddDate(0, 0, 7), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheduled, err := repo.IsScheduled(ctx, court.ID, timeslot.ID, tt.date)
+			if err != nil {
+				t.Fatalf("IsScheduled() error = %v", err)
+			}
+			if scheduled != tt.want {
+				t.Fatalf("IsScheduled() = %v, want %v", scheduled, tt.want)
+			}
+		})
+	}
+
+	if err := db.Model(timeslot).Update("is_active", false).Error; err != nil {
+		t.Fatalf("failed to deactivate timeslot: %v", err)
+	}
+	scheduled, err := repo.IsScheduled(ctx, court.ID, timeslot.ID, date)
+	if err != nil {
+		t.Fatalf("IsScheduled() error = %v", err)
+	}
+	if scheduled {
+		t.Fatal("IsScheduled() = true for an inactive timeslot, want false")
+	}
+}
+
+func TestGetAvailabilityRange(t *testing.T) {
+	db := testdb.Open(t)
+	repo := NewReservationRepository(db)
+	ctx := context.Background()
+
+	court, timeslot := testdb.CreateSlot(t, db)
+	from := testdb.Date()
+	if err := NewScheduleRepository(db).CreateSchedule(ctx, &models.CourtSchedule{
+		CourtID:       court.ID,
+		Weekday:       int(from.Weekday()),
+		TimeslotID:    timeslot.ID,
+		EffectiveFrom: time.Date(1970, 1, 1, 0, 0, 0, 0, time.Local),
+	}); err != nil {
+		t.Fatalf("CreateSchedule() error = %v", err)
+	}
+
+	// Paid on the first week's slot, held on the second week's
+	paid := heldReservation(court.ID, timeslot.ID, from)
+	held := heldReservation(court.ID, timeslot.ID, from.AddDate(0, 0, 7))
+	if err := repo.ReserveSlots(ctx, []*models.Reservation{paid, held}, bookingHistory()); err != nil {
+		t.Fatalf("ReserveSlots() error = %v", err)
+	}
+	paid.Status = models.ReservationStatusPaid
+	if err := repo.UpdateReservation(ctx, paid); err != nil {
+		t.Fatalf("UpdateReservation() error = %v", err)
+	}
+
+	to := from.AddDate(0, 0, 14)
+	days, err := repo.GetAvailability(ctx, from, to, &court.ID)
+	if err != nil {
+		t.Fatalf("GetAvailability() error = %v", err)
+	}
+	if len(days) != 15 {
+		t.Fatalf("GetAvailability() returned %d days, want 15", len(days))
+	}
+
+	for i, day := range days {
+		date := from.AddDate(0, 0, i)
+		if day.Date != date.Format("2006-01-02") {
+			t.Fatalf("day %d is %s, want %s", i, day.Date, date.Format("2006-01-02"))
+		}
+		if len(day.Courts) != 1 {
+			t.Fatalf("%s lists %d courts, want 1", day.Date, len(day.Courts))
+		}
+
+		slots := day.Courts[0].Timeslots
+		if date.Weekday() != from.Weekday() {
+			if len(slots) != 0 {
+				t.Fatalf("%s lists %d timeslots on an unscheduled weekday, want none", day.Date, len(slots))
+			}
+			continue
+		}
+		if len(slots) != 1 {
+			t.Fatalf("%s lists %d timeslots, want 1", day.Date, len(slots))
+		}
+
+		wantBooked, wantHeld := i == 0, i == 7
+		if slots[0].IsBooked != wantBooked || slots[0].IsHeld != wantHeld {
+			t.Fatalf("%s is booked %v and held %v, want booked %v and held %v", day.Date, slots[0].IsBooked, slots[0].IsHeld, wantBooked, wantHeld)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"context"
+	"gorm.io/gorm"
+
+	"diro-be/internal/models"
+)
+
+// TimeslotRepository handles database operations for timeslots
+type TimeslotRepository struct {
+	db *gorm.DB
+}
+
+// NewTimeslotRepository creates a new timeslot repository
+func NewTimeslotRepository(db *gorm.DB) *TimeslotRepository {
+	return &TimeslotRepository{db: db}
+}
+
+// ListTimeslots returns all timeslots ordered by start time. Retired
+// timeslots are only included when includeRetired is set.
+func (r *TimeslotRepository) ListTimeslots(ctx context.Context, includeRetired bool) ([]models.Timeslot, error) {
+	query := r.db.WithContext(ctx).Order("start_time")
+	if !includeRetired {
+		query = query.Where("is_active = ?", true)
+	}
+
+	var timeslots []models.Timeslot
+	err := query.Find(&timeslots).Error
+	return timeslots, err
+}
+
+// GetTimeslotByID gets a timeslot by ID
+func (r *TimeslotRepository) GetTimeslotByID(ctx context.Context, id uint) (*models.Timeslot, error) {
+	var timeslot models.Timeslot
+	err := r.db.WithContext(ctx).First(&timeslot, id).Error
+	return &timeslot, err
+}
+
+// CreateTimeslot creates a new timeslot
+func (r *TimeslotRepository) CreateTimeslot(ctx context.Context, timeslot *models.Timeslot) error {
+	return r.db.WithContext(ctx).Create(timeslot).Error
+}
+
+// UpdateTimeslot updates a timeslot
+func (r *TimeslotRepository) UpdateTimeslot(ctx context.Context, timeslot *models.Timeslot) error {
+	return r.db.WithContext(ctx).Save(timeslot).Error
+}
@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"diro-be/internal/models"
+)
+
+// ErrEmailTaken is returned when registering an email that already has an account
+var ErrEmailTaken = errors.New("email is already registered")
+
+// UserRepository handles database operations for users
+type UserRepository struct {
+	db *gorm.DB
+}
+
+// NewUserRepository creates a new user repository
+func NewUserRepository(db *gorm.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+// CreateUser creates a new user, returning ErrEmailTaken for a duplicate email
+func (r *UserRepository) CreateUser(ctx context.Context, user *models.User) error {
+	if err := r.db.WithContext(ctx).Create(user).Error; err != nil {
+		if isDuplicateEntry(err) {
+			return ErrEmailTaken
+		}
+		return err
+	}
+	return nil
+}
+
+// GetUserByID gets a user by ID
+func (r *UserRepository) GetUserByID(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).First(&user, id).Error
+	return &user, err
+}
+
+// GetUserByEmail gets a user by email
+func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
+	return &user, err
+}
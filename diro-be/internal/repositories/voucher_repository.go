@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"diro-be/internal/models"
+)
+
+// ErrVoucherCodeTaken is returned when another voucher already uses the code
+var ErrVoucherCodeTaken = errors.New("voucher code already exists")
+
+// VoucherRepository handles database operations for vouchers
+type VoucherRepository struct {
+	db *gorm.DB
+}
+
+// NewVoucherRepository creates a new voucher repository
+func NewVoucherRepository(db *gorm.DB) *VoucherRepository {
+	return &VoucherRepository{db: db}
+}
+
+// ListVouchers returns all vouchers, newest first
+func (r *VoucherRepository) ListVouchers(ctx context.Context) ([]models.Voucher, error) {
+	var vouchers []models.Voucher
+	err := r.db.WithContext(ctx).Order("id DESC").Find(&vouchers).Error
+	return vouchers, err
+}
+
+// GetVoucherByID gets a voucher by ID
+func (r *VoucherRepository) GetVoucherByID(ctx context.Context, id uint) (*models.Voucher, error) {
+	var voucher models.Voucher
+	err := r.db.WithContext(ctx).First(&voucher, id).Error
+	return &voucher, err
+}
+
+// GetVoucherByCode gets a voucher by its upper case code
+func (r *VoucherRepository) GetVoucherByCode(ctx context.Context, code string) (*models.Voucher, error) {
+	var voucher models.Voucher
+	err := r.db.WithContext(ctx).Where("code = ?", code).First(&voucher).Error
+	return &voucher, err
+}
+
+// CreateVoucher creates a new voucher, returning ErrVoucherCodeTaken if the
+// code is in use
+func (r *VoucherRepository) CreateVoucher(ctx context.Context, voucher *models.Voucher) error {
+	if err := r.db.WithContext(ctx).Create(voucher).Error; err != nil {
+		if isDuplicateEntry(err) {
+			return ErrVoucherCodeTaken
+		}
+		return err
+	}
+	return nil
+}
+
+// UpdateVoucher updates a voucher, returning ErrVoucherCodeTaken if the code
+// is in use
+func (r *VoucherRepository) UpdateVoucher(ctx context.Context, voucher *models.Voucher) error {
+	if err := r.db.WithContext(ctx).Save(voucher).Error; err != nil {
+		if isDuplicateEntry(err) {
+			return ErrVoucherCodeTaken
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"diro-be/internal/models"
+)
+
+// ErrStaleWaitlistEntry is returned when a waitlist entry changed status since
+// it was loaded
+var ErrStaleWaitlistEntry = errors.New("waitlist entry was modified concurrently")
+
+// WaitlistRepository handles database operations for waitlist entries
+type WaitlistRepository struct {
+	db *gorm.DB
+}
+
+// NewWaitlistRepository creates a new waitlist repository
+func NewWaitlistRepository(db *gorm.DB) *WaitlistRepository {
+	return &WaitlistRepository{db: db}
+}
+
+// CreateEntry creates a new waitlist entry
+func (r *WaitlistRepository) CreateEntry(ctx context.Context, entry *models.WaitlistEntry) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(entry).Error
+}
+
+// GetEntryByID gets a waitlist entry with its court and timeslot
+func (r *WaitlistRepository) GetEntryByID(ctx context.Context, id uint) (*models.WaitlistEntry, error) {
+	var entry models.WaitlistEntry
+	err := r.db.WithContext(ctx).Preload("Court", withDeletedCourts).Preload("Timeslot").First(&entry, id).Error
+	return &entry, err
+}
+
+// ListUserEntries returns the waitlist entries of a customer, newest first
+func (r *WaitlistRepository) ListUserEntries(ctx context.Context, userID uint) ([]models.WaitlistEntry, error) {
+	var entries []models.WaitlistEntry
+	err := r.db.WithContext(ctx).Preload("Court", withDeletedCourts).Preload("Timeslot").
+		Where("user_id = ?", userID).
+		Order("date DESC, id DESC").
+		Find(&entries).Error
+	return entries, err
+}
+
+// HasOpenEntry reports whether a customer is already waiting for, or has an
+// offer on, the same slot
+func (r *WaitlistRepository) HasOpenEntry(ctx context.Context, userID uint, courtID *uint, timeslotID uint, date time.Time) (bool, error) {
+	query := r.db.WithContext(ctx).Model(&models.WaitlistEntry{}).
+		Where("user_id = ? AND timeslot_id = ? AND date = ? AND status IN ?",
+			userID, timeslotID, date.Format("2006-01-02"), []string{models.WaitlistStatusWaiting, models.WaitlistStatusOffered})
+	if courtID != nil {
+		query = query.Where("court_id = ?", *courtID)
+	} else {
+		query = query.Where("court_id IS NULL")
+	}
+
+	var count int64
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+// NextInLine returns the longest waiting entry for a slot, including entries
+// waiting for any court at that time
+func (r *WaitlistRepository) NextInLine(ctx context.Context, courtID, timeslotID uint, date time.Time) (*models.WaitlistEntry, error) {
+	var entry models.WaitlistEntry
+	err := r.db.WithContext(ctx).Preload("Timeslot").
+		Where("timeslot_id = ? AND date = ? AND status = ? AND (court_id = ? OR court_id IS NULL)",
+			timeslotID, date.Format("2006-01-02"), models.WaitlistStatusWaiting, courtID).
+		Order("id").
+		First(&entry).Error
+	return &entry, err
+}
+
+// FindOpenOffers returns the offers on the slots that have not lapsed at now
+func (r *WaitlistRepository) FindOpenOffers(ctx context.Context, courtID, timeslotID uint, date time.Time, now time.Time) ([]models.WaitlistEntry, error) {
+	var entries []models.WaitlistEntry
+	err := r.db.WithContext(ctx).Where("offered_court_id = ? AND timeslot_id = ? AND date = ? AND status = ? AND offer_expires_at > ?",
+		courtID, timeslotID, date.Format("2006-01-02"), models.WaitlistStatusOffered, now).
+		Find(&entries).Error
+	return entries, err
+}
+
+// FindLapsedOffers returns the offers that lapsed at or before now
+func (r *WaitlistRepository) FindLapsedOffers(ctx context.Context, now time.Time) ([]models.WaitlistEntry, error) {
+	var entries []models.WaitlistEntry
+	err := r.db.WithContext(ctx).Where("status = ? AND offer_expires_at <= ?", models.WaitlistStatusOffered, now).
+		Order("id").
+		Find(&entries).Error
+	return entries, err
+}
+
+// ExpirePastEntries expires the entries still waiting for a date before today
+func (r *WaitlistRepository) ExpirePastEntries(ctx context.Context, today time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&models.WaitlistEntry{}).
+		Where("status = ? AND date < ?", models.WaitlistStatusWaiting, today.Format("2006-01-02")).
+		Update("status", models.WaitlistStatusExpired)
+	return result.RowsAffected, result.Error
+}
+
+// UpdateEntry saves the status and offer of an entry if it is still in the
+// from status, returning ErrStaleWaitlistEntry otherwise
+func (r *WaitlistRepository) UpdateEntry(ctx context.Context, entry *models.WaitlistEntry, from string) error {
+	result := r.db.WithContext(ctx).Model(&models.WaitlistEntry{}).
+		Where("id = ? AND status = ?", entry.ID, from).
+		Updates(map[string]interface{}{
+			"status":           entry.Status,
+			"offered_court_id": entry.OfferedCourtID,
+			"offer_expires_at": entry.OfferExpiresAt,
+			"reservation_id":   entry.ReservationID,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrStaleWaitlistEntry
+	}
+	return nil
+}
@@ -5,38 +5,162 @@ import (
 
 	"diro-be/internal/config"
 	"diro-be/internal/handlers"
-	"diro-be/internal/repositories"
+	"diro-be/internal/middleware"
+	"diro-be/internal/models"
 	"diro-be/internal/services"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRoutes(router *gin.Engine, cfg *config.Config, reservationRepo *repositories.ReservationRepository) {
-	// CORS middleware
-	router.Use(gin.Recovery())
-	router.Use(gin.Logger())
-
-	// Initialize services
-	reservationService := services.NewReservationService(reservationRepo, cfg.XenditUsername, cfg.XenditPassword)
+func SetupRoutes(router *gin.Engine, cfg *config.Config, reservationService *services.ReservationService, authService *services.AuthService, courtService *services.CourtService, timeslotService *services.TimeslotService, scheduleService *services.ScheduleService, pricingService *services.PricingService, voucherService *services.VoucherService, blackoutService *services.BlackoutService, paymentGateway services.PaymentGateway) {
+	// Request ID, logging, recovery and timeout middleware
+	router.Use(middleware.RequestID())
+	router.Use(middleware.RequestLogger())
+	router.Use(middleware.Recovery())
+	router.Use(middleware.Timeout(cfg.RequestTimeout))
 
 	// Initialize handlers
 	reservationHandler := handlers.NewReservationHandler(reservationService)
-	webhookHandler := handlers.NewWebhookHandler(reservationService)
+	webhookHandler := handlers.NewWebhookHandler(reservationService, cfg.XenditCallbackToken)
+	authHandler := handlers.NewAuthHandler(authService)
+	courtHandler := handlers.NewCourtHandler(courtService)
+	timeslotHandler := handlers.NewTimeslotHandler(timeslotService)
+	scheduleHandler := handlers.NewScheduleHandler(scheduleService)
+	pricingHandler := handlers.NewPricingHandler(pricingService)
+	voucherHandler := handlers.NewVoucherHandler(voucherService)
+	blackoutHandler := handlers.NewBlackoutHandler(blackoutService)
 
 	// API routes
 	api := router.Group("/api/v1")
 	{
+		// Auth routes
+		auth := api.Group("/auth")
+		{
+			auth.POST("/register", authHandler.Register)
+			auth.POST("/login", authHandler.Login)
+			auth.POST("/refresh", authHandler.Refresh)
+		}
+
+		// Authenticated customer routes
+		me := api.Group("/me", middleware.RequireAuth(authService))
+		{
+			me.GET("", authHandler.Me)
+			me.GET("/reservations", reservationHandler.ListMyReservations)
+			me.GET("/waitlist", reservationHandler.ListMyWaitlist)
+		}
+
 		// Reservation routes
 		reservations := api.Group("/reservations")
 		{
 			reservations.GET("/availability", reservationHandler.GetDayAvailability)
-			reservations.POST("", reservationHandler.CreateReservation)
+			reservations.POST("", middleware.OptionalAuth(authService), reservationHandler.CreateReservation)
+			reservations.GET("/:id", middleware.RequireAuth(authService), reservationHandler.GetReservation)
+			reservations.POST("/:id/cancel", middleware.RequireAuth(authService), reservationHandler.CancelReservation)
+			reservations.GET("/:id/refunds", middleware.RequireAuth(authService), reservationHandler.ListRefunds)
+		}
+
+		// Booking routes
+		api.POST("/bookings", middleware.OptionalAuth(authService), reservationHandler.CreateBooking)
+
+		// Recurring series routes
+		series := api.Group("/series", middleware.RequireAuth(authService))
+		{
+			series.POST("", reservationHandler.CreateSeries)
+			series.GET("/:id", reservationHandler.GetSeries)
+			series.POST("/:id/cancel", reservationHandler.CancelSeries)
+		}
+
+		// Waitlist routes
+		waitlist := api.Group("/waitlist", middleware.RequireAuth(authService))
+		{
+			waitlist.POST("", reservationHandler.JoinWaitlist)
+			waitlist.POST("/:id/accept", reservationHandler.AcceptWaitlistOffer)
+			waitlist.DELETE("/:id", reservationHandler.LeaveWaitlist)
+		}
+
+		// Admin routes
+		admin := api.Group("/admin", middleware.RequireAuth(authService), middleware.RequireRole(models.UserRoleAdmin))
+		{
+			courts := admin.Group("/courts")
+			{
+				courts.GET("", courtHandler.ListCourts)
+				courts.POST("", courtHandler.CreateCourt)
+				courts.GET("/:id", courtHandler.GetCourt)
+				courts.PUT("/:id", courtHandler.UpdateCourt)
+				courts.DELETE("/:id", courtHandler.DeleteCourt)
+				courts.POST("/:id/activate", courtHandler.ActivateCourt)
+				courts.POST("/:id/deactivate", courtHandler.DeactivateCourt)
+				courts.GET("/:id/schedules", scheduleHandler.ListCourtSchedules)
+				courts.POST("/:id/schedules", scheduleHandler.CreateCourtSchedule)
+			}
+
+			schedules := admin.Group("/schedules")
+			{
+				schedules.PUT("/:id", scheduleHandler.UpdateSchedule)
+				schedules.DELETE("/:id", scheduleHandler.DeleteSchedule)
+			}
+
+			priceRules := admin.Group("/price-rules")
+			{
+				priceRules.GET("", pricingHandler.ListPriceRules)
+				priceRules.POST("", pricingHandler.CreatePriceRule)
+				priceRules.PUT("/:id", pricingHandler.UpdatePriceRule)
+				priceRules.DELETE("/:id", pricingHandler.DeletePriceRule)
+			}
+
+			holidays := admin.Group("/holidays")
+			{
+				holidays.GET("", pricingHandler.ListHolidays)
+				holidays.POST("", pricingHandler.CreateHoliday)
+				holidays.DELETE("/:id", pricingHandler.DeleteHoliday)
+			}
+
+			vouchers := admin.Group("/vouchers")
+			{
+				vouchers.GET("", voucherHandler.ListVouchers)
+				vouchers.POST("", voucherHandler.CreateVoucher)
+				vouchers.GET("/:id", voucherHandler.GetVoucher)
+				vouchers.PUT("/:id", voucherHandler.UpdateVoucher)
+			}
+
+			blackouts := admin.Group("/blackouts")
+			{
+				blackouts.GET("", blackoutHandler.ListBlackouts)
+				blackouts.POST("", blackoutHandler.CreateBlackout)
+				blackouts.GET("/:id", blackoutHandler.GetBlackout)
+				blackouts.PUT("/:id", blackoutHandler.UpdateBlackout)
+				blackouts.DELETE("/:id", blackoutHandler.DeleteBlackout)
+				blackouts.GET("/:id/conflicts", blackoutHandler.ListConflicts)
+			}
+
+			timeslots := admin.Group("/timeslots")
+			{
+				timeslots.GET("", timeslotHandler.ListTimeslots)
+				timeslots.POST("", timeslotHandler.CreateTimeslot)
+				timeslots.PUT("/:id", timeslotHandler.UpdateTimeslot)
+				timeslots.POST("/:id/retire", timeslotHandler.RetireTimeslot)
+			}
+
+			admin.GET("/reservations/:id/history", reservationHandler.GetReservationHistory)
+			admin.POST("/reservations/:id/refunds", reservationHandler.RefundReservation)
 		}
 
 		// Webhook routes
 		webhooks := api.Group("/webhooks")
 		{
 			webhooks.POST("/xendit", webhookHandler.XenditWebhook)
+			webhooks.POST("/xendit/refunds", webhookHandler.XenditRefundWebhook)
+		}
+
+		// Fake payment gateway routes, only mounted when running offline
+		if fakeGateway, ok := paymentGateway.(*services.FakePaymentGateway); ok {
+			fakeGatewayHandler := handlers.NewFakeGatewayHandler(fakeGateway)
+			invoices := api.Group("/fake-gateway/invoices")
+			{
+				invoices.GET("/:id", fakeGatewayHandler.GetInvoice)
+				invoices.POST("/:id/pay", fakeGatewayHandler.PayInvoice)
+				invoices.POST("/:id/expire", fakeGatewayHandler.ExpireInvoice)
+			}
 		}
 	}
 
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"diro-be/internal/models"
+	"diro-be/internal/repositories"
+)
+
+// ErrInvalidCredentials is returned when an email and password do not match an account
+var ErrInvalidCredentials = errors.New("invalid email or password")
+
+// ErrInvalidToken is returned for malformed, expired or wrongly typed tokens
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// Token types
+const (
+	tokenTypeAccess  = "access"
+	tokenTypeRefresh = "refresh"
+)
+
+// TokenClaims are the claims carried by access and refresh tokens. The
+// subject is the user ID.
+type TokenClaims struct {
+	Role string `json:"role"`
+	Type string `json:"typ"`
+	jwt.RegisteredClaims
+}
+
+// UserID returns the user ID stored in the token subject
+func (c *TokenClaims) UserID() (uint, error) {
+	id, err := strconv.ParseUint(c.Subject, 10, 32)
+	if err != nil {
+		return 0, ErrInvalidToken
+	}
+	return uint(id), nil
+}
+
+// AuthService handles customer accounts and token issuance
+type AuthService struct {
+	userRepo        *repositories.UserRepository
+	jwtSecret       []byte
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+}
+
+// NewAuthService creates a new auth service signing tokens with jwtSecret
+func NewAuthService(userRepo *repositories.UserRepository, jwtSecret string, accessTokenTTL, refreshTokenTTL time.Duration) *AuthService {
+	return &AuthService{
+		userRepo:        userRepo,
+		jwtSecret:       []byte(jwtSecret),
+		accessTokenTTL:  accessTokenTTL,
+		refreshTokenTTL: refreshTokenTTL,
+	}
+}
+
+// Register creates a customer account and returns its first token pair
+func (s *AuthService) Register(ctx context.Context, name, email, mobileNumber, password string) (*models.User, *models.AuthTokens, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user := &models.User{
+		Name:         name,
+		Email:        strings.ToLower(strings.TrimSpace(email)),
+		MobileNumber: mobileNumber,
+		PasswordHash: string(hash),
+		Role:         models.UserRoleCustomer,
+	}
+	if err := s.userRepo.CreateUser(ctx, user); err != nil {
+		return nil, nil, err
+	}
+
+	tokens, err := s.issueTokens(user)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, tokens, nil
+}
+
+// Login checks the password of an account and returns a new token pair
+func (s *AuthService) Login(ctx context.Context, email, password string) (*models.User, *models.AuthTokens, error) {
+	user, err := s.userRepo.GetUserByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return nil, nil, ErrInvalidCredentials
+	}
+
+	tokens, err := s.issueTokens(user)
+	if err != nil {
+		return nil, nil, err
+	}
+	return user, tokens, nil
+}
+
+// Refresh exchanges a valid refresh token for a new token pair
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*models.AuthTokens, error) {
+	claims, err := s.parseToken(refreshToken, tokenTypeRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := claims.UserID()
+	if err != nil {
+		return nil, err
+	}
+
+	// Reload the user so deleted accounts and role changes take effect
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(user)
+}
+
+// ParseAccessToken validates an access token and returns its claims
+func (s *AuthService) ParseAccessToken(accessToken string) (*TokenClaims, error) {
+	return s.parseToken(accessToken, tokenTypeAccess)
+}
+
+// GetUser returns the account with the given ID
+func (s *AuthService) GetUser(ctx context.Context, userID uint) (*models.User, error) {
+	return s.userRepo.GetUserByID(ctx, userID)
+}
+
+// issueTokens signs a new access and refresh token for the user
+func (s *AuthService) issueTokens(user *models.User) (*models.AuthTokens, error) {
+	accessToken, err := s.signToken(user, tokenTypeAccess, s.accessTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := s.signToken(user, tokenTypeRefresh, s.refreshTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AuthTokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(s.accessTokenTTL.Seconds()),
+	}, nil
+}
+
+func (s *AuthService) signToken(user *models.User, tokenType string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := TokenClaims{
+		Role: user.Role,
+		Type: tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   strconv.Itoa(int(user.ID)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.jwtSecret)
+}
+
+func (s *AuthService) parseToken(tokenString, tokenType string) (*TokenClaims, error) {
+	claims := &TokenClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return s.jwtSecret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil || claims.Type != tokenType {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"diro-be/internal/models"
+	"diro-be/internal/repositories"
+)
+
+// ErrBlackoutNotFound is returned when a blackout does not exist
+var ErrBlackoutNotFound = errors.New("blackout not found")
+
+// ErrInvalidBlackout is returned when a blackout fails validation
+var ErrInvalidBlackout = errors.New("invalid blackout")
+
+// ErrSlotBlackedOut is returned when booking a slot while its court is closed
+var ErrSlotBlackedOut = errors.New("court is closed for this slot")
+
+// BlackoutList is a snapshot of the blackouts of a period, so many slots can
+// be checked without querying them again
+type BlackoutList []models.Blackout
+
+// Find returns the first blackout closing the court during any part of the
+// timeslot on date, or nil if the court is open
+func (l BlackoutList) Find(courtID uint, timeslot models.Timeslot, date time.Time) *models.Blackout {
+	start, end, err := slotWindow(timeslot, date)
+	if err != nil {
+		return nil
+	}
+	for i := range l {
+		blackout := &l[i]
+		if blackout.CourtID != nil && *blackout.CourtID != courtID {
+			continue
+		}
+		if blackout.StartsAt.Before(end) && blackout.EndsAt.After(start) {
+			return blackout
+		}
+	}
+	return nil
+}
+
+// BlackoutService manages the periods courts are closed
+type BlackoutService struct {
+	blackoutRepo    *repositories.BlackoutRepository
+	courtRepo       *repositories.CourtRepository
+	timeslotRepo    *repositories.TimeslotRepository
+	reservationRepo *repositories.ReservationRepository
+}
+
+// NewBlackoutService creates a new blackout service
+func NewBlackoutService(blackoutRepo *repositories.BlackoutRepository, courtRepo *repositories.CourtRepository, timeslotRepo *repositories.TimeslotRepository, reservationRepo *repositories.ReservationRepository) *BlackoutService {
+	return &BlackoutService{
+		blackoutRepo:    blackoutRepo,
+		courtRepo:       courtRepo,
+		timeslotRepo:    timeslotRepo,
+		reservationRepo: reservationRepo,
+	}
+}
+
+// BlackoutList loads the blackouts overlapping the days from from to to
+// inclusive
+func (s *BlackoutService) BlackoutList(ctx context.Context, from, to time.Time) (BlackoutList, error) {
+	start := dayStart(from)
+	end := dayStart(to).AddDate(0, 0, 1)
+	return s.blackoutRepo.ListBlackouts(ctx, &start, &end)
+}
+
+// SlotBlackout returns the blackout closing a court during a timeslot on a
+// date, or nil if the court is open
+func (s *BlackoutService) SlotBlackout(ctx context.Context, courtID, timeslotID uint, date time.Time) (*models.Blackout, error) {
+	timeslot, err := s.timeslotRepo.GetTimeslotByID(ctx, timeslotID)
+	if err != nil {
+		return nil, err
+	}
+
+	blackouts, err := s.BlackoutList(ctx, date, date)
+	if err != nil {
+		return nil, err
+	}
+	return blackouts.Find(courtID, *timeslot, date), nil
+}
+
+// ListBlackouts returns the blackouts overlapping the days from from to to
+// inclusive. A nil bound leaves that side open.
+func (s *BlackoutService) ListBlackouts(ctx context.Context, from, to *time.Time) ([]models.Blackout, error) {
+	var start, end *time.Time
+	if from != nil {
+		day := dayStart(*from)
+		start = &day
+	}
+	if to != nil {
+		day := dayStart(*to).AddDate(0, 0, 1)
+		end = &day
+	}
+	return s.blackoutRepo.ListBlackouts(ctx, start, end)
+}
+
+// GetBlackout returns a blackout by ID
+func (s *BlackoutService) GetBlackout(ctx context.Context, id uint) (*models.Blackout, error) {
+	blackout, err := s.blackoutRepo.GetBlackoutByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrBlackoutNotFound
+	}
+	return blackout, err
+}
+
+// CreateBlackout validates and creates a blackout. It returns the paid
+// reservations the blackout collides with, so staff can contact those
+// customers; they are not cancelled.
+func (s *BlackoutService) CreateBlackout(ctx context.Context, blackout *models.Blackout) (*models.Blackout, []models.Reservation, error) {
+	blackout.ID = 0
+	if err := s.validateBlackout(ctx, blackout); err != nil {
+		return nil, nil, err
+	}
+
+	// Looked up first, so a failing lookup does not leave the blackout saved
+	// behind an error
+	conflicts, err := s.conflictingReservations(ctx, blackout)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.blackoutRepo.CreateBlackout(ctx, blackout); err != nil {
+		return nil, nil, err
+	}
+	return blackout, conflicts, nil
+}
+
+// UpdateBlackout replaces the court, period and reason of a blackout and
+// returns the paid reservations it now collides with
+func (s *BlackoutService) UpdateBlackout(ctx context.Context, id uint, blackout *models.Blackout) (*models.Blackout, []models.Reservation, error) {
+	existing, err := s.GetBlackout(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	blackout.ID = existing.ID
+	blackout.CreatedAt = existing.CreatedAt
+	if err := s.validateBlackout(ctx, blackout); err != nil {
+		return nil, nil, err
+	}
+
+	conflicts, err := s.conflictingReservations(ctx, blackout)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.blackoutRepo.UpdateBlackout(ctx, blackout); err != nil {
+		return nil, nil, err
+	}
+	return blackout, conflicts, nil
+}
+
+// DeleteBlackout deletes a blackout, reopening its slots
+func (s *BlackoutService) DeleteBlackout(ctx context.Context, id uint) error {
+	if _, err := s.GetBlackout(ctx, id); err != nil {
+		return err
+	}
+	return s.blackoutRepo.DeleteBlackout(ctx, id)
+}
+
+// ConflictingReservations returns the paid reservations a blackout collides
+// with
+func (s *BlackoutService) ConflictingReservations(ctx context.Context, id uint) ([]models.Reservation, error) {
+	blackout, err := s.GetBlackout(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.conflictingReservations(ctx, blackout)
+}
+
+// conflictingReservations returns the paid reservations whose slot overlaps
+// the blackout
+func (s *BlackoutService) conflictingReservations(ctx context.Context, blackout *models.Blackout) ([]models.Reservation, error) {
+	reservations, err := s.reservationRepo.FindPaidReservationsBetween(ctx, blackout.CourtID, blackout.StartsAt, blackout.EndsAt)
+	if err != nil {
+		return nil, err
+	}
+
+	blackouts := BlackoutList{*blackout}
+	conflicts := make([]models.Reservation, 0)
+	for _, reservation := range reservations {
+		if blackouts.Find(reservation.CourtID, reservation.Timeslot, reservation.Date) != nil {
+			conflicts = append(conflicts, reservation)
+		}
+	}
+	return conflicts, nil
+}
+
+// validateBlackout checks the reason, period and court of a blackout
+func (s *BlackoutService) validateBlackout(ctx context.Context, blackout *models.Blackout) error {
+	blackout.Reason = strings.TrimSpace(blackout.Reason)
+	if blackout.Reason == "" {
+		return fmt.Errorf("%w: reason is required", ErrInvalidBlackout)
+	}
+	if !blackout.EndsAt.After(blackout.StartsAt) {
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidBlackout)
+	}
+
+	if blackout.CourtID != nil {
+		if _, err := s.courtRepo.GetCourtByID(ctx, *blackout.CourtID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: court %d does not exist", ErrInvalidBlackout, *blackout.CourtID)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// slotWindow returns when a timeslot starts and ends on a date, in local time.
+// A slot ending at or before its start time ends the next day.
+func slotWindow(timeslot models.Timeslot, date time.Time) (time.Time, time.Time, error) {
+	start, err := slotStart(&models.Reservation{Date: date, Timeslot: timeslot})
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	clock, err := time.Parse("15:04", timeslot.EndTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid timeslot end time %q: %w", timeslot.EndTime, err)
+	}
+	end := time.Date(start.Year(), start.Month(), start.Day(), clock.Hour(), clock.Minute(), 0, 0, time.Local)
+	if !end.After(start) {
+		end = end.AddDate(0, 0, 1)
+	}
+	return start, end, nil
+}
+
+// dayStart returns midnight at the start of the date, in local time
+func dayStart(date time.Time) time.Time {
+	year, month, day := date.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+}
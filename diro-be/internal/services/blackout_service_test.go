@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"diro-be/internal/models"
+	"diro-be/internal/testdb"
+)
+
+func TestCreateBlackoutReportsConflicts(t *testing.T) {
+	db := testdb.Open(t)
+	gateway := newTestGateway()
+	service := newTestReservationService(t, db, gateway)
+	user := createTestUser(t, db)
+
+	court, timeslot := testdb.CreateSlot(t, db)
+	testdb.ScheduleDaily(t, db, court, timeslot)
+	date := testdb.Date()
+	reservation, _, err := service.CreateReservation(context.Background(), &user.ID, court.ID, timeslot.ID, date, testCustomer, "")
+	if err != nil {
+		t.Fatalf("CreateReservation() error = %v", err)
+	}
+	payTestReservation(t, service, gateway, reservation)
+
+	// Covers the paid 19:00 slot, and the free slot of the next day
+	blackout, conflicts, err := service.blackoutService.CreateBlackout(context.Background(), &models.Blackout{
+		CourtID:  &court.ID,
+		StartsAt: date.Add(18 * time.Hour),
+		EndsAt:   date.Add(24*time.Hour + 21*time.Hour),
+		Reason:   "Resurfacing",
+	})
+	if err != nil {
+		t.Fatalf("CreateBlackout() error = %v", err)
+	}
+	if blackout.ID == 0 {
+		t.Fatal("blackout was not saved")
+	}
+
+	if len(conflicts) != 1 || conflicts[0].ID != reservation.ID {
+		t.Fatalf("CreateBlackout() reported %d conflicts, want the paid reservation %d", len(conflicts), reservation.ID)
+	}
+	if conflicts[0].User == nil || conflicts[0].User.ID != user.ID {
+		t.Fatalf("conflict lists customer %+v, want user %d", conflicts[0].User, user.ID)
+	}
+}
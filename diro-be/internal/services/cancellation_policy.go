@@ -0,0 +1,45 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"diro-be/internal/models"
+)
+
+// CancellationPolicy decides how much of a paid reservation is refunded when
+// the customer cancels it
+type CancellationPolicy struct {
+	// FullRefundBefore is how long before the slot starts a cancellation is
+	// still refunded in full
+	FullRefundBefore time.Duration
+	// PartialRefundPercent is the share refunded for later cancellations made
+	// before the slot starts. Nothing is refunded once the slot has started.
+	PartialRefundPercent float64
+}
+
+// RefundAmount returns the refund for cancelling a reservation of the given
+// price, whose slot starts at slotStart, at now
+func (p CancellationPolicy) RefundAmount(price float64, slotStart, now time.Time) float64 {
+	untilStart := slotStart.Sub(now)
+	switch {
+	case untilStart >= p.FullRefundBefore:
+		return price
+	case untilStart > 0:
+		return math.Round(price * p.PartialRefundPercent / 100)
+	default:
+		return 0
+	}
+}
+
+// slotStart returns when the reservation's timeslot starts, in local time
+func slotStart(reservation *models.Reservation) (time.Time, error) {
+	clock, err := time.Parse("15:04", reservation.Timeslot.StartTime)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timeslot start time %q: %w", reservation.Timeslot.StartTime, err)
+	}
+
+	year, month, day := reservation.Date.Date()
+	return time.Date(year, month, day, clock.Hour(), clock.Minute(), 0, 0, time.Local), nil
+}
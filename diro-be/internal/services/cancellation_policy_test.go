@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"diro-be/internal/models"
+	"diro-be/internal/testdb"
+)
+
+func TestCancellationPolicyRefundAmount(t *testing.T) {
+	policy := CancellationPolicy{FullRefundBefore: 24 * time.Hour, PartialRefundPercent: 50}
+	start := time.Date(2030, 6, 1, 19, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name       string
+		untilStart time.Duration
+		want       float64
+	}{
+		{name: "well before the full refund window", untilStart: 72 * time.Hour, want: 75000},
+		{name: "exactly at the full refund window", untilStart: 24 * time.Hour, want: 75000},
+		{name: "just inside the partial refund window", untilStart: 24*time.Hour - time.Second, want: 37500},
+		{name: "just before the start", untilStart: time.Second, want: 37500},
+		{name: "exactly at the start", untilStart: 0, want: 0},
+		{name: "after the start", untilStart: -time.Hour, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.RefundAmount(75000, start, start.Add(-tt.untilStart)); got != tt.want {
+				t.Fatalf("RefundAmount() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCancellationPolicyRoundsPartialRefund(t *testing.T) {
+	policy := CancellationPolicy{FullRefundBefore: 24 * time.Hour, PartialRefundPercent: 33}
+	start := time.Date(2030, 6, 1, 19, 0, 0, 0, time.Local)
+
+	if got := policy.RefundAmount(50001, start, start.Add(-time.Hour)); got != 16500 {
+		t.Fatalf("RefundAmount() = %v, want 16500", got)
+	}
+}
+
+// payTestReservation pays the invoice of a booked reservation at the gateway
+// and delivers the PAID callback
+func payTestReservation(t *testing.T, service *ReservationService, gateway *FakePaymentGateway, reservation *models.Reservation) {
+	t.Helper()
+
+	if gateway != nil {
+		if _, err := gateway.setStatus(reservation.PaymentID, models.PaymentStatusPaid); err != nil {
+			t.Fatalf("failed to pay invoice: %v", err)
+		}
+	}
+	paid := invoiceWebhook(reservation, models.PaymentStatusPaid, int(reservation.TotalPrice))
+	if err := service.HandleInvoiceWebhook(context.Background(), reservation.ID, paid); err != nil {
+		t.Fatalf("HandleInvoiceWebhook() error = %v", err)
+	}
+}
+
+func TestCancelPaidReservationRefunds(t *testing.T) {
+	db := testdb.Open(t)
+	gateway := newTestGateway()
+	service := newTestReservationService(t, db, gateway)
+	reservation := bookTestSlot(t, db, service)
+	payTestReservation(t, service, gateway, reservation)
+
+	cancelled, err := service.CancelReservation(context.Background(), 1, models.UserRoleAdmin, reservation.ID, "")
+	if err != nil {
+		t.Fatalf("CancelReservation() error = %v", err)
+	}
+	if cancelled.Status != models.ReservationStatusCancelled || cancelled.RefundAmount != reservation.TotalPrice {
+		t.Fatalf("reservation is %q with %v refunded, want cancelled with %v refunded", cancelled.Status, cancelled.RefundAmount, reservation.TotalPrice)
+	}
+
+	refunds, err := service.refundRepo.ListReservationRefunds(context.Background(), reservation.ID)
+	if err != nil {
+		t.Fatalf("ListReservationRefunds() error = %v", err)
+	}
+	if len(refunds) != 1 || refunds[0].Status != models.RefundStatusSucceeded || refunds[0].Amount != reservation.TotalPrice {
+		t.Fatalf("refunds = %+v, want one succeeded refund of %v", refunds, reservation.TotalPrice)
+	}
+	if status := reservationStatus(t, service, reservation.ID); status != models.ReservationStatusCancelled {
+		t.Fatalf("stored status = %q, want cancelled", status)
+	}
+}
+
+func TestCancelPaidReservationRefundRejected(t *testing.T) {
+	db := testdb.Open(t)
+	gateway := newTestGateway()
+	service := newTestReservationService(t, db, gateway)
+	reservation := bookTestSlot(t, db, service)
+	// Paid by callback only, so the gateway refuses to refund the invoice
+	payTestReservation(t, service, nil, reservation)
+
+	if _, err := service.CancelReservation(context.Background(), 1, models.UserRoleAdmin, reservation.ID, ""); !errors.Is(err, ErrGatewayFailure) {
+		t.Fatalf("CancelReservation() error = %v, want ErrGatewayFailure", err)
+	}
+
+	if status := reservationStatus(t, service, reservation.ID); status != models.ReservationStatusPaid {
+		t.Fatalf("status = %q after the refund was rejected, want paid", status)
+	}
+	refunds, err := service.refundRepo.ListReservationRefunds(context.Background(), reservation.ID)
+	if err != nil {
+		t.Fatalf("ListReservationRefunds() error = %v", err)
+	}
+	if len(refunds) != 0 {
+		t.Fatalf("%d refunds recorded for a refund the gateway rejected, want none", len(refunds))
+	}
+	if count := historyCount(t, service, reservation.ID); count != 2 {
+		t.Fatalf("%d status changes recorded, want booking and payment only", count)
+	}
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"diro-be/internal/models"
+	"diro-be/internal/repositories"
+)
+
+// ErrCourtNotFound is returned when a court does not exist or was deleted
+var ErrCourtNotFound = errors.New("court not found")
+
+// ErrInvalidCourt is returned when court details fail validation
+var ErrInvalidCourt = errors.New("invalid court")
+
+// CourtInUseError is returned when deactivating or deleting a court that
+// still has paid reservations in the future
+type CourtInUseError struct {
+	CourtID      uint
+	Reservations []models.Reservation
+}
+
+func (e *CourtInUseError) Error() string {
+	return fmt.Sprintf("court %d has %d future paid reservations", e.CourtID, len(e.Reservations))
+}
+
+// CourtService handles court management by venue staff
+type CourtService struct {
+	courtRepo       *repositories.CourtRepository
+	reservationRepo *repositories.ReservationRepository
+}
+
+// NewCourtService creates a new court service
+func NewCourtService(courtRepo *repositories.CourtRepository, reservationRepo *repositories.ReservationRepository) *CourtService {
+	return &CourtService{
+		courtRepo:       courtRepo,
+		reservationRepo: reservationRepo,
+	}
+}
+
+// ListCourts returns every court that is not deleted, active or not
+func (s *CourtService) ListCourts(ctx context.Context) ([]models.Court, error) {
+	return s.courtRepo.ListCourts(ctx)
+}
+
+// GetCourt returns a court by ID
+func (s *CourtService) GetCourt(ctx context.Context, id uint) (*models.Court, error) {
+	court, err := s.courtRepo.GetCourtByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCourtNotFound
+	}
+	return court, err
+}
+
+// CreateCourt creates a new active court
+func (s *CourtService) CreateCourt(ctx context.Context, name, description string) (*models.Court, error) {
+	court := &models.Court{
+		Name:        strings.TrimSpace(name),
+		Description: strings.TrimSpace(description),
+		IsActive:    true,
+	}
+	if err := validateCourt(court); err != nil {
+		return nil, err
+	}
+
+	if err := s.courtRepo.CreateCourt(ctx, court); err != nil {
+		return nil, err
+	}
+	return court, nil
+}
+
+// UpdateCourt changes the name and/or description of a court. Nil values are
+// left unchanged.
+func (s *CourtService) UpdateCourt(ctx context.Context, id uint, name, description *string) (*models.Court, error) {
+	court, err := s.GetCourt(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if name != nil {
+		court.Name = strings.TrimSpace(*name)
+	}
+	if description != nil {
+		court.Description = strings.TrimSpace(*description)
+	}
+	if err := validateCourt(court); err != nil {
+		return nil, err
+	}
+
+	if err := s.courtRepo.UpdateCourt(ctx, court); err != nil {
+		return nil, err
+	}
+	return court, nil
+}
+
+// SetCourtActive activates or deactivates a court. Deactivating a court with
+// future paid reservations fails with a CourtInUseError unless force is set,
+// in which case the court is deactivated and the affected reservations are
+// returned so staff can contact those customers.
+func (s *CourtService) SetCourtActive(ctx context.Context, id uint, active, force bool) (*models.Court, []models.Reservation, error) {
+	court, err := s.GetCourt(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var affected []models.Reservation
+	if !active {
+		if affected, err = s.checkFutureReservations(ctx, court.ID, force); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	court.IsActive = active
+	if err := s.courtRepo.UpdateCourt(ctx, court); err != nil {
+		return nil, nil, err
+	}
+	return court, affected, nil
+}
+
+// DeleteCourt soft deletes a court, with the same future reservation check
+// as deactivating it
+func (s *CourtService) DeleteCourt(ctx context.Context, id uint, force bool) ([]models.Reservation, error) {
+	court, err := s.GetCourt(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	affected, err := s.checkFutureReservations(ctx, court.ID, force)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.courtRepo.DeleteCourt(ctx, court.ID); err != nil {
+		return nil, err
+	}
+	return affected, nil
+}
+
+// checkFutureReservations returns the court's future paid reservations, or a
+// CourtInUseError if there are any and force is not set
+func (s *CourtService) checkFutureReservations(ctx context.Context, courtID uint, force bool) ([]models.Reservation, error) {
+	reservations, err := s.reservationRepo.FindFuturePaidReservations(ctx, courtID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if len(reservations) > 0 && !force {
+		return nil, &CourtInUseError{CourtID: courtID, Reservations: reservations}
+	}
+	return reservations, nil
+}
+
+func validateCourt(court *models.Court) error {
+	if court.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidCourt)
+	}
+	if len(court.Name) > 255 {
+		return fmt.Errorf("%w: name must be at most 255 characters", ErrInvalidCourt)
+	}
+	if len(court.Description) > 1000 {
+		return fmt.Errorf("%w: description must be at most 1000 characters", ErrInvalidCourt)
+	}
+	return nil
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"diro-be/internal/logging"
+	"diro-be/internal/models"
+)
+
+// FakePaymentGateway is an in-memory payment gateway for running the booking
+// flow offline. Its invoice URLs point back at this server, and paying or
+// expiring an invoice delivers the same webhook Xendit would send.
+type FakePaymentGateway struct {
+	baseURL       string
+	webhookURL    string
+	callbackToken string
+	settings      PaymentSettings
+	client        *http.Client
+	idPrefix      string // Unique per gateway, so invoice IDs are not reused across restarts
+
+	mu       sync.Mutex
+	nextID   int
+	invoices map[string]*models.XenditInvoiceResponse
+	refunds  map[string]*models.XenditRefundResponse // By reference ID
+}
+
+var _ PaymentGateway = (*FakePaymentGateway)(nil)
+
+// NewFakePaymentGateway creates a fake gateway for the server reachable at
+// baseURL, signing its webhooks with callbackToken like Xendit does. Only the
+// currency, invoice duration, URLs and timeout of the settings are used; the
+// timeout bounds webhook deliveries.
+func NewFakePaymentGateway(baseURL, callbackToken string, settings PaymentSettings) *FakePaymentGateway {
+	return &FakePaymentGateway{
+		baseURL:       baseURL,
+		webhookURL:    baseURL + "/api/v1/webhooks/xendit",
+		callbackToken: callbackToken,
+		idPrefix:      "fake-inv-" + strconv.FormatInt(time.Now().UnixNano(), 36) + "-",
+		settings:      settings,
+		client:        &http.Client{Timeout: settings.Timeout},
+		invoices:      make(map[string]*models.XenditInvoiceResponse),
+		refunds:       make(map[string]*models.XenditRefundResponse),
+	}
+}
+
+// CreateInvoice creates a pending invoice payable through the fake gateway routes
+func (g *FakePaymentGateway) CreateInvoice(ctx context.Context, reservations []models.Reservation, customer models.XenditCustomer) (*models.XenditInvoiceResponse, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.nextID++
+	id := g.idPrefix + strconv.Itoa(g.nextID)
+	now := time.Now().UTC()
+
+	successURL, failureURL := g.settings.redirectURLs(reservations)
+	invoice := &models.XenditInvoiceResponse{
+		ID:                 id,
+		ExternalID:         strconv.Itoa(int(reservations[0].ID)),
+		Status:             "PENDING",
+		MerchantName:       "Diro (fake gateway)",
+		Amount:             invoiceAmount(reservations),
+		Description:        invoiceDescription(reservations),
+		ExpiryDate:         now.Add(g.settings.InvoiceDuration).Format(time.RFC3339),
+		InvoiceURL:         g.baseURL + "/api/v1/fake-gateway/invoices/" + id,
+		SuccessRedirectURL: successURL,
+		FailureRedirectURL: failureURL,
+		Created:            now.Format(time.RFC3339),
+		Updated:            now.Format(time.RFC3339),
+		Currency:           g.settings.Currency,
+		Items:              invoiceItems(reservations, g.settings.ItemURL),
+		Fees:               invoiceFees(reservations),
+		Customer:           customer,
+	}
+	g.invoices[id] = invoice
+
+	copied := *invoice
+	return &copied, nil
+}
+
+// GetInvoice retrieves an invoice created by the fake gateway
+func (g *FakePaymentGateway) GetInvoice(ctx context.Context, invoiceID string) (*models.XenditInvoiceResponse, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	invoice, ok := g.invoices[invoiceID]
+	if !ok {
+		return nil, ErrInvoiceNotFound
+	}
+
+	copied := *invoice
+	return &copied, nil
+}
+
+// ListInvoices lists the invoices the fake gateway created from from until to
+func (g *FakePaymentGateway) ListInvoices(ctx context.Context, from, to time.Time) ([]models.XenditInvoiceResponse, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var invoices []models.XenditInvoiceResponse
+	for _, invoice := range g.invoices {
+		if invoiceCreatedIn(invoice, from, to) {
+			invoices = append(invoices, *invoice)
+		}
+	}
+	sort.Slice(invoices, func(i, j int) bool {
+		return invoices[i].Created < invoices[j].Created
+	})
+	return invoices, nil
+}
+
+// ExpireInvoice expires a pending invoice
+func (g *FakePaymentGateway) ExpireInvoice(ctx context.Context, invoiceID string) (*models.XenditInvoiceResponse, error) {
+	return g.setStatus(invoiceID, "EXPIRED")
+}
+
+// Refund records a refund of a paid invoice, which always succeeds as long as
+// the refunds of the invoice do not exceed its amount. Repeating a reference
+// ID returns the refund made for it before, like Xendit does.
+func (g *FakePaymentGateway) Refund(ctx context.Context, request models.XenditRefundRequest) (*models.XenditRefundResponse, error) {
+	invoice, err := g.GetInvoice(ctx, request.InvoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.Status != "PAID" && invoice.Status != "SETTLED" {
+		return nil, fmt.Errorf("invoice %s is not paid", request.InvoiceID)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if refund, ok := g.refunds[request.ReferenceID]; ok {
+		copied := *refund
+		return &copied, nil
+	}
+
+	refunded := request.Amount
+	for _, refund := range g.refunds {
+		if refund.InvoiceID == request.InvoiceID {
+			refunded += refund.Amount
+		}
+	}
+	if refunded > invoice.Amount {
+		return nil, fmt.Errorf("refund amount exceeds invoice amount")
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	refund := &models.XenditRefundResponse{
+		ID:          "fake-refund-" + request.ReferenceID,
+		InvoiceID:   request.InvoiceID,
+		ReferenceID: request.ReferenceID,
+		Amount:      request.Amount,
+		Currency:    invoice.Currency,
+		Status:      "SUCCEEDED",
+		Reason:      request.Reason,
+		Created:     now,
+		Updated:     now,
+		Metadata:    request.Metadata,
+	}
+	g.refunds[request.ReferenceID] = refund
+
+	copied := *refund
+	return &copied, nil
+}
+
+// SimulatePayment marks an invoice as paid and delivers the PAID webhook
+func (g *FakePaymentGateway) SimulatePayment(ctx context.Context, invoiceID string) error {
+	invoice, err := g.setStatus(invoiceID, "PAID")
+	if err != nil {
+		return err
+	}
+	return g.sendWebhook(ctx, invoice)
+}
+
+// SimulateExpiry expires an invoice and delivers the EXPIRED webhook
+func (g *FakePaymentGateway) SimulateExpiry(ctx context.Context, invoiceID string) error {
+	invoice, err := g.setStatus(invoiceID, "EXPIRED")
+	if err != nil {
+		return err
+	}
+	return g.sendWebhook(ctx, invoice)
+}
+
+// setStatus moves a pending invoice to the given status
+func (g *FakePaymentGateway) setStatus(invoiceID, status string) (*models.XenditInvoiceResponse, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	invoice, ok := g.invoices[invoiceID]
+	if !ok {
+		return nil, ErrInvoiceNotFound
+	}
+	if invoice.Status != "PENDING" {
+		return nil, fmt.Errorf("invoice %s is already %s", invoiceID, invoice.Status)
+	}
+
+	invoice.Status = status
+	invoice.Updated = time.Now().UTC().Format(time.RFC3339)
+
+	copied := *invoice
+	return &copied, nil
+}
+
+// sendWebhook posts an invoice callback to this server's Xendit webhook. It
+// carries the request ID of ctx, so the webhook is logged under the request
+// that paid or expired the invoice.
+func (g *FakePaymentGateway) sendWebhook(ctx context.Context, invoice *models.XenditInvoiceResponse) error {
+	payload := models.XenditWebhookPayload{
+		ID:          invoice.ID,
+		Amount:      int(invoice.Amount),
+		Status:      invoice.Status,
+		Created:     invoice.Created,
+		Updated:     invoice.Updated,
+		Currency:    invoice.Currency,
+		Description: invoice.Description,
+		ExternalID:  invoice.ExternalID,
+	}
+	if invoice.Status == "PAID" {
+		payload.PaidAt = &invoice.Updated
+		payload.PaidAmount = int(invoice.Amount)
+		payload.PaymentMethod = "FAKE"
+		payload.PaymentChannel = "FAKE"
+	}
+
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.webhookURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-callback-token", g.callbackToken)
+	if id := logging.RequestID(ctx); id != "" {
+		req.Header.Set(logging.RequestIDHeader, id)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("webhook rejected with status %d", resp.StatusCode)
+	}
+	return nil
+}
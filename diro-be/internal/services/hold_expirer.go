@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"diro-be/internal/logging"
+)
+
+// HoldExpirer periodically reconciles reservations whose invoice expired with
+// the payment gateway, releasing the slots that were not paid, and expires
+// lapsed waitlist offers
+type HoldExpirer struct {
+	reservationService *ReservationService
+	interval           time.Duration
+	timeout            time.Duration
+}
+
+// NewHoldExpirer creates a new hold expirer running every interval, each run
+// abandoned after timeout
+func NewHoldExpirer(reservationService *ReservationService, interval, timeout time.Duration) *HoldExpirer {
+	return &HoldExpirer{
+		reservationService: reservationService,
+		interval:           interval,
+		timeout:            timeout,
+	}
+}
+
+// Start runs the expirer in the background until ctx is cancelled. Each run
+// logs under a request ID of its own.
+func (e *HoldExpirer) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(e.interval)
+		defer ticker.Stop()
+
+		for {
+			runCtx, cancel := context.WithTimeout(logging.WithRequestID(ctx, "expirer-"+logging.NewCorrelationID()), e.timeout)
+			e.RunOnce(runCtx, time.Now())
+			cancel()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// RunOnce reconciles every hold and expires every waitlist offer that lapsed
+// before now
+func (e *HoldExpirer) RunOnce(ctx context.Context, now time.Time) {
+	summary, err := e.reservationService.SweepLapsedHolds(ctx, now)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to sweep lapsed slot holds", "error", err.Error())
+	}
+	if summary != nil && summary.Checked > 0 {
+		slog.InfoContext(ctx, "invoice sweep", "summary", *summary)
+	}
+
+	lapsed, err := e.reservationService.ExpireLapsedOffers(ctx, now)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to expire waitlist offers", "error", err.Error())
+	}
+	if lapsed > 0 {
+		slog.InfoContext(ctx, "expired lapsed waitlist offers", "count", lapsed)
+	}
+}
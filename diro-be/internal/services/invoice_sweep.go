@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"diro-be/internal/models"
+	"diro-be/internal/repositories"
+)
+
+// SweepSummary counts what a sweep of lapsed slot holds changed
+type SweepSummary struct {
+	Checked       int // Pending reservations whose hold lapsed
+	Paid          int // Paid at the gateway although no webhook arrived
+	PaidTooLate   int // Paid after another booking took the released slot, left pending to be refunded
+	Expired       int
+	PaymentFailed int
+	Skipped       int // Left pending because the gateway could not be queried
+}
+
+func (s SweepSummary) String() string {
+	return fmt.Sprintf("checked %d lapsed holds: %d paid, %d paid too late, %d expired, %d failed, %d skipped",
+		s.Checked, s.Paid, s.PaidTooLate, s.Expired, s.PaymentFailed, s.Skipped)
+}
+
+// LogValue logs the counts of the sweep as a group
+func (s SweepSummary) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int("checked", s.Checked),
+		slog.Int("paid", s.Paid),
+		slog.Int("paid_too_late", s.PaidTooLate),
+		slog.Int("expired", s.Expired),
+		slog.Int("failed", s.PaymentFailed),
+		slog.Int("skipped", s.Skipped))
+}
+
+// SweepLapsedHolds reconciles the pending reservations whose invoice expired
+// before now, or whose hold a booking released, with the real invoice status
+// at the payment gateway, in case its webhook was lost. Reservations paid in
+// the meantime are marked paid and the rest expired, releasing their slots.
+func (s *ReservationService) SweepLapsedHolds(ctx context.Context, now time.Time) (*SweepSummary, error) {
+	lapsed, err := s.reservationRepo.FindLapsedHolds(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &SweepSummary{Checked: len(lapsed)}
+	for _, holds := range groupByPayment(lapsed) {
+		to, tooLate, err := s.reconcileHolds(ctx, holds)
+		if errors.Is(err, ErrGatewayFailure) {
+			slog.WarnContext(ctx, "skipped reconciling invoice", "invoice_id", holds[0].PaymentID, "error", err.Error())
+			summary.Skipped += len(holds)
+			continue
+		}
+		if err != nil {
+			return summary, err
+		}
+
+		switch to {
+		case models.ReservationStatusPaid:
+			slog.InfoContext(ctx, "invoice was paid without a webhook, marked its reservations paid",
+				"invoice_id", holds[0].PaymentID, "reservation_ids", reservationIDs(holds))
+			summary.Paid += len(holds) - tooLate
+			summary.PaidTooLate += tooLate
+		case models.ReservationStatusFailed:
+			summary.PaymentFailed += len(holds)
+		default:
+			summary.Expired += len(holds)
+		}
+	}
+	return summary, nil
+}
+
+// reconcileHolds moves lapsed holds billed on one invoice to the status of
+// the invoice at the gateway and returns that status, with the number of
+// paid holds whose slot was booked by someone else after it was released.
+// An invoice that is still payable is expired first, so a late payment cannot
+// book the slot.
+func (s *ReservationService) reconcileHolds(ctx context.Context, holds []models.Reservation) (models.ReservationStatus, int, error) {
+	paymentStatus := models.PaymentStatusExpired
+	if paymentID := holds[0].PaymentID; paymentID != "" {
+		invoice, err := s.paymentGateway.GetInvoice(ctx, paymentID)
+		switch {
+		case errors.Is(err, ErrInvoiceNotFound):
+			// The gateway has no such invoice, so it can never be paid
+		case err != nil:
+			return "", 0, fmt.Errorf("%w: %w", ErrGatewayFailure, err)
+		default:
+			if _, ok := statusForPayment(invoice.Status); ok {
+				paymentStatus = invoice.Status
+			} else if _, err := s.paymentGateway.ExpireInvoice(ctx, paymentID); err != nil {
+				return "", 0, fmt.Errorf("%w: %w", ErrGatewayFailure, err)
+			}
+		}
+	}
+
+	// The gateway has settled the invoice, so its holds are updated even if
+	// the sweep runs out of time meanwhile
+	ctx = context.WithoutCancel(ctx)
+	tooLate := 0
+	for i := range holds {
+		err := s.UpdatePaymentStatus(ctx, holds[i].ID, paymentStatus, TriggerInvoiceSweep, ActorSystem)
+		if errors.Is(err, repositories.ErrSlotTaken) {
+			// Paid after a booking released the hold and took the slot. Keep
+			// the payment on record so it can be refunded.
+			slog.WarnContext(ctx, "invoice was paid after the slot of a released hold was booked again",
+				"invoice_id", holds[i].PaymentID, "reservation_id", holds[i].ID)
+			holds[i].PaymentStatus = paymentStatus
+			if err := s.reservationRepo.SaveTransition(ctx, &holds[i], nil, nil); err != nil {
+				return "", 0, err
+			}
+			tooLate++
+			continue
+		}
+		if err != nil && !errors.Is(err, repositories.ErrStaleReservation) {
+			return "", 0, err
+		}
+	}
+
+	to, _ := statusForPayment(paymentStatus)
+	return to, tooLate, nil
+}
+
+// reservationIDs lists the IDs of reservations, for logging
+func reservationIDs(reservations []models.Reservation) []uint {
+	ids := make([]uint, 0, len(reservations))
+	for _, reservation := range reservations {
+		ids = append(ids, reservation.ID)
+	}
+	return ids
+}
+
+// groupByPayment groups reservations billed on the same invoice, keeping the
+// order they were found in. Reservations without an invoice are grouped alone.
+func groupByPayment(reservations []models.Reservation) [][]models.Reservation {
+	var groups [][]models.Reservation
+	index := make(map[string]int)
+	for _, reservation := range reservations {
+		if i, ok := index[reservation.PaymentID]; ok && reservation.PaymentID != "" {
+			groups[i] = append(groups[i], reservation)
+			continue
+		}
+		index[reservation.PaymentID] = len(groups)
+		groups = append(groups, []models.Reservation{reservation})
+	}
+	return groups
+}
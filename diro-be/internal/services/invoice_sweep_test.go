@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"diro-be/internal/models"
+	"diro-be/internal/testdb"
+)
+
+// lapseHold moves the hold of a reservation into the past
+func lapseHold(t *testing.T, service *ReservationService, reservation *models.Reservation) {
+	t.Helper()
+
+	lapsed := time.Now().Add(-time.Minute)
+	reservation.HoldExpiresAt = &lapsed
+	if err := service.reservationRepo.UpdateReservation(context.Background(), reservation); err != nil {
+		t.Fatalf("UpdateReservation() error = %v", err)
+	}
+}
+
+// invoiceStatus returns the status of an invoice at the fake gateway
+func invoiceStatus(t *testing.T, gateway *FakePaymentGateway, invoiceID string) string {
+	t.Helper()
+
+	invoice, err := gateway.GetInvoice(context.Background(), invoiceID)
+	if err != nil {
+		t.Fatalf("GetInvoice() error = %v", err)
+	}
+	return invoice.Status
+}
+
+func TestSweepLapsedHolds(t *testing.T) {
+	db := testdb.Open(t)
+	gateway := newTestGateway()
+	service := newTestReservationService(t, db, gateway)
+
+	paid := bookTestSlot(t, db, service)
+	unpaid := bookTestSlot(t, db, service)
+	// Paid at the gateway, but its webhook never arrived
+	if _, err := gateway.setStatus(paid.PaymentID, models.PaymentStatusPaid); err != nil {
+		t.Fatalf("failed to pay invoice: %v", err)
+	}
+
+	// Later than the invoices expire, sooner than the holds of other tests
+	summary, err := service.SweepLapsedHolds(context.Background(), time.Now().Add(20*time.Minute))
+	if err != nil {
+		t.Fatalf("SweepLapsedHolds() error = %v", err)
+	}
+	if summary.Paid < 1 || summary.Expired < 1 {
+		t.Fatalf("summary = %v, want at least one paid and one expired", summary)
+	}
+
+	if status := reservationStatus(t, service, paid.ID); status != models.ReservationStatusPaid {
+		t.Fatalf("reservation paid at the gateway is %q, want paid", status)
+	}
+	if status := reservationStatus(t, service, unpaid.ID); status != models.ReservationStatusExpired {
+		t.Fatalf("unpaid reservation is %q, want expired", status)
+	}
+	if status := invoiceStatus(t, gateway, unpaid.PaymentID); status != models.PaymentStatusExpired {
+		t.Fatalf("unpaid invoice is %s at the gateway, want EXPIRED", status)
+	}
+}
+
+func TestBookingReleasesLapsedHoldLocally(t *testing.T) {
+	db := testdb.Open(t)
+	fake := newTestGateway()
+	gateway := &recordingGateway{PaymentGateway: fake}
+	service := newTestReservationService(t, db, gateway)
+	ctx := context.Background()
+
+	lapsed := bookTestSlot(t, db, service)
+	lapseHold(t, service, lapsed)
+
+	rebooked, _, err := service.CreateReservation(ctx, nil, lapsed.CourtID, lapsed.TimeslotID, lapsed.Date, testCustomer, "")
+	if err != nil {
+		t.Fatalf("CreateReservation() of a slot whose hold lapsed error = %v", err)
+	}
+	if calls := gateway.called("GetInvoice "+lapsed.PaymentID) + gateway.called("ExpireInvoice "+lapsed.PaymentID); calls != 0 {
+		t.Fatalf("booking made %d gateway calls for the lapsed invoice, want none", calls)
+	}
+
+	released, err := service.reservationRepo.GetReservationByID(ctx, lapsed.ID)
+	if err != nil {
+		t.Fatalf("GetReservationByID() error = %v", err)
+	}
+	if released.Status != models.ReservationStatusPending || released.HoldExpiresAt != nil || released.SlotKey != nil {
+		t.Fatalf("lapsed reservation is %q holding until %v, want pending without a hold", released.Status, released.HoldExpiresAt)
+	}
+
+	// The sweep settles the released reservation's invoice
+	if _, err := service.SweepLapsedHolds(ctx, time.Now()); err != nil {
+		t.Fatalf("SweepLapsedHolds() error = %v", err)
+	}
+	if status := reservationStatus(t, service, lapsed.ID); status != models.ReservationStatusExpired {
+		t.Fatalf("released reservation is %q after the sweep, want expired", status)
+	}
+	if gateway.called("ExpireInvoice "+lapsed.PaymentID) != 1 {
+		t.Fatal("sweep did not expire the released reservation's invoice")
+	}
+	if status := reservationStatus(t, service, rebooked.ID); status != models.ReservationStatusPending {
+		t.Fatalf("new booking is %q after the sweep, want pending", status)
+	}
+}
+
+func TestSweepPaidAfterSlotRebooked(t *testing.T) {
+	db := testdb.Open(t)
+	gateway := newTestGateway()
+	service := newTestReservationService(t, db, gateway)
+	ctx := context.Background()
+
+	lapsed := bookTestSlot(t, db, service)
+	lapseHold(t, service, lapsed)
+	if _, _, err := service.CreateReservation(ctx, nil, lapsed.CourtID, lapsed.TimeslotID, lapsed.Date, testCustomer, ""); err != nil {
+		t.Fatalf("CreateReservation() error = %v", err)
+	}
+	// Paid late, with its webhook lost
+	if _, err := gateway.setStatus(lapsed.PaymentID, models.PaymentStatusPaid); err != nil {
+		t.Fatalf("failed to pay invoice: %v", err)
+	}
+
+	summary, err := service.SweepLapsedHolds(ctx, time.Now())
+	if err != nil {
+		t.Fatalf("SweepLapsedHolds() error = %v", err)
+	}
+	if summary.PaidTooLate < 1 {
+		t.Fatalf("summary = %v, want the late payment counted", summary)
+	}
+
+	reservation, err := service.reservationRepo.GetReservationByID(ctx, lapsed.ID)
+	if err != nil {
+		t.Fatalf("GetReservationByID() error = %v", err)
+	}
+	if reservation.Status != models.ReservationStatusPending || reservation.PaymentStatus != models.PaymentStatusPaid {
+		t.Fatalf("late paid reservation is %q with payment %s, want pending with PAID kept for a refund", reservation.Status, reservation.PaymentStatus)
+	}
+
+	// Not picked up again by the next sweep
+	lapsedHolds, err := service.reservationRepo.FindLapsedHolds(ctx, time.Now())
+	if err != nil {
+		t.Fatalf("FindLapsedHolds() error = %v", err)
+	}
+	for _, hold := range lapsedHolds {
+		if hold.ID == lapsed.ID {
+			t.Fatal("late paid reservation is still listed as a lapsed hold")
+		}
+	}
+}
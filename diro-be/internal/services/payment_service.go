@@ -2,98 +2,321 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"math"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
+	"diro-be/internal/config"
+	"diro-be/internal/logging"
 	"diro-be/internal/models"
 )
 
-// PaymentService handles payment processing
+// ErrInvoiceNotFound is returned when the gateway has no invoice with the ID
+var ErrInvoiceNotFound = errors.New("invoice not found")
+
+// invoicePageSize is how many invoices are requested per page when listing
+const invoicePageSize = 100
+
+// PaymentSettings configures the invoices a payment gateway issues
+type PaymentSettings struct {
+	BaseURL         string        // Xendit API URL
+	APIVersion      string        // Sent as the X-API-VERSION header
+	Currency        string        // ISO 4217 code
+	InvoiceDuration time.Duration // How long invoices stay payable, the same as the slot hold
+	SuccessURL      string        // Redirect after paying, with {reservation_id} replaced
+	FailureURL      string        // Redirect after a failed payment, with {reservation_id} replaced
+	ItemURL         string        // Linked from every invoice item
+	Timeout         time.Duration // Deadline of each gateway call
+}
+
+// redirectURLs returns the success and failure redirect URLs of an invoice
+// for reservations booked together
+func (p PaymentSettings) redirectURLs(reservations []models.Reservation) (string, string) {
+	id := strconv.Itoa(int(reservations[0].ID))
+	return strings.ReplaceAll(p.SuccessURL, config.ReservationIDPlaceholder, id),
+		strings.ReplaceAll(p.FailureURL, config.ReservationIDPlaceholder, id)
+}
+
+// PaymentGateway is a payment provider that bills reservations through invoices
+type PaymentGateway interface {
+	// CreateInvoice creates one payment invoice for reservations booked
+	// together. The first reservation's ID is the invoice external ID.
+	CreateInvoice(ctx context.Context, reservations []models.Reservation, customer models.XenditCustomer) (*models.XenditInvoiceResponse, error)
+	// GetInvoice retrieves the current state of an invoice, or returns
+	// ErrInvoiceNotFound if the gateway does not know it
+	GetInvoice(ctx context.Context, invoiceID string) (*models.XenditInvoiceResponse, error)
+	// ExpireInvoice expires an unpaid invoice so it can no longer be paid
+	ExpireInvoice(ctx context.Context, invoiceID string) (*models.XenditInvoiceResponse, error)
+	// Refund requests a full or partial refund of a paid invoice
+	Refund(ctx context.Context, request models.XenditRefundRequest) (*models.XenditRefundResponse, error)
+	// ListInvoices lists the invoices created from from until to
+	ListInvoices(ctx context.Context, from, to time.Time) ([]models.XenditInvoiceResponse, error)
+}
+
+// GatewayError is returned when Xendit rejects a request. Its response body
+// may echo request data, so it is only logged under CorrelationID.
+type GatewayError struct {
+	StatusCode    int
+	CorrelationID string
+}
+
+func (e *GatewayError) Error() string {
+	return fmt.Sprintf("xendit API returned status %d (correlation id %s)", e.StatusCode, e.CorrelationID)
+}
+
+// PaymentService handles payment processing through Xendit
 type PaymentService struct {
 	xenditUsername string
 	xenditPassword string
-	xenditBaseURL  string
+	settings       PaymentSettings
+	client         *http.Client
 }
 
-// NewPaymentService creates a new payment service
-func NewPaymentService(username, password string) *PaymentService {
+var _ PaymentGateway = (*PaymentService)(nil)
+
+// NewPaymentService creates a new payment service issuing invoices with the
+// given settings. The credentials are registered as secrets so they are never
+// logged, not even encoded in an Authorization header.
+func NewPaymentService(username, password string, settings PaymentSettings) *PaymentService {
+	logging.RegisterSecrets(username, password, basicAuth(username, password))
 	return &PaymentService{
 		xenditUsername: username,
 		xenditPassword: password,
-		xenditBaseURL:  "https://api.xendit.co",
+		settings:       settings,
+		client:         &http.Client{Timeout: settings.Timeout},
 	}
 }
 
 // CreateInvoice creates a payment invoice via Xendit
-func (s *PaymentService) CreateInvoice(reservation *models.Reservation, customer models.XenditCustomer) (*models.XenditInvoiceResponse, error) {
+func (s *PaymentService) CreateInvoice(ctx context.Context, reservations []models.Reservation, customer models.XenditCustomer) (*models.XenditInvoiceResponse, error) {
+	successURL, failureURL := s.settings.redirectURLs(reservations)
 	request := models.XenditInvoiceRequest{
-		ExternalID:         strconv.Itoa(int(reservation.ID)),
-		Amount:             reservation.TotalPrice,
-		Description:        fmt.Sprintf("Reservation for %s at %s", reservation.Court.Name, reservation.Date.Format("2006-01-02")),
-		InvoiceDuration:    86400, // 24 hours
+		ExternalID:         strconv.Itoa(int(reservations[0].ID)),
+		Amount:             invoiceAmount(reservations),
+		Description:        invoiceDescription(reservations),
+		InvoiceDuration:    int(s.settings.InvoiceDuration.Seconds()),
 		Customer:           customer,
-		SuccessRedirectURL: "http://localhost:3000/success",
-		FailureRedirectURL: "http://localhost:3000/failed",
-		Currency:           "IDR",
-		Items: []models.XenditInvoiceItem{
-			{
-				Name:     fmt.Sprintf("Court %s - %s to %s", reservation.Court.Name, reservation.Timeslot.StartTime, reservation.Timeslot.EndTime),
-				Quantity: 1,
-				Price:    reservation.TotalPrice,
-				Category: "Sports",
-				URL:      "http://localhost:3000",
-			},
-		},
-		Metadata: map[string]interface{}{
-			"reservation_id": reservation.ID,
-			"court_id":       reservation.CourtID,
-			"date":           reservation.Date.Format("2006-01-02"),
+		SuccessRedirectURL: successURL,
+		FailureRedirectURL: failureURL,
+		Currency:           s.settings.Currency,
+		Items:              invoiceItems(reservations, s.settings.ItemURL),
+		Fees:               invoiceFees(reservations),
+		Metadata:           invoiceMetadata(reservations),
+	}
+
+	var invoiceResp models.XenditInvoiceResponse
+	if err := s.doRequest(ctx, http.MethodPost, "/v2/invoices", nil, request, &invoiceResp); err != nil {
+		return nil, err
+	}
+
+	return &invoiceResp, nil
+}
+
+// invoiceAmount is the total billed for reservations booked together
+func invoiceAmount(reservations []models.Reservation) float64 {
+	var amount float64
+	for _, reservation := range reservations {
+		amount += reservation.TotalPrice
+	}
+	return amount
+}
+
+// amountUnits rounds an amount to the whole currency units the gateway
+// reports amounts in, so totals of fractional prices compare exactly
+func amountUnits(amount float64) int64 {
+	return int64(math.Round(amount))
+}
+
+// invoiceDescription describes the booked slots on the invoice
+func invoiceDescription(reservations []models.Reservation) string {
+	if len(reservations) == 1 {
+		return fmt.Sprintf("Reservation for %s at %s", reservations[0].Court.Name, reservations[0].Date.Format("2006-01-02"))
+	}
+	return fmt.Sprintf("Reservation for %d slots", len(reservations))
+}
+
+// invoiceItems lists each booked slot at its price before any discount,
+// linking each to itemURL
+func invoiceItems(reservations []models.Reservation, itemURL string) []models.XenditInvoiceItem {
+	items := make([]models.XenditInvoiceItem, 0, len(reservations))
+	for _, reservation := range reservations {
+		items = append(items, models.XenditInvoiceItem{
+			Name:     fmt.Sprintf("Court %s - %s %s to %s", reservation.Court.Name, reservation.Date.Format("2006-01-02"), reservation.Timeslot.StartTime, reservation.Timeslot.EndTime),
+			Quantity: 1,
+			Price:    reservation.TotalPrice + reservation.DiscountAmount,
+			Category: "Sports",
+			URL:      itemURL,
+		})
+	}
+	return items
+}
+
+// invoiceFees lists the promo code discount as a negative fee, so items and
+// fees add up to the amount billed
+func invoiceFees(reservations []models.Reservation) []models.XenditInvoiceFee {
+	var discount float64
+	for _, reservation := range reservations {
+		discount += reservation.DiscountAmount
+	}
+	if discount == 0 {
+		return nil
+	}
+	return []models.XenditInvoiceFee{
+		{
+			Type:  "Promo " + reservations[0].PromoCode,
+			Value: -discount,
 		},
 	}
+}
 
-	jsonData, err := json.Marshal(request)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+// invoiceMetadata identifies the reservations an invoice bills
+func invoiceMetadata(reservations []models.Reservation) map[string]interface{} {
+	ids := make([]uint, 0, len(reservations))
+	for _, reservation := range reservations {
+		ids = append(ids, reservation.ID)
+	}
+	return map[string]interface{}{
+		"reservation_id":  reservations[0].ID,
+		"reservation_ids": ids,
+		"court_id":        reservations[0].CourtID,
+		"date":            reservations[0].Date.Format("2006-01-02"),
+	}
+}
+
+// GetInvoice retrieves an invoice from Xendit. An invoice Xendit answers 404
+// for returns ErrInvoiceNotFound.
+func (s *PaymentService) GetInvoice(ctx context.Context, invoiceID string) (*models.XenditInvoiceResponse, error) {
+	var invoiceResp models.XenditInvoiceResponse
+	if err := s.doRequest(ctx, http.MethodGet, "/v2/invoices/"+url.PathEscape(invoiceID), nil, nil, &invoiceResp); err != nil {
+		var gatewayErr *GatewayError
+		if errors.As(err, &gatewayErr) && gatewayErr.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%w: %w", ErrInvoiceNotFound, err)
+		}
+		return nil, err
 	}
+	return &invoiceResp, nil
+}
+
+// ExpireInvoice expires an unpaid invoice so it can no longer be paid
+func (s *PaymentService) ExpireInvoice(ctx context.Context, invoiceID string) (*models.XenditInvoiceResponse, error) {
+	var invoiceResp models.XenditInvoiceResponse
+	if err := s.doRequest(ctx, http.MethodPost, "/invoices/"+url.PathEscape(invoiceID)+"/expire!", nil, nil, &invoiceResp); err != nil {
+		return nil, err
+	}
+	return &invoiceResp, nil
+}
+
+// ListInvoices lists the invoices created from from until to, following
+// Xendit's pagination
+func (s *PaymentService) ListInvoices(ctx context.Context, from, to time.Time) ([]models.XenditInvoiceResponse, error) {
+	var invoices []models.XenditInvoiceResponse
+	lastInvoiceID := ""
+	for {
+		query := url.Values{}
+		query.Set("created_after", from.UTC().Format(time.RFC3339))
+		query.Set("created_before", to.UTC().Format(time.RFC3339))
+		query.Set("limit", strconv.Itoa(invoicePageSize))
+		if lastInvoiceID != "" {
+			query.Set("last_invoice_id", lastInvoiceID)
+		}
 
-	req, err := http.NewRequest("POST", s.xenditBaseURL+"/v2/invoices", bytes.NewBuffer(jsonData))
+		var page []models.XenditInvoiceResponse
+		if err := s.doRequest(ctx, http.MethodGet, "/v2/invoices?"+query.Encode(), nil, nil, &page); err != nil {
+			return nil, err
+		}
+		invoices = append(invoices, page...)
+		if len(page) < invoicePageSize {
+			return invoices, nil
+		}
+		lastInvoiceID = page[len(page)-1].ID
+	}
+}
+
+// Refund requests a full or partial refund of a paid invoice
+func (s *PaymentService) Refund(ctx context.Context, request models.XenditRefundRequest) (*models.XenditRefundResponse, error) {
+	// Xendit answers a request repeating the key with the refund it created
+	// before, so a retry never refunds twice
+	header := http.Header{"Idempotency-Key": {request.ReferenceID}}
+	var refundResp models.XenditRefundResponse
+	if err := s.doRequest(ctx, http.MethodPost, "/refunds", header, request, &refundResp); err != nil {
+		return nil, err
+	}
+	return &refundResp, nil
+}
+
+// doRequest sends an authenticated request to the Xendit API and decodes the
+// JSON response into out, adding header to the request's headers. A nil
+// payload sends a request without a body. The call is abandoned when ctx is
+// done or the settings' timeout passes. Every call is logged with the request
+// ID of ctx, without its payload.
+func (s *PaymentService) doRequest(ctx context.Context, method, path string, header http.Header, payload interface{}, out interface{}) error {
+	if s.settings.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.settings.Timeout)
+		defer cancel()
+	}
+
+	var body io.Reader
+	if payload != nil {
+		jsonData, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewBuffer(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.settings.BaseURL+path, body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	auth := s.xenditUsername + ":" + s.xenditPassword
-	// log usernam and password
-
-	fmt.Printf("Username: %s, Password: %s\n", s.xenditUsername, s.xenditPassword)
-	encodedAuth := base64.StdEncoding.EncodeToString([]byte(auth))
-	req.Header.Set("Authorization", "Basic "+encodedAuth)
-	req.Header.Set("X-API-VERSION", "2020-02-01")
+	req.Header.Set("Authorization", "Basic "+basicAuth(s.xenditUsername, s.xenditPassword))
+	req.Header.Set("X-API-VERSION", s.settings.APIVersion)
+	for key, values := range header {
+		req.Header[key] = values
+	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	endpoint, _, _ := strings.Cut(path, "?")
+	start := time.Now()
+	resp, err := s.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		slog.ErrorContext(ctx, "xendit request failed", "method", method, "path", endpoint, "duration_ms", time.Since(start).Milliseconds(), "error", err.Error())
+		return fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
+	slog.InfoContext(ctx, "xendit request", "method", method, "path", endpoint, "status", resp.StatusCode, "duration_ms", time.Since(start).Milliseconds())
 
-	body, err := io.ReadAll(resp.Body)
+	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return fmt.Errorf("failed to read response: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("xendit API error: %s", string(body))
+		gatewayErr := &GatewayError{StatusCode: resp.StatusCode, CorrelationID: logging.NewCorrelationID()}
+		slog.ErrorContext(ctx, "xendit rejected request", "method", method, "path", endpoint, "status", resp.StatusCode,
+			"correlation_id", gatewayErr.CorrelationID, "response", string(respBody))
+		return gatewayErr
 	}
 
-	var invoiceResp models.XenditInvoiceResponse
-	if err := json.Unmarshal(body, &invoiceResp); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
-	return &invoiceResp, nil
+	return nil
+}
+
+// basicAuth encodes credentials for an HTTP Basic Authorization header
+func basicAuth(username, password string) string {
+	return base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
 }
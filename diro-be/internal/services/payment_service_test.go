@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"diro-be/internal/models"
+)
+
+// newTestPaymentService returns a payment service sending its requests to
+// handler, giving up on each after timeout
+func newTestPaymentService(t *testing.T, timeout time.Duration, handler http.HandlerFunc) *PaymentService {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewPaymentService("test-key", "", PaymentSettings{
+		BaseURL:         server.URL,
+		APIVersion:      "2020-02-01",
+		Currency:        "IDR",
+		InvoiceDuration: time.Hour,
+		Timeout:         timeout,
+	})
+}
+
+// testReservations returns a booking to invoice
+func testReservations() []models.Reservation {
+	return []models.Reservation{{
+		ID:         1,
+		CourtID:    1,
+		TimeslotID: 1,
+		Date:       time.Now().AddDate(0, 0, 1),
+		TotalPrice: 50000,
+		Court:      models.Court{ID: 1, Name: "A"},
+		Timeslot:   models.Timeslot{ID: 1, StartTime: "19:00", EndTime: "20:00"},
+	}}
+}
+
+// stalledGateway answers no request until the client gives up on it. The
+// body is read first, as the server only notices the client leaving then.
+func stalledGateway(w http.ResponseWriter, r *http.Request) {
+	io.Copy(io.Discard, r.Body)
+	<-r.Context().Done()
+}
+
+func TestGetInvoice(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		body         string
+		wantNotFound bool
+		wantErr      bool
+	}{
+		{name: "found", status: http.StatusOK, body: `{"id":"inv-1","status":"PAID"}`},
+		{name: "not found", status: http.StatusNotFound, body: `{"error_code":"INVOICE_NOT_FOUND_ERROR"}`, wantNotFound: true, wantErr: true},
+		{name: "server error", status: http.StatusInternalServerError, body: `{"error_code":"SERVER_ERROR"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newTestPaymentService(t, 5*time.Second, func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v2/invoices/inv-1" {
+					t.Errorf("request path = %q, want /v2/invoices/inv-1", r.URL.Path)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+
+			invoice, err := service.GetInvoice(context.Background(), "inv-1")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetInvoice() error = %v, want error %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrInvoiceNotFound) != tt.wantNotFound {
+				t.Fatalf("GetInvoice() error = %v, want ErrInvoiceNotFound %v", err, tt.wantNotFound)
+			}
+			if !tt.wantErr && invoice.Status != "PAID" {
+				t.Fatalf("GetInvoice() status = %q, want PAID", invoice.Status)
+			}
+		})
+	}
+}
+
+func TestCreateInvoiceWithCancelledContext(t *testing.T) {
+	var calls atomic.Int32
+	service := newTestPaymentService(t, 5*time.Second, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(`{"id":"inv-1"}`))
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := service.CreateInvoice(ctx, testReservations(), models.XenditCustomer{Email: "customer@example.com"})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("CreateInvoice() error = %v, want context.Canceled", err)
+	}
+	if calls.Load() != 0 {
+		t.Fatalf("gateway received %d requests, want none", calls.Load())
+	}
+}
+
+func TestCreateInvoiceWithExpiredDeadline(t *testing.T) {
+	service := newTestPaymentService(t, 5*time.Second, stalledGateway)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := service.CreateInvoice(ctx, testReservations(), models.XenditCustomer{Email: "customer@example.com"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("CreateInvoice() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("CreateInvoice() returned after %s, want it to stop at the deadline", elapsed)
+	}
+}
+
+func TestDoRequestTimeout(t *testing.T) {
+	service := newTestPaymentService(t, 50*time.Millisecond, stalledGateway)
+
+	start := time.Now()
+	_, err := service.GetInvoice(context.Background(), "inv-1")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("GetInvoice() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("GetInvoice() returned after %s, want it to stop at the gateway timeout", elapsed)
+	}
+}
+
+func TestRefundIdempotencyKey(t *testing.T) {
+	service := newTestPaymentService(t, 5*time.Second, func(w http.ResponseWriter, r *http.Request) {
+		if key := r.Header.Get("Idempotency-Key"); key != "reservation-1-refund" {
+			t.Errorf("Idempotency-Key = %q, want the reference ID", key)
+		}
+		w.Write([]byte(`{"id":"rfd-1","reference_id":"reservation-1-refund","status":"SUCCEEDED"}`))
+	})
+
+	refund, err := service.Refund(context.Background(), models.XenditRefundRequest{
+		ReferenceID: "reservation-1-refund",
+		InvoiceID:   "inv-1",
+		Amount:      50000,
+		Reason:      models.RefundReasonCancellation,
+	})
+	if err != nil {
+		t.Fatalf("Refund() error = %v", err)
+	}
+	if refund.ID != "rfd-1" {
+		t.Fatalf("Refund() ID = %q, want rfd-1", refund.ID)
+	}
+}
@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"diro-be/internal/models"
+	"diro-be/internal/repositories"
+)
+
+// ErrPriceRuleNotFound is returned when a price rule does not exist
+var ErrPriceRuleNotFound = errors.New("price rule not found")
+
+// ErrInvalidPriceRule is returned when a price rule fails validation
+var ErrInvalidPriceRule = errors.New("invalid price rule")
+
+// ErrHolidayNotFound is returned when a holiday does not exist
+var ErrHolidayNotFound = errors.New("holiday not found")
+
+// ErrInvalidHoliday is returned when a holiday fails validation
+var ErrInvalidHoliday = errors.New("invalid holiday")
+
+// PriceList is a snapshot of the active price rules and holidays, so many
+// slots can be quoted without querying them again
+type PriceList struct {
+	DefaultPrice float64            // Used when no rule matches
+	Rules        []models.PriceRule // Highest priority first
+	Holidays     map[string]bool    // Holiday dates as YYYY-MM-DD
+}
+
+// Quote returns the price of booking a timeslot on a court on a date: the
+// price of the first matching rule, or the default price
+func (p *PriceList) Quote(courtID uint, timeslot models.Timeslot, date time.Time) float64 {
+	holiday := p.Holidays[date.Format("2006-01-02")]
+	for i := range p.Rules {
+		if ruleMatches(&p.Rules[i], courtID, timeslot, date, holiday) {
+			return p.Rules[i].Price
+		}
+	}
+	return p.DefaultPrice
+}
+
+// ruleMatches reports whether every condition set on the rule holds for the slot
+func ruleMatches(rule *models.PriceRule, courtID uint, timeslot models.Timeslot, date time.Time, holiday bool) bool {
+	day := date.Format("2006-01-02")
+	switch {
+	case rule.CourtID != nil && *rule.CourtID != courtID:
+		return false
+	case rule.Weekday != nil && *rule.Weekday != int(date.Weekday()):
+		return false
+	case rule.StartTime != "" && timeslot.StartTime < rule.StartTime:
+		return false
+	case rule.EndTime != "" && timeslot.StartTime >= rule.EndTime:
+		return false
+	case rule.DateFrom != nil && day < rule.DateFrom.Format("2006-01-02"):
+		return false
+	case rule.DateTo != nil && day > rule.DateTo.Format("2006-01-02"):
+		return false
+	case rule.HolidayOnly && !holiday:
+		return false
+	}
+	return true
+}
+
+// PricingService quotes slot prices and manages price rules and holidays
+type PricingService struct {
+	pricingRepo  *repositories.PricingRepository
+	courtRepo    *repositories.CourtRepository
+	timeslotRepo *repositories.TimeslotRepository
+	defaultPrice float64
+}
+
+// NewPricingService creates a new pricing service. defaultPrice applies to
+// slots no price rule matches.
+func NewPricingService(pricingRepo *repositories.PricingRepository, courtRepo *repositories.CourtRepository, timeslotRepo *repositories.TimeslotRepository, defaultPrice float64) *PricingService {
+	return &PricingService{
+		pricingRepo:  pricingRepo,
+		courtRepo:    courtRepo,
+		timeslotRepo: timeslotRepo,
+		defaultPrice: defaultPrice,
+	}
+}
+
+// PriceList loads the active price rules and the holidays between from and
+// to inclusive
+func (s *PricingService) PriceList(ctx context.Context, from, to time.Time) (*PriceList, error) {
+	rules, err := s.pricingRepo.ListPriceRules(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	holidays, err := s.pricingRepo.ListHolidays(ctx, &from, &to)
+	if err != nil {
+		return nil, err
+	}
+
+	priceList := &PriceList{
+		DefaultPrice: s.defaultPrice,
+		Rules:        rules,
+		Holidays:     make(map[string]bool, len(holidays)),
+	}
+	for _, holiday := range holidays {
+		priceList.Holidays[holiday.Date.Format("2006-01-02")] = true
+	}
+	return priceList, nil
+}
+
+// QuoteSlot returns the current price of booking a timeslot on a court on a date
+func (s *PricingService) QuoteSlot(ctx context.Context, courtID, timeslotID uint, date time.Time) (float64, error) {
+	timeslot, err := s.timeslotRepo.GetTimeslotByID(ctx, timeslotID)
+	if err != nil {
+		return 0, err
+	}
+
+	priceList, err := s.PriceList(ctx, date, date)
+	if err != nil {
+		return 0, err
+	}
+	return priceList.Quote(courtID, *timeslot, date), nil
+}
+
+// ListPriceRules returns every price rule, including inactive ones, in the
+// order they are evaluated
+func (s *PricingService) ListPriceRules(ctx context.Context) ([]models.PriceRule, error) {
+	return s.pricingRepo.ListPriceRules(ctx, true)
+}
+
+// GetPriceRule returns a price rule by ID
+func (s *PricingService) GetPriceRule(ctx context.Context, id uint) (*models.PriceRule, error) {
+	rule, err := s.pricingRepo.GetPriceRuleByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPriceRuleNotFound
+	}
+	return rule, err
+}
+
+// CreatePriceRule validates and creates a price rule
+func (s *PricingService) CreatePriceRule(ctx context.Context, rule *models.PriceRule) (*models.PriceRule, error) {
+	rule.ID = 0
+	if err := s.validatePriceRule(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	if err := s.pricingRepo.CreatePriceRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// UpdatePriceRule replaces the conditions, price and priority of a price rule
+func (s *PricingService) UpdatePriceRule(ctx context.Context, id uint, rule *models.PriceRule) (*models.PriceRule, error) {
+	existing, err := s.GetPriceRule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rule.ID = existing.ID
+	rule.CreatedAt = existing.CreatedAt
+	if err := s.validatePriceRule(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	if err := s.pricingRepo.UpdatePriceRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// DeletePriceRule deletes a price rule. Prices of existing reservations are
+// not affected.
+func (s *PricingService) DeletePriceRule(ctx context.Context, id uint) error {
+	if _, err := s.GetPriceRule(ctx, id); err != nil {
+		return err
+	}
+	return s.pricingRepo.DeletePriceRule(ctx, id)
+}
+
+// ListHolidays returns the holidays between from and to inclusive
+func (s *PricingService) ListHolidays(ctx context.Context, from, to *time.Time) ([]models.Holiday, error) {
+	return s.pricingRepo.ListHolidays(ctx, from, to)
+}
+
+// CreateHoliday marks a date as a holiday
+func (s *PricingService) CreateHoliday(ctx context.Context, date time.Time, name string) (*models.Holiday, error) {
+	holiday := &models.Holiday{
+		Date: date,
+		Name: strings.TrimSpace(name),
+	}
+	if holiday.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidHoliday)
+	}
+
+	if err := s.pricingRepo.CreateHoliday(ctx, holiday); err != nil {
+		return nil, err
+	}
+	return holiday, nil
+}
+
+// DeleteHoliday deletes a holiday
+func (s *PricingService) DeleteHoliday(ctx context.Context, id uint) error {
+	if _, err := s.pricingRepo.GetHolidayByID(ctx, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrHolidayNotFound
+		}
+		return err
+	}
+	return s.pricingRepo.DeleteHoliday(ctx, id)
+}
+
+// validatePriceRule checks the name, price and the conditions of a price rule
+func (s *PricingService) validatePriceRule(ctx context.Context, rule *models.PriceRule) error {
+	rule.Name = strings.TrimSpace(rule.Name)
+	if rule.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidPriceRule)
+	}
+	if rule.Price <= 0 {
+		return fmt.Errorf("%w: price must be positive", ErrInvalidPriceRule)
+	}
+	if rule.Weekday != nil && (*rule.Weekday < int(time.Sunday) || *rule.Weekday > int(time.Saturday)) {
+		return fmt.Errorf("%w: weekday must be between 0 (Sunday) and 6 (Saturday)", ErrInvalidPriceRule)
+	}
+	if (rule.StartTime != "" && !clockPattern.MatchString(rule.StartTime)) || (rule.EndTime != "" && !clockPattern.MatchString(rule.EndTime)) {
+		return fmt.Errorf("%w: times must use the HH:MM format", ErrInvalidPriceRule)
+	}
+	if rule.StartTime != "" && rule.EndTime != "" && rule.EndTime <= rule.StartTime {
+		return fmt.Errorf("%w: end time must be after start time", ErrInvalidPriceRule)
+	}
+	if rule.DateFrom != nil && rule.DateTo != nil && rule.DateTo.Before(*rule.DateFrom) {
+		return fmt.Errorf("%w: date_to must not be before date_from", ErrInvalidPriceRule)
+	}
+
+	if rule.CourtID != nil {
+		if _, err := s.courtRepo.GetCourtByID(ctx, *rule.CourtID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: court %d does not exist", ErrInvalidPriceRule, *rule.CourtID)
+			}
+			return err
+		}
+	}
+	return nil
+}
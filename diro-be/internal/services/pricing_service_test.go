@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"diro-be/internal/models"
+	"diro-be/internal/repositories"
+	"diro-be/internal/testdb"
+)
+
+func TestPriceListQuote(t *testing.T) {
+	courtID := uint(2)
+	saturday := int(time.Saturday)
+	summerFrom := time.Date(2030, 7, 1, 0, 0, 0, 0, time.Local)
+	summerTo := time.Date(2030, 7, 31, 0, 0, 0, 0, time.Local)
+
+	// Highest priority first, as the repository lists them
+	priceList := &PriceList{
+		DefaultPrice: 50000,
+		Rules: []models.PriceRule{
+			{Name: "Court 2 holiday", CourtID: &courtID, HolidayOnly: true, Price: 90000, Priority: 40},
+			{Name: "Holiday", HolidayOnly: true, Price: 80000, Priority: 30},
+			{Name: "Court 2 evening", CourtID: &courtID, StartTime: "18:00", EndTime: "22:00", Price: 85000, Priority: 25},
+			{Name: "Saturday", Weekday: &saturday, Price: 65000, Priority: 20},
+			{Name: "Evening", StartTime: "18:00", EndTime: "22:00", Price: 75000, Priority: 10},
+			{Name: "Summer", DateFrom: &summerFrom, DateTo: &summerTo, Price: 60000, Priority: 5},
+		},
+		Holidays: map[string]bool{"2030-06-04": true},
+	}
+
+	monday := time.Date(2030, 6, 3, 0, 0, 0, 0, time.Local)
+	tests := []struct {
+		name    string
+		courtID uint
+		start   string
+		date    time.Time
+		want    float64
+	}{
+		{name: "no rule matches", courtID: 1, start: "08:00", date: monday, want: 50000},
+		{name: "evening starts at its start time", courtID: 1, start: "18:00", date: monday, want: 75000},
+		{name: "evening ends before its end time", courtID: 1, start: "22:00", date: monday, want: 50000},
+		{name: "weekday outranks time window", courtID: 1, start: "19:00", date: monday.AddDate(0, 0, 5), want: 65000},
+		{name: "court rule outranks global rules", courtID: 2, start: "19:00", date: monday.AddDate(0, 0, 5), want: 85000},
+		{name: "court rule does not match other courts", courtID: 3, start: "19:00", date: monday, want: 75000},
+		{name: "holiday", courtID: 1, start: "08:00", date: monday.AddDate(0, 0, 1), want: 80000},
+		{name: "court holiday outranks holiday", courtID: 2, start: "08:00", date: monday.AddDate(0, 0, 1), want: 90000},
+		{name: "first day of date range", courtID: 1, start: "08:00", date: summerFrom, want: 60000},
+		{name: "last day of date range", courtID: 1, start: "08:00", date: summerTo, want: 60000},
+		{name: "after date range", courtID: 1, start: "08:00", date: summerTo.AddDate(0, 0, 1), want: 50000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeslot := models.Timeslot{StartTime: tt.start, EndTime: "23:00"}
+			if got := priceList.Quote(tt.courtID, timeslot, tt.date); got != tt.want {
+				t.Fatalf("Quote() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuoteSlot(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	court, timeslot := testdb.CreateSlot(t, db)
+	courtRepo := repositories.NewCourtRepository(db)
+	timeslotRepo := repositories.NewTimeslotRepository(db)
+	pricing := NewPricingService(repositories.NewPricingRepository(db), courtRepo, timeslotRepo, 50000)
+
+	// Priorities above any rule other tests leave behind, so only these
+	// rules decide the price of this test's court
+	date := testdb.Date()
+	weekday := int(date.Weekday())
+	rules := []*models.PriceRule{
+		{Name: "Court evening", CourtID: &court.ID, StartTime: "18:00", EndTime: "22:00", Price: 72000, Priority: 100000, IsActive: true},
+		{Name: "Court weekday", CourtID: &court.ID, Weekday: &weekday, Price: 70000, Priority: 100000, IsActive: true},
+		{Name: "Court inactive", CourtID: &court.ID, Price: 99000, Priority: 200000, IsActive: false},
+	}
+	for _, rule := range rules {
+		if _, err := pricing.CreatePriceRule(ctx, rule); err != nil {
+			t.Fatalf("CreatePriceRule() error = %v", err)
+		}
+	}
+
+	inactive, err := pricing.GetPriceRule(ctx, rules[2].ID)
+	if err != nil {
+		t.Fatalf("GetPriceRule() error = %v", err)
+	}
+	if inactive.IsActive {
+		t.Fatal("rule created inactive is stored as active")
+	}
+
+	tests := []struct {
+		name string
+		date time.Time
+		want float64
+	}{
+		{name: "newest of equal priorities wins", date: date, want: 70000},
+		{name: "older rule on other weekdays", date: date.AddDate(0, 0, 1), want: 72000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price, err := pricing.QuoteSlot(ctx, court.ID, timeslot.ID, tt.date)
+			if err != nil {
+				t.Fatalf("QuoteSlot() error = %v", err)
+			}
+			if price != tt.want {
+				t.Fatalf("QuoteSlot() = %v, want %v", price, tt.want)
+			}
+		})
+	}
+
+	// Bookings and availability charge what the slot is quoted at
+	testdb.ScheduleDaily(t, db, court, timeslot)
+	service := newTestReservationService(t, db, newTestGateway())
+	availability, err := service.GetAvailability(ctx, date, date, &court.ID)
+	if err != nil {
+		t.Fatalf("GetAvailability() error = %v", err)
+	}
+	if slots := availability.Days[0].Courts[0].Timeslots; len(slots) != 1 || slots[0].Price != 70000 {
+		t.Fatalf("availability lists %+v, want one slot at 70000", slots)
+	}
+	reservation, _, err := service.CreateReservation(ctx, nil, court.ID, timeslot.ID, date, testCustomer, "")
+	if err != nil {
+		t.Fatalf("CreateReservation() error = %v", err)
+	}
+	if reservation.TotalPrice != 70000 {
+		t.Fatalf("reservation costs %v, want the quoted 70000", reservation.TotalPrice)
+	}
+}
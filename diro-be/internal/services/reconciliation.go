@@ -0,0 +1,284 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"diro-be/internal/models"
+	"diro-be/internal/repositories"
+)
+
+// Reconciliation mismatch kinds
+const (
+	MismatchPaidNotMarked = "paid_not_marked" // Paid at the gateway but the reservations are not paid
+	MismatchMarkedNotPaid = "marked_not_paid" // Paid reservations without a paid invoice
+	MismatchAmount        = "amount_mismatch" // The paid amount differs from the reservations' total
+)
+
+// InvoiceLister lists the invoices created in a period
+type InvoiceLister interface {
+	ListInvoices(ctx context.Context, from, to time.Time) ([]models.XenditInvoiceResponse, error)
+}
+
+// InvoiceFixture lists invoices from a JSON file holding an array of Xendit
+// invoice objects, for reconciling offline
+type InvoiceFixture struct {
+	path string
+}
+
+var _ InvoiceLister = (*InvoiceFixture)(nil)
+
+// NewInvoiceFixture creates an invoice lister reading the file at path
+func NewInvoiceFixture(path string) *InvoiceFixture {
+	return &InvoiceFixture{path: path}
+}
+
+// ListInvoices lists the invoices in the file created from from until to.
+// Invoices without a valid created time are always listed.
+func (f *InvoiceFixture) ListInvoices(ctx context.Context, from, to time.Time) ([]models.XenditInvoiceResponse, error) {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read invoice fixture: %w", err)
+	}
+
+	var all []models.XenditInvoiceResponse
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, fmt.Errorf("failed to parse invoice fixture: %w", err)
+	}
+
+	var invoices []models.XenditInvoiceResponse
+	for i := range all {
+		if invoiceCreatedIn(&all[i], from, to) {
+			invoices = append(invoices, all[i])
+		}
+	}
+	return invoices, nil
+}
+
+// ReconciliationMismatch is an invoice and the reservations it bills that
+// disagree
+type ReconciliationMismatch struct {
+	Kind                string   `json:"kind"`
+	InvoiceID           string   `json:"invoice_id,omitempty"`
+	ExternalID          string   `json:"external_id,omitempty"`
+	InvoiceStatus       string   `json:"invoice_status,omitempty"`
+	InvoiceAmount       float64  `json:"invoice_amount"`
+	ReservationIDs      []uint   `json:"reservation_ids"`
+	ReservationStatuses []string `json:"reservation_statuses"`
+	ReservationAmount   float64  `json:"reservation_amount"`
+	Fixed               bool     `json:"fixed"`
+	Note                string   `json:"note,omitempty"`
+}
+
+// ReconciliationReport lists the mismatches between the invoices at the
+// gateway and the reservations created in a period
+type ReconciliationReport struct {
+	From                string                   `json:"from"`
+	To                  string                   `json:"to"`
+	InvoicesChecked     int                      `json:"invoices_checked"`
+	ReservationsChecked int                      `json:"reservations_checked"`
+	Mismatches          []ReconciliationMismatch `json:"mismatches"`
+}
+
+// Reconcile matches the invoices created from from until to with their
+// reservations by payment ID, falling back to the external ID, and reports
+// paid invoices whose reservations are not paid, paid reservations without a
+// paid invoice and amounts that differ. With fix set, reservations of a paid
+// invoice with the right amount that are still pending or expired are marked
+// paid; the other mismatches need a person to look at them.
+func (s *ReservationService) Reconcile(ctx context.Context, lister InvoiceLister, from, to time.Time, fix bool) (*ReconciliationReport, error) {
+	invoices, err := lister.ListInvoices(ctx, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrGatewayFailure, err)
+	}
+
+	paymentIDs := make([]string, 0, len(invoices))
+	for _, invoice := range invoices {
+		paymentIDs = append(paymentIDs, invoice.ID)
+	}
+	created, err := s.reservationRepo.FindReservationsCreatedBetween(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	billed, err := s.reservationRepo.FindReservationsByPaymentIDs(ctx, paymentIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Group the reservations by invoice; those without one stand alone
+	groups := make(map[string][]models.Reservation)
+	groupOf := make(map[uint]string)
+	for _, reservation := range append(created, billed...) {
+		if _, seen := groupOf[reservation.ID]; seen {
+			continue
+		}
+		key := reservation.PaymentID
+		if key == "" {
+			key = fmt.Sprintf("reservation:%d", reservation.ID)
+		}
+		groups[key] = append(groups[key], reservation)
+		groupOf[reservation.ID] = key
+	}
+
+	report := &ReconciliationReport{
+		From:                from.Format(time.RFC3339),
+		To:                  to.Format(time.RFC3339),
+		InvoicesChecked:     len(invoices),
+		ReservationsChecked: len(groupOf),
+		Mismatches:          make([]ReconciliationMismatch, 0),
+	}
+
+	matched := make(map[string]bool)
+	for _, invoice := range invoices {
+		key := invoice.ID
+		group, ok := groups[key]
+		if !ok {
+			// The payment ID is not saved if the booking failed right after
+			// the invoice was created, but the external ID still names its
+			// first reservation
+			if id, err := strconv.ParseUint(invoice.ExternalID, 10, 32); err == nil {
+				key = groupOf[uint(id)]
+				group = groups[key]
+			}
+		}
+		if len(group) > 0 {
+			matched[key] = true
+		}
+
+		paid := isPaidInvoice(invoice.Status)
+		amountMatches := amountUnits(invoice.Amount) == amountUnits(invoiceAmount(group))
+		switch {
+		case paid && len(group) == 0:
+			mismatch := newMismatch(MismatchPaidNotMarked, &invoice, nil)
+			mismatch.Note = "no reservation for this invoice"
+			report.Mismatches = append(report.Mismatches, mismatch)
+		case paid && !allSettled(group):
+			mismatch := newMismatch(MismatchPaidNotMarked, &invoice, group)
+			if fix && amountMatches {
+				mismatch.Fixed, mismatch.Note = s.markInvoicePaid(ctx, group, invoice.Status)
+			}
+			report.Mismatches = append(report.Mismatches, mismatch)
+		case !paid && anySettled(group):
+			mismatch := newMismatch(MismatchMarkedNotPaid, &invoice, group)
+			mismatch.Note = "invoice is " + invoice.Status
+			report.Mismatches = append(report.Mismatches, mismatch)
+		}
+		if paid && len(group) > 0 && !amountMatches {
+			report.Mismatches = append(report.Mismatches, newMismatch(MismatchAmount, &invoice, group))
+		}
+	}
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		group := groups[key]
+		if matched[key] || !anySettled(group) {
+			continue
+		}
+		mismatch := newMismatch(MismatchMarkedNotPaid, nil, group)
+		mismatch.InvoiceID = group[0].PaymentID
+		if mismatch.InvoiceID == "" {
+			mismatch.Note = "reservation has no invoice"
+		} else {
+			mismatch.Note = "invoice not found at the gateway for this period"
+		}
+		report.Mismatches = append(report.Mismatches, mismatch)
+	}
+
+	return report, nil
+}
+
+// markInvoicePaid marks the unpaid reservations of a paid invoice paid and
+// reports whether all of them were, with a note on any that were not
+func (s *ReservationService) markInvoicePaid(ctx context.Context, group []models.Reservation, paymentStatus string) (bool, string) {
+	for _, reservation := range group {
+		if settled(&reservation) {
+			continue
+		}
+		err := s.UpdatePaymentStatus(ctx, reservation.ID, paymentStatus, TriggerReconcile, ActorSystem)
+		switch {
+		case errors.Is(err, ErrInvalidTransition):
+			return false, fmt.Sprintf("reservation %d is %s and needs a refund or manual review", reservation.ID, reservation.Status)
+		case errors.Is(err, repositories.ErrSlotTaken):
+			return false, fmt.Sprintf("slot of reservation %d was booked by someone else and needs a refund", reservation.ID)
+		case err != nil:
+			return false, fmt.Sprintf("failed to mark reservation %d paid: %v", reservation.ID, err)
+		}
+	}
+	return true, "marked paid"
+}
+
+// newMismatch describes an invoice, if any, and its reservations
+func newMismatch(kind string, invoice *models.XenditInvoiceResponse, group []models.Reservation) ReconciliationMismatch {
+	mismatch := ReconciliationMismatch{
+		Kind:                kind,
+		ReservationIDs:      make([]uint, 0, len(group)),
+		ReservationStatuses: make([]string, 0, len(group)),
+		ReservationAmount:   invoiceAmount(group),
+	}
+	if invoice != nil {
+		mismatch.InvoiceID = invoice.ID
+		mismatch.ExternalID = invoice.ExternalID
+		mismatch.InvoiceStatus = invoice.Status
+		mismatch.InvoiceAmount = invoice.Amount
+	}
+	for _, reservation := range group {
+		mismatch.ReservationIDs = append(mismatch.ReservationIDs, reservation.ID)
+		mismatch.ReservationStatuses = append(mismatch.ReservationStatuses, string(reservation.Status))
+	}
+	return mismatch
+}
+
+// isPaidInvoice reports whether an invoice status means the customer paid
+func isPaidInvoice(status string) bool {
+	return status == models.PaymentStatusPaid || status == models.PaymentStatusSettled
+}
+
+// settled reports whether a reservation was paid, including paid
+// reservations refunded or cancelled later
+func settled(reservation *models.Reservation) bool {
+	switch reservation.Status {
+	case models.ReservationStatusPaid, models.ReservationStatusPartiallyRefunded, models.ReservationStatusRefunded:
+		return true
+	}
+	return reservation.Status == models.ReservationStatusCancelled && isPaidInvoice(reservation.PaymentStatus)
+}
+
+// allSettled reports whether every reservation was paid
+func allSettled(group []models.Reservation) bool {
+	for i := range group {
+		if !settled(&group[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// anySettled reports whether any reservation was paid
+func anySettled(group []models.Reservation) bool {
+	for i := range group {
+		if settled(&group[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// invoiceCreatedIn reports whether an invoice was created from from until to.
+// Invoices without a valid created time are included.
+func invoiceCreatedIn(invoice *models.XenditInvoiceResponse, from, to time.Time) bool {
+	created, err := time.Parse(time.RFC3339, invoice.Created)
+	if err != nil {
+		return true
+	}
+	return !created.Before(from) && created.Before(to)
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"diro-be/internal/models"
+	"diro-be/internal/testdb"
+)
+
+// bookFractionalSlots books three slots of their own on one invoice, priced
+// so the total is 100000 only when rounded to whole units
+func bookFractionalSlots(t *testing.T, db *gorm.DB, service *ReservationService) []models.Reservation {
+	t.Helper()
+
+	prices := []float64{33333.33, 33333.33, 33333.34}
+	items := make([]BookingItem, 0, len(prices))
+	for range prices {
+		court, timeslot := testdb.CreateSlot(t, db)
+		testdb.ScheduleDaily(t, db, court, timeslot)
+		items = append(items, BookingItem{CourtID: court.ID, TimeslotID: timeslot.ID, Date: testdb.Date()})
+	}
+	reservations, _, err := service.CreateBooking(context.Background(), nil, items, testCustomer, "")
+	if err != nil {
+		t.Fatalf("CreateBooking() error = %v", err)
+	}
+
+	for i := range reservations {
+		reservations[i].TotalPrice = prices[i]
+		if err := db.Model(&reservations[i]).Update("total_price", prices[i]).Error; err != nil {
+			t.Fatalf("failed to price reservation: %v", err)
+		}
+	}
+	return reservations
+}
+
+// writeInvoiceFixture writes invoices to a fixture file of its own
+func writeInvoiceFixture(t *testing.T, invoices []models.XenditInvoiceResponse) *InvoiceFixture {
+	t.Helper()
+
+	data, err := json.Marshal(invoices)
+	if err != nil {
+		t.Fatalf("failed to encode invoices: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "invoices.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("failed to write invoice fixture: %v", err)
+	}
+	return NewInvoiceFixture(path)
+}
+
+func TestReconcileFractionalAmounts(t *testing.T) {
+	db := testdb.Open(t)
+	service := newTestReservationService(t, db, newTestGateway())
+	ctx := context.Background()
+
+	matching := bookFractionalSlots(t, db, service)
+	short := bookFractionalSlots(t, db, service)
+	created := time.Now().Format(time.RFC3339)
+	fixture := writeInvoiceFixture(t, []models.XenditInvoiceResponse{
+		{ID: matching[0].PaymentID, Status: models.PaymentStatusPaid, Amount: 100000, Created: created},
+		{ID: short[0].PaymentID, Status: models.PaymentStatusPaid, Amount: 99999, Created: created},
+	})
+
+	report, err := service.Reconcile(ctx, fixture, time.Now().Add(-time.Hour), time.Now().Add(time.Hour), true)
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	// Only the mismatches of this test's invoices, by kind
+	kinds := make(map[string][]ReconciliationMismatch)
+	for _, mismatch := range report.Mismatches {
+		if mismatch.InvoiceID == matching[0].PaymentID || mismatch.InvoiceID == short[0].PaymentID {
+			kinds[mismatch.InvoiceID+" "+mismatch.Kind] = append(kinds[mismatch.InvoiceID+" "+mismatch.Kind], mismatch)
+		}
+	}
+
+	if mismatches := kinds[matching[0].PaymentID+" "+MismatchAmount]; len(mismatches) != 0 {
+		t.Fatalf("invoice paying the rounded total reported as %+v, want no amount mismatch", mismatches)
+	}
+	if mismatches := kinds[matching[0].PaymentID+" "+MismatchPaidNotMarked]; len(mismatches) != 1 || !mismatches[0].Fixed {
+		t.Fatalf("invoice paying the rounded total reported as %+v, want one fixed paid_not_marked", mismatches)
+	}
+	for _, reservation := range matching {
+		if status := reservationStatus(t, service, reservation.ID); status != models.ReservationStatusPaid {
+			t.Fatalf("reservation %d is %q after the fix, want paid", reservation.ID, status)
+		}
+	}
+
+	if mismatches := kinds[short[0].PaymentID+" "+MismatchAmount]; len(mismatches) != 1 {
+		t.Fatalf("invoice one unit short reported as %+v, want one amount mismatch", mismatches)
+	}
+	if mismatches := kinds[short[0].PaymentID+" "+MismatchPaidNotMarked]; len(mismatches) != 1 || mismatches[0].Fixed {
+		t.Fatalf("invoice one unit short reported as %+v, want one unfixed paid_not_marked", mismatches)
+	}
+	for _, reservation := range short {
+		if status := reservationStatus(t, service, reservation.ID); status != models.ReservationStatusPending {
+			t.Fatalf("reservation %d of an underpaid invoice is %q, want pending", reservation.ID, status)
+		}
+	}
+}
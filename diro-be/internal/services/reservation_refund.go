@@ -0,0 +1,338 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"diro-be/internal/models"
+	"diro-be/internal/repositories"
+)
+
+// ErrInvalidRefund is returned when a refund request fails validation
+var ErrInvalidRefund = errors.New("invalid refund")
+
+// ErrNotRefundable is returned when refunding a reservation that was not paid
+// or has been refunded in full
+var ErrNotRefundable = errors.New("reservation cannot be refunded")
+
+// ErrRefundNotFound is returned when a refund callback refers to an unknown refund
+var ErrRefundNotFound = errors.New("refund not found")
+
+// refundReasons are the refund reasons Xendit accepts
+var refundReasons = map[string]bool{
+	models.RefundReasonRequestedByCustomer: true,
+	models.RefundReasonCancellation:        true,
+	models.RefundReasonDuplicate:           true,
+	models.RefundReasonFraudulent:          true,
+	models.RefundReasonOthers:              true,
+}
+
+// RefundRequest asks to give back part or all of what was paid for a reservation
+type RefundRequest struct {
+	Amount      float64 // What is left to refund when zero
+	Reason      string  // One of the Xendit refund reasons
+	Note        string
+	ReferenceID string // Makes retries idempotent, generated when empty
+}
+
+// cancellationReferencePrefix starts the reference IDs of the refunds issued
+// when a paid reservation is cancelled
+const cancellationReferencePrefix = "reservation-%d-cancellation"
+
+// RefundReservation refunds a paid reservation through the payment gateway.
+// A refund the gateway confirms right away moves the reservation to
+// partially_refunded, or to refunded once everything paid was given back,
+// which releases its slot. Otherwise the status follows the gateway's refund
+// callback. Refunds of cancelled or expired reservations that were paid leave
+// their status unchanged. Retrying a request with the same reference ID and
+// amount returns the refund it created instead of refunding again; the
+// reservation is locked while a refund is sent, so concurrent retries reach
+// the gateway once.
+func (s *ReservationService) RefundReservation(ctx context.Context, userID uint, reservationID uint, req RefundRequest) (*models.Refund, *models.Reservation, error) {
+	reservation, err := s.reservationRepo.GetReservationByID(ctx, reservationID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, ErrReservationNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	referenceID := strings.TrimSpace(req.ReferenceID)
+	if referenceID != "" {
+		refund, err := s.refundRepo.GetRefundByReferenceID(ctx, referenceID)
+		if err == nil {
+			return replayRefund(refund, reservation, req.Amount)
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, err
+		}
+	}
+
+	if reservation.PaymentID == "" || !isPaidInvoice(reservation.PaymentStatus) || reservation.Status == models.ReservationStatusRefunded {
+		return nil, nil, fmt.Errorf("%w: reservation %d is %s", ErrNotRefundable, reservation.ID, reservation.Status)
+	}
+
+	remaining := reservation.TotalPrice - reservation.RefundAmount
+	if remaining <= 0 {
+		return nil, nil, fmt.Errorf("%w: reservation %d has nothing left to refund", ErrNotRefundable, reservation.ID)
+	}
+
+	reason := strings.ToUpper(strings.TrimSpace(req.Reason))
+	if !refundReasons[reason] {
+		return nil, nil, fmt.Errorf("%w: unknown reason %q", ErrInvalidRefund, req.Reason)
+	}
+	amount := req.Amount
+	if amount == 0 {
+		amount = remaining
+	}
+	if amount < 0 || amount != math.Trunc(amount) {
+		return nil, nil, fmt.Errorf("%w: amount must be a positive whole number", ErrInvalidRefund)
+	}
+	if amount > remaining {
+		return nil, nil, fmt.Errorf("%w: at most %.0f is left to refund", ErrInvalidRefund, remaining)
+	}
+
+	if referenceID == "" {
+		referenceID = fmt.Sprintf("reservation-%d-refund-%d", reservation.ID, time.Now().UnixNano())
+	}
+
+	actor := fmt.Sprintf("user:%d", userID)
+	refund := &models.Refund{
+		ReservationID: reservation.ID,
+		ReferenceID:   referenceID,
+		Amount:        amount,
+		Reason:        reason,
+		Note:          strings.TrimSpace(req.Note),
+		Actor:         actor,
+	}
+	if err := s.issueRefund(ctx, reservation, refund, nil); err != nil {
+		if errors.Is(err, repositories.ErrDuplicateRefund) {
+			// A concurrent retry created the refund first
+			if existing, findErr := s.refundRepo.GetRefundByReferenceID(ctx, referenceID); findErr == nil {
+				return replayRefund(existing, reservation, req.Amount)
+			}
+		}
+		return nil, nil, err
+	}
+
+	// The gateway has the refund, so its status is recorded even if the
+	// client goes away
+	ctx = context.WithoutCancel(ctx)
+	if err := s.settleRefund(ctx, refund, reservation, actor, nil); err != nil {
+		return nil, nil, err
+	}
+	return refund, reservation, nil
+}
+
+// replayRefund answers a retried refund request with the refund it created
+// before, or ErrDuplicateRefund if the reference ID was used for a different
+// reservation or amount. A zero amount asked for whatever was left, so it
+// matches any amount.
+func replayRefund(refund *models.Refund, reservation *models.Reservation, amount float64) (*models.Refund, *models.Reservation, error) {
+	if refund.ReservationID != reservation.ID || (amount != 0 && amount != refund.Amount) {
+		return nil, nil, fmt.Errorf("%w: reference %s was used for another refund", repositories.ErrDuplicateRefund, refund.ReferenceID)
+	}
+	if refund.Status == models.RefundStatusFailed {
+		return nil, nil, fmt.Errorf("%w: refund failed with %s", ErrGatewayFailure, refund.FailureCode)
+	}
+	return refund, reservation, nil
+}
+
+// cancellationReference returns the reference ID for the refund of a
+// cancelled reservation. The gateway takes each reference ID only once, so
+// every attempt after a failed refund gets a new one.
+func (s *ReservationService) cancellationReference(ctx context.Context, reservationID uint) (string, error) {
+	refunds, err := s.refundRepo.ListReservationRefunds(ctx, reservationID)
+	if err != nil {
+		return "", err
+	}
+
+	prefix := fmt.Sprintf(cancellationReferencePrefix, reservationID)
+	failed := 0
+	for _, refund := range refunds {
+		if strings.HasPrefix(refund.ReferenceID, prefix) && refund.Status == models.RefundStatusFailed {
+			failed++
+		}
+	}
+	if failed == 0 {
+		return prefix, nil
+	}
+	return fmt.Sprintf("%s-%d", prefix, failed+1), nil
+}
+
+// ListRefunds returns the refunds of a customer's reservation, oldest first.
+// Admins may read the refunds of any reservation.
+func (s *ReservationService) ListRefunds(ctx context.Context, userID uint, role string, reservationID uint) ([]models.Refund, error) {
+	if _, err := s.GetCustomerReservation(ctx, userID, role, reservationID); err != nil {
+		return nil, err
+	}
+	return s.refundRepo.ListReservationRefunds(ctx, reservationID)
+}
+
+// HandleRefundWebhook applies a refund callback from the payment gateway.
+// Each refund status is applied at most once; redeliveries return
+// repositories.ErrDuplicateEvent. Callbacks for a refund that already
+// succeeded or failed are recorded but change nothing.
+func (s *ReservationService) HandleRefundWebhook(ctx context.Context, payload models.XenditRefundWebhookPayload) error {
+	data := payload.Data
+	refund, err := s.refundRepo.GetRefundByReferenceID(ctx, data.ReferenceID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrRefundNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if (refund.GatewayRefundID != "" && data.ID != refund.GatewayRefundID) || data.Amount != refund.Amount {
+		return ErrWebhookMismatch
+	}
+
+	rawPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	event := &models.WebhookEvent{
+		EventID:       data.ID + ":" + data.Status,
+		InvoiceID:     data.InvoiceID,
+		ReservationID: refund.ReservationID,
+		Status:        data.Status,
+		Payload:       string(rawPayload),
+	}
+
+	if refund.Status != models.RefundStatusPending || data.Status == models.RefundStatusPending {
+		return s.reservationRepo.SaveTransition(ctx, nil, nil, event)
+	}
+
+	reservation, err := s.reservationRepo.GetReservationByID(ctx, refund.ReservationID)
+	if err != nil {
+		return err
+	}
+
+	event.Applied = true
+	refund.GatewayRefundID = data.ID
+	refund.Status = data.Status
+	refund.FailureCode = data.FailureCode
+	slog.InfoContext(ctx, "refund webhook applied", "reservation_id", reservation.ID, "invoice_id", reservation.PaymentID,
+		"refund_reference_id", refund.ReferenceID, "status", refund.Status)
+	return s.settleRefund(ctx, refund, reservation, ActorXendit, event)
+}
+
+// errRefundDeclined rolls back a refund the payment gateway declined
+var errRefundDeclined = errors.New("refund declined")
+
+// issueRefund sends a refund of the reservation to the payment gateway while
+// the reservation is locked, saving its transition described by history, if
+// any, in the same transaction. A refund the gateway did not accept leaves
+// the reservation unchanged; one it declined is kept as failed, so the next
+// attempt gets a new reference ID.
+func (s *ReservationService) issueRefund(ctx context.Context, reservation *models.Reservation, refund *models.Refund, history *models.ReservationStatusHistory) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	// A transaction ends with the context it began with, and once the gateway
+	// has the refund it has to be recorded even if the client goes away
+	ctx = context.WithoutCancel(ctx)
+
+	refund.Status = models.RefundStatusPending
+	err := s.refundRepo.IssueRefund(ctx, refund, reservation, history, func(refund *models.Refund) error {
+		response, err := s.paymentGateway.Refund(ctx, models.XenditRefundRequest{
+			ReferenceID: refund.ReferenceID,
+			InvoiceID:   reservation.PaymentID,
+			Amount:      refund.Amount,
+			Reason:      refund.Reason,
+			Metadata: map[string]interface{}{
+				"reservation_id": reservation.ID,
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrGatewayFailure, err)
+		}
+
+		refund.GatewayRefundID = response.ID
+		if response.Status != "" {
+			refund.Status = response.Status
+		}
+		refund.FailureCode = response.FailureCode
+		if refund.Status == models.RefundStatusFailed {
+			return errRefundDeclined
+		}
+		return nil
+	})
+	switch {
+	case errors.Is(err, repositories.ErrRefundExceedsPayment):
+		return fmt.Errorf("%w: %v", ErrInvalidRefund, err)
+	case errors.Is(err, errRefundDeclined):
+		refund.ID = 0
+		if err := s.refundRepo.SaveRefund(ctx, refund, nil, nil, nil); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: refund failed with %s", ErrGatewayFailure, refund.FailureCode)
+	case err != nil:
+		return err
+	}
+
+	slog.InfoContext(ctx, "refund requested", "reservation_id", reservation.ID, "invoice_id", reservation.PaymentID,
+		"refund_reference_id", refund.ReferenceID, "amount", refund.Amount, "status", refund.Status)
+	return nil
+}
+
+// settleRefund persists the status of a refund together with what it means
+// for its reservation: the amount refunded and, once the refund succeeded,
+// the move of a booked reservation to partially_refunded or refunded
+func (s *ReservationService) settleRefund(ctx context.Context, refund *models.Refund, reservation *models.Reservation, actor string, event *models.WebhookEvent) error {
+	refunds, err := s.refundRepo.ListReservationRefunds(ctx, reservation.ID)
+	if err != nil {
+		return err
+	}
+
+	var refunded, succeeded float64
+	for _, other := range refunds {
+		if other.ID == refund.ID {
+			other = *refund
+		}
+		switch other.Status {
+		case models.RefundStatusSucceeded:
+			succeeded += other.Amount
+			refunded += other.Amount
+		case models.RefundStatusPending:
+			refunded += other.Amount
+		}
+	}
+	reservation.RefundAmount = refunded
+
+	var history *models.ReservationStatusHistory
+	if refund.Status == models.RefundStatusSucceeded && reservation.Status.IsBooked() {
+		to := models.ReservationStatusPartiallyRefunded
+		if succeeded >= reservation.TotalPrice {
+			to = models.ReservationStatusRefunded
+		}
+		if to != reservation.Status {
+			history, err = applyTransition(reservation, Transition{
+				To:      to,
+				Trigger: TriggerRefund,
+				Actor:   actor,
+				Reason:  fmt.Sprintf("refunded %.0f (%s)", refund.Amount, strings.ToLower(refund.Reason)),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := s.refundRepo.SaveRefund(ctx, refund, reservation, history, event); err != nil {
+		return err
+	}
+	if history != nil {
+		s.slotsReleased(ctx, reservation)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"diro-be/internal/models"
+	"diro-be/internal/testdb"
+)
+
+func TestRefundReservationRetry(t *testing.T) {
+	db := testdb.Open(t)
+	fake := newTestGateway()
+	gateway := &recordingGateway{PaymentGateway: fake}
+	service := newTestReservationService(t, db, gateway)
+	reservation := bookTestSlot(t, db, service)
+	payTestReservation(t, service, fake, reservation)
+	ctx := context.Background()
+
+	req := RefundRequest{
+		Amount:      20000,
+		Reason:      models.RefundReasonRequestedByCustomer,
+		ReferenceID: fmt.Sprintf("reservation-%d-retry", reservation.ID),
+	}
+	first, _, err := service.RefundReservation(ctx, 1, reservation.ID, req)
+	if err != nil {
+		t.Fatalf("RefundReservation() error = %v", err)
+	}
+	retried, _, err := service.RefundReservation(ctx, 1, reservation.ID, req)
+	if err != nil {
+		t.Fatalf("RefundReservation() retry error = %v", err)
+	}
+
+	if retried.ID != first.ID || retried.Status != models.RefundStatusSucceeded {
+		t.Fatalf("retry returned refund %d (%s), want the stored refund %d", retried.ID, retried.Status, first.ID)
+	}
+	if calls := gateway.called("Refund " + req.ReferenceID); calls != 1 {
+		t.Fatalf("gateway was asked for the refund %d times, want once", calls)
+	}
+}
+
+func TestRefundReservationConcurrentRetry(t *testing.T) {
+	db := testdb.Open(t)
+	fake := newTestGateway()
+	gateway := &recordingGateway{PaymentGateway: fake}
+	service := newTestReservationService(t, db, gateway)
+	reservation := bookTestSlot(t, db, service)
+	payTestReservation(t, service, fake, reservation)
+
+	req := RefundRequest{
+		Amount:      20000,
+		Reason:      models.RefundReasonRequestedByCustomer,
+		ReferenceID: fmt.Sprintf("reservation-%d-concurrent", reservation.ID),
+	}
+	refunds := make([]*models.Refund, 2)
+	errs := make([]error, 2)
+	var wg sync.WaitGroup
+	for i := range refunds {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			refunds[i], _, errs[i] = service.RefundReservation(context.Background(), 1, reservation.ID, req)
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("RefundReservation() %d error = %v", i, err)
+		}
+	}
+	if refunds[0].ID != refunds[1].ID {
+		t.Fatalf("concurrent requests returned refunds %d and %d, want the same refund", refunds[0].ID, refunds[1].ID)
+	}
+	if calls := gateway.called("Refund " + req.ReferenceID); calls != 1 {
+		t.Fatalf("gateway was asked for the refund %d times, want once", calls)
+	}
+
+	stored, err := service.refundRepo.ListReservationRefunds(context.Background(), reservation.ID)
+	if err != nil {
+		t.Fatalf("ListReservationRefunds() error = %v", err)
+	}
+	if len(stored) != 1 {
+		t.Fatalf("%d refunds recorded, want 1", len(stored))
+	}
+}
@@ -0,0 +1,352 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+
+	"diro-be/internal/models"
+	"diro-be/internal/repositories"
+)
+
+// ErrInvalidSeries is returned when a recurring series request fails validation
+var ErrInvalidSeries = errors.New("invalid series")
+
+// ErrSeriesNotFound is returned when a series does not exist or belongs to
+// another customer
+var ErrSeriesNotFound = errors.New("series not found")
+
+// ErrNothingToBook is returned when no occurrence of a series can be booked
+var ErrNothingToBook = errors.New("no occurrence of the series can be booked")
+
+// maxSeriesOccurrences is the most occurrences a single series may have
+const maxSeriesOccurrences = 52
+
+// Series occurrence conflicts
+const (
+	conflictNotScheduled   = "not scheduled"
+	conflictSlotTaken      = "already reserved"
+	conflictAlreadyStarted = "already started"
+	conflictBlackedOut     = "court closed"
+	conflictOffered        = "offered to the waitlist"
+	conflictPromoCode      = "promo code not applicable"
+	conflictPayment        = "payment could not be set up"
+	conflictFailed         = "could not be booked"
+)
+
+// SeriesRequest describes a recurring series: the same court slot every week
+// or every other week from StartDate until EndDate or for Occurrences dates,
+// whichever is set
+type SeriesRequest struct {
+	CourtID     uint
+	TimeslotID  uint
+	Frequency   string
+	StartDate   time.Time
+	EndDate     *time.Time
+	Occurrences int
+	BillingMode string
+}
+
+// SeriesBooking is the outcome of booking a series: every occurrence with its
+// conflict if it could not be booked, the booked reservations and the
+// invoices to pay them
+type SeriesBooking struct {
+	Series       *models.ReservationSeries `json:"series"`
+	Occurrences  []models.SeriesOccurrence `json:"occurrences"`
+	Reservations []models.Reservation      `json:"reservations"`
+	InvoiceURLs  []string                  `json:"invoice_urls"`
+}
+
+// CheckSeries lists the dates of a series and whether each can be booked,
+// without reserving anything
+func (s *ReservationService) CheckSeries(ctx context.Context, req SeriesRequest) ([]models.SeriesOccurrence, error) {
+	dates, err := seriesDates(req)
+	if err != nil {
+		return nil, err
+	}
+
+	timeslot, err := s.timeslotRepo.GetTimeslotByID(ctx, req.TimeslotID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: timeslot %d does not exist", ErrInvalidSeries, req.TimeslotID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	blackouts, err := s.blackoutService.BlackoutList(ctx, dates[0], dates[len(dates)-1])
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	occurrences := make([]models.SeriesOccurrence, 0, len(dates))
+	for _, date := range dates {
+		occurrence := models.SeriesOccurrence{Date: date.Format("2006-01-02")}
+
+		start, err := slotStart(&models.Reservation{Date: date, Timeslot: *timeslot})
+		if err != nil {
+			return nil, err
+		}
+		scheduled, err := s.scheduleRepo.IsScheduled(ctx, req.CourtID, req.TimeslotID, date)
+		if err != nil {
+			return nil, err
+		}
+		available, err := s.reservationRepo.CheckSlotAvailability(ctx, req.CourtID, req.TimeslotID, date)
+		if err != nil {
+			return nil, err
+		}
+
+		blackout := blackouts.Find(req.CourtID, *timeslot, date)
+
+		switch {
+		case !start.After(now):
+			occurrence.Conflict = conflictAlreadyStarted
+		case !scheduled:
+			occurrence.Conflict = conflictNotScheduled
+		case blackout != nil:
+			occurrence.Conflict = conflictBlackedOut + ": " + blackout.Reason
+		case !available:
+			occurrence.Conflict = conflictSlotTaken
+		default:
+			occurrence.Available = true
+		}
+		occurrences = append(occurrences, occurrence)
+	}
+	return occurrences, nil
+}
+
+// CreateSeries books every available occurrence of a series for a customer
+// and reports the others as conflicts. With single billing the occurrences
+// are reserved all or nothing on one invoice, which takes at most
+// maxBookingItems of them like any booking; per occurrence billing issues
+// an invoice for each and reports occurrences that fail to book as
+// conflicts, returning the ones that were booked. The series is removed
+// again when nothing could be booked.
+func (s *ReservationService) CreateSeries(ctx context.Context, userID uint, req SeriesRequest, customer models.XenditCustomer, promoCode string) (*SeriesBooking, error) {
+	if req.BillingMode != models.SeriesBillingSingle && req.BillingMode != models.SeriesBillingPerOccurrence {
+		return nil, fmt.Errorf("%w: billing_mode must be %q or %q", ErrInvalidSeries, models.SeriesBillingSingle, models.SeriesBillingPerOccurrence)
+	}
+
+	occurrences, err := s.CheckSeries(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []BookingItem
+	for _, occurrence := range occurrences {
+		if !occurrence.Available {
+			continue
+		}
+		date, _ := time.Parse("2006-01-02", occurrence.Date)
+		items = append(items, BookingItem{CourtID: req.CourtID, TimeslotID: req.TimeslotID, Date: date})
+	}
+	if len(items) == 0 {
+		return nil, ErrNothingToBook
+	}
+	// One invoice bills at most as many slots as a booking may reserve
+	if req.BillingMode == models.SeriesBillingSingle && len(items) > maxBookingItems {
+		return nil, fmt.Errorf("%w: single billing covers at most %d occurrences, use %q billing for %d", ErrInvalidSeries, maxBookingItems, models.SeriesBillingPerOccurrence, len(items))
+	}
+
+	series := &models.ReservationSeries{
+		UserID:      userID,
+		CourtID:     req.CourtID,
+		TimeslotID:  req.TimeslotID,
+		Frequency:   req.Frequency,
+		StartDate:   req.StartDate,
+		EndDate:     items[len(items)-1].Date,
+		BillingMode: req.BillingMode,
+	}
+	if err := s.reservationRepo.CreateSeries(ctx, series); err != nil {
+		return nil, err
+	}
+
+	result := &SeriesBooking{Series: series, Occurrences: occurrences}
+	if req.BillingMode == models.SeriesBillingSingle {
+		reservations, invoiceURL, err := s.book(ctx, &userID, items, customer, promoCode, &series.ID)
+		if err != nil {
+			s.deleteSeries(ctx, series)
+			return nil, err
+		}
+		result.Reservations = reservations
+		result.InvoiceURLs = []string{invoiceURL}
+	} else {
+		var firstErr error
+		for _, item := range items {
+			reservations, invoiceURL, err := s.book(ctx, &userID, []BookingItem{item}, customer, promoCode, &series.ID)
+			if err != nil {
+				// Earlier occurrences hold their slots and invoices already,
+				// so a failing occurrence is reported instead of aborting
+				slog.WarnContext(ctx, "series occurrence could not be booked",
+					"series_id", series.ID, "date", item.Date.Format("2006-01-02"), "error", err.Error())
+				markConflict(occurrences, item.Date, bookingConflict(err))
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			result.Reservations = append(result.Reservations, reservations...)
+			result.InvoiceURLs = append(result.InvoiceURLs, invoiceURL)
+		}
+		if len(result.Reservations) == 0 {
+			s.deleteSeries(ctx, series)
+			if errors.Is(firstErr, repositories.ErrSlotTaken) {
+				return nil, ErrNothingToBook
+			}
+			return nil, firstErr
+		}
+	}
+
+	for _, reservation := range result.Reservations {
+		for i := range occurrences {
+			if occurrences[i].Date == reservation.Date.Format("2006-01-02") {
+				id := reservation.ID
+				occurrences[i].ReservationID = &id
+			}
+		}
+	}
+	return result, nil
+}
+
+// deleteSeries removes a series nothing could be booked for. It runs even if
+// the request was cancelled, and failing only leaves an empty series behind,
+// so errors are logged.
+func (s *ReservationService) deleteSeries(ctx context.Context, series *models.ReservationSeries) {
+	ctx = context.WithoutCancel(ctx)
+	if err := s.reservationRepo.DeleteSeries(ctx, series.ID); err != nil {
+		slog.ErrorContext(ctx, "failed to delete series without bookings", "series_id", series.ID, "error", err.Error())
+	}
+}
+
+// GetCustomerSeries returns a series with its reservations if it belongs to
+// the customer. Admins can read any series.
+func (s *ReservationService) GetCustomerSeries(ctx context.Context, userID uint, role string, seriesID uint) (*models.ReservationSeries, error) {
+	series, err := s.reservationRepo.GetSeriesByID(ctx, seriesID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSeriesNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if role != models.UserRoleAdmin && series.UserID != userID {
+		return nil, ErrSeriesNotFound
+	}
+	return series, nil
+}
+
+// CancelSeries cancels the occurrences of a series that have not started yet,
+// from the given date on (or all of them with a nil from), each according to
+// the cancellation policy. A single occurrence is cancelled like any other
+// reservation. It returns the cancelled reservations.
+func (s *ReservationService) CancelSeries(ctx context.Context, userID uint, role string, seriesID uint, from *time.Time, reason string) ([]models.Reservation, error) {
+	series, err := s.GetCustomerSeries(ctx, userID, role, seriesID)
+	if err != nil {
+		return nil, err
+	}
+
+	if reason == "" {
+		reason = "series cancelled by customer"
+	}
+
+	now := time.Now()
+	var cancelled []models.Reservation
+	for i := range series.Reservations {
+		reservation := &series.Reservations[i]
+		if from != nil && reservation.Date.Before(*from) {
+			continue
+		}
+		start, err := slotStart(reservation)
+		if err != nil {
+			return nil, err
+		}
+		if !start.After(now) {
+			continue
+		}
+
+		// Cancelling a pending occurrence billed on the series invoice also
+		// cancels the other occurrences on it, so skip those already done
+		updated, err := s.CancelReservation(ctx, userID, role, reservation.ID, reason)
+		if errors.Is(err, ErrInvalidTransition) || errors.Is(err, repositories.ErrStaleReservation) {
+			continue
+		}
+		if err != nil {
+			return cancelled, err
+		}
+		cancelled = append(cancelled, *updated)
+	}
+	return cancelled, nil
+}
+
+// seriesDates returns the dates of a series, validating its frequency and
+// bounds
+func seriesDates(req SeriesRequest) ([]time.Time, error) {
+	var step int
+	switch req.Frequency {
+	case models.SeriesFrequencyWeekly:
+		step = 7
+	case models.SeriesFrequencyBiweekly:
+		step = 14
+	default:
+		return nil, fmt.Errorf("%w: frequency must be %q or %q", ErrInvalidSeries, models.SeriesFrequencyWeekly, models.SeriesFrequencyBiweekly)
+	}
+
+	if (req.EndDate == nil) == (req.Occurrences == 0) {
+		return nil, fmt.Errorf("%w: set either end_date or occurrences", ErrInvalidSeries)
+	}
+	if req.Occurrences < 0 {
+		return nil, fmt.Errorf("%w: occurrences must be positive", ErrInvalidSeries)
+	}
+	if req.EndDate != nil && req.EndDate.Before(req.StartDate) {
+		return nil, fmt.Errorf("%w: end_date must not be before start_date", ErrInvalidSeries)
+	}
+
+	var dates []time.Time
+	for date := req.StartDate; ; date = date.AddDate(0, 0, step) {
+		if req.EndDate != nil && date.After(*req.EndDate) {
+			break
+		}
+		if req.Occurrences > 0 && len(dates) == req.Occurrences {
+			break
+		}
+		if len(dates) == maxSeriesOccurrences {
+			return nil, fmt.Errorf("%w: a series can have at most %d occurrences", ErrInvalidSeries, maxSeriesOccurrences)
+		}
+		dates = append(dates, date)
+	}
+	return dates, nil
+}
+
+// bookingConflict describes why booking an occurrence failed
+func bookingConflict(err error) string {
+	switch {
+	case errors.Is(err, repositories.ErrSlotTaken):
+		return conflictSlotTaken
+	case errors.Is(err, ErrSlotNotScheduled):
+		return conflictNotScheduled
+	case errors.Is(err, ErrSlotBlackedOut):
+		return conflictBlackedOut
+	case errors.Is(err, ErrSlotOffered):
+		return conflictOffered
+	case errors.Is(err, ErrInvalidPromoCode), errors.Is(err, repositories.ErrVoucherUsedUp):
+		return conflictPromoCode
+	case errors.Is(err, ErrGatewayFailure):
+		return conflictPayment
+	}
+	return conflictFailed
+}
+
+// markConflict records why the occurrence on date could not be booked
+func markConflict(occurrences []models.SeriesOccurrence, date time.Time, conflict string) {
+	day := date.Format("2006-01-02")
+	for i := range occurrences {
+		if occurrences[i].Date == day {
+			occurrences[i].Available = false
+			occurrences[i].Conflict = conflict
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"diro-be/internal/models"
+	"diro-be/internal/testdb"
+)
+
+func TestCreateSeriesBillingModes(t *testing.T) {
+	tests := []struct {
+		name         string
+		billingMode  string
+		occurrences  int
+		wantInvoices int
+		wantErr      error
+	}{
+		{name: "single", billingMode: models.SeriesBillingSingle, occurrences: 3, wantInvoices: 1},
+		{name: "single at the booking limit", billingMode: models.SeriesBillingSingle, occurrences: maxBookingItems, wantInvoices: 1},
+		{name: "single over the booking limit", billingMode: models.SeriesBillingSingle, occurrences: maxBookingItems + 1, wantErr: ErrInvalidSeries},
+		{name: "per occurrence", billingMode: models.SeriesBillingPerOccurrence, occurrences: 3, wantInvoices: 3},
+		{name: "per occurrence over the booking limit", billingMode: models.SeriesBillingPerOccurrence, occurrences: maxBookingItems + 1, wantInvoices: maxBookingItems + 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testdb.Open(t)
+			service := newTestReservationService(t, db, newTestGateway())
+			court, timeslot := testdb.CreateSlot(t, db)
+			testdb.ScheduleDaily(t, db, court, timeslot)
+			user := createTestUser(t, db)
+
+			booking, err := service.CreateSeries(context.Background(), user.ID, SeriesRequest{
+				CourtID:     court.ID,
+				TimeslotID:  timeslot.ID,
+				Frequency:   models.SeriesFrequencyWeekly,
+				StartDate:   testdb.Date(),
+				Occurrences: tt.occurrences,
+				BillingMode: tt.billingMode,
+			}, testCustomer, "")
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("CreateSeries() error = %v, want %v", err, tt.wantErr)
+				}
+				available, err := service.reservationRepo.CheckSlotAvailability(context.Background(), court.ID, timeslot.ID, testdb.Date())
+				if err != nil {
+					t.Fatalf("CheckSlotAvailability() error = %v", err)
+				}
+				if !available {
+					t.Fatal("a rejected series reserved its first occurrence")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateSeries() error = %v", err)
+			}
+
+			if len(booking.Reservations) != tt.occurrences {
+				t.Fatalf("%d occurrences booked, want %d", len(booking.Reservations), tt.occurrences)
+			}
+			if len(booking.InvoiceURLs) != tt.wantInvoices {
+				t.Fatalf("%d invoices issued, want %d", len(booking.InvoiceURLs), tt.wantInvoices)
+			}
+			payments := make(map[string]bool)
+			for _, reservation := range booking.Reservations {
+				if reservation.SeriesID == nil || *reservation.SeriesID != booking.Series.ID {
+					t.Fatalf("reservation %d is not linked to series %d", reservation.ID, booking.Series.ID)
+				}
+				payments[reservation.PaymentID] = true
+			}
+			if len(payments) != tt.wantInvoices {
+				t.Fatalf("reservations are billed on %d invoices, want %d", len(payments), tt.wantInvoices)
+			}
+		})
+	}
+}
@@ -1,94 +1,647 @@
 package services
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
+	"math"
+	"strings"
 	"time"
 
+	"gorm.io/gorm"
+
 	"diro-be/internal/models"
 	"diro-be/internal/repositories"
 )
 
+// ErrWebhookMismatch is returned when a webhook does not match the invoice
+// stored on the reservation it refers to
+var ErrWebhookMismatch = errors.New("webhook does not match reservation invoice")
+
+// ErrReservationNotFound is returned when a reservation does not exist or
+// belongs to another customer
+var ErrReservationNotFound = errors.New("reservation not found")
+
+// ErrGatewayFailure wraps errors returned by the payment gateway
+var ErrGatewayFailure = errors.New("payment gateway request failed")
+
+// ErrSlotNotScheduled is returned when booking a timeslot that is not on the
+// court's schedule for that date
+var ErrSlotNotScheduled = errors.New("timeslot is not scheduled for this court on this date")
+
+// ErrInvalidBooking is returned when a booking request lists no slots, too
+// many slots or the same slot twice
+var ErrInvalidBooking = errors.New("invalid booking")
+
+// ErrInvalidRange is returned when an availability range is reversed or too
+// long
+var ErrInvalidRange = errors.New("invalid date range")
+
+// maxAvailabilityDays is the most days a single availability request may cover
+const maxAvailabilityDays = 31
+
+// maxBookingItems is the most slots a single booking may reserve
+const maxBookingItems = 10
+
+// BookingItem is one court slot of a booking
+type BookingItem struct {
+	CourtID    uint
+	TimeslotID uint
+	Date       time.Time
+}
+
 // ReservationService handles reservation business logic
 type ReservationService struct {
-	reservationRepo *repositories.ReservationRepository
-	paymentService  *PaymentService
+	reservationRepo    *repositories.ReservationRepository
+	scheduleRepo       *repositories.ScheduleRepository
+	timeslotRepo       *repositories.TimeslotRepository
+	pricingService     *PricingService
+	voucherService     *VoucherService
+	blackoutService    *BlackoutService
+	waitlistRepo       *repositories.WaitlistRepository
+	refundRepo         *repositories.RefundRepository
+	paymentGateway     PaymentGateway
+	cancellationPolicy CancellationPolicy
+	holdDuration       time.Duration
+	offerTTL           time.Duration
 }
 
-// NewReservationService creates a new reservation service
-func NewReservationService(reservationRepo *repositories.ReservationRepository, xenditUsername, xenditPassword string) *ReservationService {
+// NewReservationService creates a new reservation service. Booked slots are
+// held for holdDuration while their invoice is unpaid, and slots offered to
+// the waitlist for offerTTL.
+func NewReservationService(reservationRepo *repositories.ReservationRepository, scheduleRepo *repositories.ScheduleRepository, timeslotRepo *repositories.TimeslotRepository, pricingService *PricingService, voucherService *VoucherService, blackoutService *BlackoutService, waitlistRepo *repositories.WaitlistRepository, refundRepo *repositories.RefundRepository, paymentGateway PaymentGateway, cancellationPolicy CancellationPolicy, holdDuration, offerTTL time.Duration) *ReservationService {
 	return &ReservationService{
-		reservationRepo: reservationRepo,
-		paymentService:  NewPaymentService(xenditUsername, xenditPassword),
+		reservationRepo:    reservationRepo,
+		scheduleRepo:       scheduleRepo,
+		timeslotRepo:       timeslotRepo,
+		pricingService:     pricingService,
+		voucherService:     voucherService,
+		blackoutService:    blackoutService,
+		waitlistRepo:       waitlistRepo,
+		refundRepo:         refundRepo,
+		paymentGateway:     paymentGateway,
+		cancellationPolicy: cancellationPolicy,
+		holdDuration:       holdDuration,
+		offerTTL:           offerTTL,
 	}
 }
 
-// CreateReservation creates a new reservation with payment
-func (s *ReservationService) CreateReservation(courtID, timeslotID uint, date time.Time, customer models.XenditCustomer) (*models.Reservation, string, error) {
-	// Check if the slot is still available
-	available, err := s.reservationRepo.CheckSlotAvailability(courtID, timeslotID, date)
+// CreateReservation creates a new reservation with payment. userID links the
+// reservation to a customer account and is nil for guest bookings. A
+// non-empty promoCode discounts the quoted price.
+func (s *ReservationService) CreateReservation(ctx context.Context, userID *uint, courtID, timeslotID uint, date time.Time, customer models.XenditCustomer, promoCode string) (*models.Reservation, string, error) {
+	reservations, invoiceURL, err := s.CreateBooking(ctx, userID, []BookingItem{
+		{CourtID: courtID, TimeslotID: timeslotID, Date: date},
+	}, customer, promoCode)
 	if err != nil {
 		return nil, "", err
 	}
-	if !available {
-		return nil, "", errors.New("slot is already reserved")
+	return &reservations[0], invoiceURL, nil
+}
+
+// CreateBooking reserves several court slots at once, all or nothing, and
+// bills them on a single invoice with one item per slot. The reservations of
+// a booking share the invoice's payment ID. A promo code discounts the slots
+// it is valid for.
+func (s *ReservationService) CreateBooking(ctx context.Context, userID *uint, items []BookingItem, customer models.XenditCustomer, promoCode string) ([]models.Reservation, string, error) {
+	if len(items) == 0 || len(items) > maxBookingItems {
+		return nil, "", fmt.Errorf("%w: a booking needs between 1 and %d slots", ErrInvalidBooking, maxBookingItems)
+	}
+	return s.book(ctx, userID, items, customer, promoCode, nil)
+}
+
+// book reserves the slots of a booking, all or nothing, on a single invoice.
+// seriesID links the reservations to the recurring series they belong to.
+func (s *ReservationService) book(ctx context.Context, userID *uint, items []BookingItem, customer models.XenditCustomer, promoCode string, seriesID *uint) ([]models.Reservation, string, error) {
+	// Create the reservations, holding the slots until the invoice expires
+	now := time.Now()
+	holdExpiresAt := now.Add(s.holdDuration)
+	reservations := make([]*models.Reservation, 0, len(items))
+	slotKeys := make(map[string]bool, len(items))
+	for _, item := range items {
+		slotKey := models.SlotKey(item.CourtID, item.TimeslotID, item.Date)
+		if slotKeys[slotKey] {
+			return nil, "", fmt.Errorf("%w: slot %s is listed twice", ErrInvalidBooking, slotKey)
+		}
+		slotKeys[slotKey] = true
+
+		reservation, err := s.newReservation(ctx, userID, item, holdExpiresAt)
+		if err != nil {
+			return nil, "", err
+		}
+		reservation.SeriesID = seriesID
+		reservations = append(reservations, reservation)
+	}
+
+	if promoCode != "" {
+		if err := s.applyPromoCode(ctx, reservations, promoCode); err != nil {
+			return nil, "", err
+		}
+	}
+
+	// Release lapsed holds on the slots the expirer has not picked up yet.
+	// Their invoices are left to the sweep, so booking never waits on the
+	// gateway for them.
+	for slotKey := range slotKeys {
+		released, err := s.reservationRepo.ReleaseLapsedHolds(ctx, now, slotKey)
+		if err != nil {
+			return nil, "", err
+		}
+		if released > 0 {
+			slog.InfoContext(ctx, "released lapsed slot hold", "slot_key", slotKey)
+		}
 	}
 
-	// Create the reservation
-	reservation := &models.Reservation{
-		CourtID:       courtID,
-		TimeslotID:    timeslotID,
-		Date:          date,
-		Status:        "pending",
-		TotalPrice:    50000, // Fixed price for now
-		PaymentStatus: "PENDING",
+	// Slots on offer to the waitlist are kept for the customers they were
+	// offered to
+	offers, err := s.takeOffers(ctx, userID, reservations, now)
+	if err != nil {
+		return nil, "", err
 	}
 
-	if err := s.reservationRepo.CreateReservation(reservation); err != nil {
+	if err := s.reservationRepo.ReserveSlots(ctx, reservations, models.ReservationStatusHistory{
+		ToStatus:      models.ReservationStatusPending,
+		PaymentStatus: models.PaymentStatusPending,
+		Trigger:       TriggerBooking,
+		Actor:         customer.Email,
+		Reason:        "reservation created",
+	}); err != nil {
 		return nil, "", err
 	}
 
+	// The slots are taken now, so the booking is finished, or its slots
+	// released, even if the client goes away
+	ctx = context.WithoutCancel(ctx)
+	s.markOffersBooked(ctx, offers, reservations)
+
+	// Load relations for the invoice description and items
+	booked := make([]models.Reservation, 0, len(reservations))
+	for _, reservation := range reservations {
+		loaded, err := s.reservationRepo.GetReservationByID(ctx, reservation.ID)
+		if err != nil {
+			return nil, "", err
+		}
+		booked = append(booked, *loaded)
+	}
+
 	// Create Xendit invoice
-	invoiceResp, err := s.paymentService.CreateInvoice(reservation, customer)
+	invoiceResp, err := s.paymentGateway.CreateInvoice(ctx, booked, customer)
 	if err != nil {
-		// Invoice creation failed, delete reservation
-		s.reservationRepo.DeleteReservation(reservation.ID)
-		return nil, "", fmt.Errorf("failed to create invoice: %w", err)
+		// Invoice creation failed, release the slots
+		for i := range booked {
+			s.transition(ctx, &booked[i], Transition{
+				To:      models.ReservationStatusFailed,
+				Trigger: TriggerBooking,
+				Actor:   ActorSystem,
+				Reason:  "invoice creation failed",
+			})
+		}
+		slog.ErrorContext(ctx, "invoice creation failed, released the booking's slots", "reservation_ids", reservationIDs(booked), "error", err.Error())
+		return nil, "", fmt.Errorf("%w: %w", ErrGatewayFailure, err)
 	}
 
-	// Update reservation with payment info
-	reservation.PaymentID = invoiceResp.ID
-	reservation.InvoiceURL = invoiceResp.InvoiceURL
-	reservation.PaymentStatus = invoiceResp.Status
-	if err := s.reservationRepo.UpdateReservation(reservation); err != nil {
+	// Update reservations with payment info
+	for i := range booked {
+		booked[i].PaymentID = invoiceResp.ID
+		booked[i].InvoiceURL = invoiceResp.InvoiceURL
+		booked[i].PaymentStatus = invoiceResp.Status
+		if expiry, err := time.Parse(time.RFC3339, invoiceResp.ExpiryDate); err == nil {
+			booked[i].HoldExpiresAt = &expiry
+		}
+	}
+	if err := s.reservationRepo.UpdateReservations(ctx, booked); err != nil {
 		return nil, "", err
 	}
 
-	// Load relations
-	reservation, err = s.reservationRepo.GetReservationByID(reservation.ID)
+	slog.InfoContext(ctx, "booking created", "reservation_ids", reservationIDs(booked), "invoice_id", invoiceResp.ID)
+	return booked, invoiceResp.InvoiceURL, nil
+}
+
+// newReservation prepares a pending reservation for a scheduled slot at its
+// quoted price
+func (s *ReservationService) newReservation(ctx context.Context, userID *uint, item BookingItem, holdExpiresAt time.Time) (*models.Reservation, error) {
+	scheduled, err := s.scheduleRepo.IsScheduled(ctx, item.CourtID, item.TimeslotID, item.Date)
 	if err != nil {
-		return nil, "", err
+		return nil, err
+	}
+	if !scheduled {
+		return nil, ErrSlotNotScheduled
 	}
 
-	return reservation, invoiceResp.InvoiceURL, nil
+	blackout, err := s.blackoutService.SlotBlackout(ctx, item.CourtID, item.TimeslotID, item.Date)
+	if err != nil {
+		return nil, err
+	}
+	if blackout != nil {
+		return nil, fmt.Errorf("%w: %s", ErrSlotBlackedOut, blackout.Reason)
+	}
+
+	price, err := s.pricingService.QuoteSlot(ctx, item.CourtID, item.TimeslotID, item.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Reservation{
+		UserID:        userID,
+		CourtID:       item.CourtID,
+		TimeslotID:    item.TimeslotID,
+		Date:          item.Date,
+		Status:        models.ReservationStatusPending,
+		TotalPrice:    price,
+		PaymentStatus: models.PaymentStatusPending,
+		HoldExpiresAt: &holdExpiresAt,
+	}, nil
 }
 
-// UpdatePaymentStatus updates the payment status of a reservation
-func (s *ReservationService) UpdatePaymentStatus(reservationID uint, paymentStatus string) error {
-	reservation, err := s.reservationRepo.GetReservationByID(reservationID)
+// applyPromoCode discounts the reservations the promo code is valid for. It
+// fails if the code applies to none of them.
+func (s *ReservationService) applyPromoCode(ctx context.Context, reservations []*models.Reservation, promoCode string) error {
+	var firstErr error
+	applied := false
+	for _, reservation := range reservations {
+		voucher, discount, err := s.voucherService.ApplyPromoCode(ctx, promoCode, reservation.UserID, reservation.CourtID, reservation.TimeslotID, reservation.Date, reservation.TotalPrice)
+		if errors.Is(err, ErrInvalidPromoCode) {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		reservation.VoucherID = &voucher.ID
+		reservation.PromoCode = voucher.Code
+		reservation.DiscountAmount = discount
+		reservation.TotalPrice -= discount
+		applied = true
+	}
+
+	if !applied {
+		return firstErr
+	}
+	return nil
+}
+
+// HandleInvoiceWebhook applies a Xendit invoice callback to the reservations
+// it bills. reservationID is the invoice's external ID; every reservation
+// booked on the same invoice changes status together. The payload must match
+// the stored invoice ID and, once paid, the booking total. Each event is
+// applied at most once; redeliveries return repositories.ErrDuplicateEvent.
+// Events that arrive out of order, such as EXPIRED after PAID, are recorded
+// but leave the reservations unchanged.
+func (s *ReservationService) HandleInvoiceWebhook(ctx context.Context, reservationID uint, payload models.XenditWebhookPayload) error {
+	reservation, err := s.reservationRepo.GetReservationByID(ctx, reservationID)
+	if err != nil {
+		return err
+	}
+
+	if reservation.PaymentID == "" || payload.ID != reservation.PaymentID {
+		return ErrWebhookMismatch
+	}
+
+	booking, err := s.reservationRepo.FindReservationsByPaymentID(ctx, reservation.PaymentID)
 	if err != nil {
 		return err
 	}
+	// Every status that pays the reservations, SETTLED as well as PAID, has
+	// to cover the booking total
+	to, ok := statusForPayment(payload.Status)
+	if to == models.ReservationStatusPaid && int64(payload.PaidAmount) != amountUnits(invoiceAmount(booking)) {
+		return ErrWebhookMismatch
+	}
+
+	rawPayload, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
 
-	reservation.PaymentStatus = paymentStatus
-	if paymentStatus == "PAID" {
-		reservation.Status = "paid"
+	event := &models.WebhookEvent{
+		EventID:       payload.ID + ":" + payload.Status,
+		InvoiceID:     payload.ID,
+		ReservationID: reservation.ID,
+		Status:        payload.Status,
+		Payload:       string(rawPayload),
 	}
 
-	return s.reservationRepo.UpdateReservation(reservation)
+	if !ok {
+		return s.reservationRepo.SaveTransition(ctx, nil, nil, event)
+	}
+
+	var changed []*models.Reservation
+	var histories []*models.ReservationStatusHistory
+	for i := range booking {
+		if booking[i].Status == to {
+			continue
+		}
+		history, err := applyTransition(&booking[i], Transition{
+			To:            to,
+			PaymentStatus: payload.Status,
+			Trigger:       TriggerWebhook,
+			Actor:         ActorXendit,
+			Reason:        "invoice " + strings.ToLower(payload.Status),
+		})
+		if errors.Is(err, ErrInvalidTransition) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		changed = append(changed, &booking[i])
+		histories = append(histories, history)
+	}
+	if len(changed) == 0 {
+		return s.reservationRepo.SaveTransition(ctx, nil, nil, event)
+	}
+
+	event.Applied = true
+	err = s.reservationRepo.SaveTransitions(ctx, changed, histories, event)
+	if err == nil {
+		slog.InfoContext(ctx, "invoice webhook applied", "invoice_id", payload.ID, "status", payload.Status, "reservation_ids", reservationIDs(booking))
+		s.slotsReleased(ctx, changed...)
+	}
+	if errors.Is(err, repositories.ErrSlotTaken) {
+		// Paid after the hold lapsed and someone else booked one of the
+		// slots. Keep the payment on record so it can be refunded.
+		slog.WarnContext(ctx, "invoice was paid after a slot of its booking was taken by another booking",
+			"invoice_id", payload.ID, "reservation_ids", reservationIDs(booking))
+		for i, reservation := range changed {
+			reservation.Status = histories[i].FromStatus
+		}
+		event.ID = 0
+		event.Applied = false
+		return s.reservationRepo.SaveTransitions(ctx, changed, make([]*models.ReservationStatusHistory, len(changed)), event)
+	}
+	return err
 }
 
-// GetDayAvailability returns availability for a specific day
-func (s *ReservationService) GetDayAvailability(date time.Time) (*models.DayAvailability, error) {
-	return s.reservationRepo.GetDayAvailability(date)
+// CancelReservation cancels a customer's reservation and releases its slot.
+// An unpaid reservation has its invoice expired, which also cancels the
+// reservations booked on the same invoice; a paid one is refunded through the
+// payment gateway according to the cancellation policy, less what was
+// refunded before. Admins may cancel any reservation.
+func (s *ReservationService) CancelReservation(ctx context.Context, userID uint, role string, reservationID uint, reason string) (*models.Reservation, error) {
+	reservation, err := s.GetCustomerReservation(ctx, userID, role, reservationID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !CanTransition(reservation.Status, models.ReservationStatusCancelled) {
+		return nil, &TransitionError{From: reservation.Status, To: models.ReservationStatusCancelled}
+	}
+
+	now := time.Now()
+	if reason == "" {
+		reason = "cancelled by customer"
+	}
+	actor := fmt.Sprintf("user:%d", userID)
+	paymentStatus := reservation.PaymentStatus
+	switch reservation.Status {
+	case models.ReservationStatusPending:
+		if reservation.PaymentID != "" {
+			if _, err := s.paymentGateway.ExpireInvoice(ctx, reservation.PaymentID); err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrGatewayFailure, err)
+			}
+		}
+		paymentStatus = models.PaymentStatusExpired
+
+		// The invoice can no longer be paid, so the cancellation is recorded
+		// even if the client goes away
+		ctx = context.WithoutCancel(ctx)
+
+	case models.ReservationStatusPaid, models.ReservationStatusPartiallyRefunded:
+		start, err := slotStart(reservation)
+		if err != nil {
+			return nil, err
+		}
+
+		refundAmount := s.cancellationPolicy.RefundAmount(reservation.TotalPrice, start, now)
+		refundAmount = math.Min(refundAmount, reservation.TotalPrice-reservation.RefundAmount)
+		if refundAmount > 0 {
+			return s.cancelWithRefund(ctx, reservation, refundAmount, actor, reason, now)
+		}
+	}
+
+	wasPending := reservation.Status == models.ReservationStatusPending
+	reservation.CancelledAt = &now
+	if err := s.transition(ctx, reservation, Transition{
+		To:            models.ReservationStatusCancelled,
+		PaymentStatus: paymentStatus,
+		Trigger:       TriggerCancellation,
+		Actor:         actor,
+		Reason:        reason,
+	}); err != nil {
+		return nil, err
+	}
+
+	// The expired invoice billed the whole booking, so its other unpaid
+	// reservations are cancelled too
+	if wasPending && reservation.PaymentID != "" {
+		if err := s.cancelPendingBooking(ctx, reservation, actor, now); err != nil {
+			return nil, err
+		}
+	}
+
+	slog.InfoContext(ctx, "reservation cancelled", "reservation_id", reservation.ID, "invoice_id", reservation.PaymentID, "actor", actor)
+	return reservation, nil
+}
+
+// cancelWithRefund cancels a paid reservation and refunds amount of it. The
+// cancellation is saved before the refund is sent, in the same transaction,
+// so a reservation that changed status meanwhile is never refunded and a
+// refund the gateway did not accept leaves it paid.
+func (s *ReservationService) cancelWithRefund(ctx context.Context, reservation *models.Reservation, amount float64, actor, reason string, now time.Time) (*models.Reservation, error) {
+	referenceID, err := s.cancellationReference(ctx, reservation.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	cancelled := *reservation
+	cancelled.RefundAmount += amount
+	cancelled.CancelledAt = &now
+	history, err := applyTransition(&cancelled, Transition{
+		To:      models.ReservationStatusCancelled,
+		Trigger: TriggerCancellation,
+		Actor:   actor,
+		Reason:  reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	refund := &models.Refund{
+		ReservationID: reservation.ID,
+		ReferenceID:   referenceID,
+		Amount:        amount,
+		Reason:        models.RefundReasonCancellation,
+		Actor:         actor,
+	}
+	if err := s.issueRefund(ctx, &cancelled, refund, history); err != nil {
+		return nil, err
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	s.slotsReleased(ctx, &cancelled)
+	slog.InfoContext(ctx, "reservation cancelled", "reservation_id", cancelled.ID, "invoice_id", cancelled.PaymentID,
+		"actor", actor, "refund_reference_id", referenceID, "refund_amount", amount)
+	return &cancelled, nil
+}
+
+// cancelPendingBooking cancels the other pending reservations billed on the
+// same invoice as a cancelled reservation
+func (s *ReservationService) cancelPendingBooking(ctx context.Context, cancelled *models.Reservation, actor string, now time.Time) error {
+	booking, err := s.reservationRepo.FindReservationsByPaymentID(ctx, cancelled.PaymentID)
+	if err != nil {
+		return err
+	}
+
+	for i := range booking {
+		reservation := &booking[i]
+		if reservation.ID == cancelled.ID || reservation.Status != models.ReservationStatusPending {
+			continue
+		}
+		reservation.CancelledAt = &now
+		err := s.transition(ctx, reservation, Transition{
+			To:            models.ReservationStatusCancelled,
+			PaymentStatus: models.PaymentStatusExpired,
+			Trigger:       TriggerCancellation,
+			Actor:         actor,
+			Reason:        fmt.Sprintf("booked together with cancelled reservation %d", cancelled.ID),
+		})
+		if err != nil && !errors.Is(err, repositories.ErrStaleReservation) {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpdatePaymentStatus moves a reservation to the status matching a gateway
+// invoice status. It returns a TransitionError if the move is not allowed.
+func (s *ReservationService) UpdatePaymentStatus(ctx context.Context, reservationID uint, paymentStatus, trigger, actor string) error {
+	reservation, err := s.reservationRepo.GetReservationByID(ctx, reservationID)
+	if err != nil {
+		return err
+	}
+
+	to, ok := statusForPayment(paymentStatus)
+	if !ok || to == reservation.Status {
+		return nil
+	}
+
+	return s.transition(ctx, reservation, Transition{
+		To:            to,
+		PaymentStatus: paymentStatus,
+		Trigger:       trigger,
+		Actor:         actor,
+		Reason:        "invoice " + strings.ToLower(paymentStatus),
+	})
+}
+
+// ListCustomerReservations returns one page of a customer's reservations and
+// the total number of reservations matching the filter
+func (s *ReservationService) ListCustomerReservations(ctx context.Context, userID uint, filter repositories.ReservationFilter) ([]models.Reservation, int64, error) {
+	return s.reservationRepo.ListUserReservations(ctx, userID, filter, time.Now())
+}
+
+// GetCustomerReservation returns a reservation owned by the customer. Admins
+// may read any reservation.
+func (s *ReservationService) GetCustomerReservation(ctx context.Context, userID uint, role string, reservationID uint) (*models.Reservation, error) {
+	reservation, err := s.reservationRepo.GetReservationByID(ctx, reservationID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrReservationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if role != models.UserRoleAdmin && (reservation.UserID == nil || *reservation.UserID != userID) {
+		return nil, ErrReservationNotFound
+	}
+	return reservation, nil
+}
+
+// GetStatusHistory returns the status transitions of a reservation, oldest first
+func (s *ReservationService) GetStatusHistory(ctx context.Context, reservationID uint) ([]models.ReservationStatusHistory, error) {
+	return s.reservationRepo.GetStatusHistory(ctx, reservationID)
+}
+
+// GetDayAvailability returns availability for a specific day with the price
+// of each slot. Slots during a blackout are marked with its reason.
+func (s *ReservationService) GetDayAvailability(ctx context.Context, date time.Time) (*models.DayAvailability, error) {
+	availability, err := s.GetAvailability(ctx, date, date, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &availability.Days[0], nil
+}
+
+// GetAvailability returns availability with slot prices for every day from
+// from to to inclusive, on every active court or on one court, with a
+// summary of the free slots of each day
+func (s *ReservationService) GetAvailability(ctx context.Context, from, to time.Time, courtID *uint) (*models.AvailabilityRange, error) {
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: to must not be before from", ErrInvalidRange)
+	}
+	if days := int(to.Sub(from).Hours()/24) + 1; days > maxAvailabilityDays {
+		return nil, fmt.Errorf("%w: a range can cover at most %d days", ErrInvalidRange, maxAvailabilityDays)
+	}
+
+	days, err := s.reservationRepo.GetAvailability(ctx, from, to, courtID)
+	if err != nil {
+		return nil, err
+	}
+
+	priceList, err := s.pricingService.PriceList(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	blackouts, err := s.blackoutService.BlackoutList(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	availability := &models.AvailabilityRange{
+		From:    from.Format("2006-01-02"),
+		To:      to.Format("2006-01-02"),
+		Summary: make([]models.DaySummary, 0, len(days)),
+		Days:    days,
+	}
+	for d := range days {
+		date := from.AddDate(0, 0, d)
+		summary := models.DaySummary{Date: days[d].Date}
+		for i := range days[d].Courts {
+			court := &days[d].Courts[i]
+			for j := range court.Timeslots {
+				slot := &court.Timeslots[j]
+				slot.Price = priceList.Quote(court.Court.ID, slot.Timeslot, date)
+				if blackout := blackouts.Find(court.Court.ID, slot.Timeslot, date); blackout != nil {
+					slot.IsBlackedOut = true
+					slot.BlackoutReason = blackout.Reason
+				}
+
+				summary.TotalSlots++
+				if !slot.IsBooked && !slot.IsHeld && !slot.IsBlackedOut {
+					summary.FreeSlots++
+				}
+			}
+		}
+		availability.Summary = append(availability.Summary, summary)
+	}
+
+	return availability, nil
+}
+
+// transition applies a status change and persists it with its history entry
+func (s *ReservationService) transition(ctx context.Context, reservation *models.Reservation, t Transition) error {
+	history, err := applyTransition(reservation, t)
+	if err != nil {
+		return err
+	}
+	if err := s.reservationRepo.SaveTransition(ctx, reservation, history, nil); err != nil {
+		return err
+	}
+	s.slotsReleased(ctx, reservation)
+	return nil
 }
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"diro-be/internal/models"
+	"diro-be/internal/repositories"
+	"diro-be/internal/testdb"
+)
+
+// testCustomer is the customer billed for test bookings
+var testCustomer = models.XenditCustomer{GivenNames: "Test", Email: "customer@example.com"}
+
+// newTestGateway returns a fake gateway whose invoices stay payable for 15 minutes
+func newTestGateway() *FakePaymentGateway {
+	return NewFakePaymentGateway("http://fake-gateway.test", "test-token", PaymentSettings{
+		Currency:        "IDR",
+		InvoiceDuration: 15 * time.Minute,
+		Timeout:         time.Second,
+	})
+}
+
+// recordingGateway records the calls made to the gateway it wraps, naming
+// each by method and invoice or refund reference
+type recordingGateway struct {
+	PaymentGateway
+
+	mu    sync.Mutex
+	calls []string
+}
+
+func (g *recordingGateway) record(call string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.calls = append(g.calls, call)
+}
+
+// called reports how many times call was made
+func (g *recordingGateway) called(call string) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	count := 0
+	for _, made := range g.calls {
+		if made == call {
+			count++
+		}
+	}
+	return count
+}
+
+func (g *recordingGateway) GetInvoice(ctx context.Context, invoiceID string) (*models.XenditInvoiceResponse, error) {
+	g.record("GetInvoice " + invoiceID)
+	return g.PaymentGateway.GetInvoice(ctx, invoiceID)
+}
+
+func (g *recordingGateway) ExpireInvoice(ctx context.Context, invoiceID string) (*models.XenditInvoiceResponse, error) {
+	g.record("ExpireInvoice " + invoiceID)
+	return g.PaymentGateway.ExpireInvoice(ctx, invoiceID)
+}
+
+func (g *recordingGateway) Refund(ctx context.Context, request models.XenditRefundRequest) (*models.XenditRefundResponse, error) {
+	g.record("Refund " + request.ReferenceID)
+	return g.PaymentGateway.Refund(ctx, request)
+}
+
+// newTestReservationService returns a reservation service on the test
+// database billing through gateway. Slots cost 50000 unless a price rule
+// says otherwise and are refunded in full up to a day before they start.
+func newTestReservationService(t *testing.T, db *gorm.DB, gateway PaymentGateway) *ReservationService {
+	t.Helper()
+
+	reservationRepo := repositories.NewReservationRepository(db)
+	courtRepo := repositories.NewCourtRepository(db)
+	timeslotRepo := repositories.NewTimeslotRepository(db)
+	return NewReservationService(
+		reservationRepo,
+		repositories.NewScheduleRepository(db),
+		timeslotRepo,
+		NewPricingService(repositories.NewPricingRepository(db), courtRepo, timeslotRepo, 50000),
+		NewVoucherService(repositories.NewVoucherRepository(db), courtRepo, timeslotRepo),
+		NewBlackoutService(repositories.NewBlackoutRepository(db), courtRepo, timeslotRepo, reservationRepo),
+		repositories.NewWaitlistRepository(db),
+		repositories.NewRefundRepository(db),
+		gateway,
+		CancellationPolicy{FullRefundBefore: 24 * time.Hour, PartialRefundPercent: 50},
+		15*time.Minute,
+		30*time.Minute,
+	)
+}
+
+// bookTestSlot books a scheduled slot of its own, leaving it pending payment
+func bookTestSlot(t *testing.T, db *gorm.DB, service *ReservationService) *models.Reservation {
+	t.Helper()
+
+	court, timeslot := testdb.CreateSlot(t, db)
+	testdb.ScheduleDaily(t, db, court, timeslot)
+	reservation, _, err := service.CreateReservation(context.Background(), nil, court.ID, timeslot.ID, testdb.Date(), testCustomer, "")
+	if err != nil {
+		t.Fatalf("CreateReservation() error = %v", err)
+	}
+	return reservation
+}
+
+// createTestUser creates a customer account of its own for a test
+func createTestUser(t *testing.T, db *gorm.DB) *models.User {
+	t.Helper()
+
+	stamp := time.Now().Format("20060102150405.000000000")
+	user := &models.User{Name: "Test customer", Email: "customer-" + stamp + "@example.com", PasswordHash: "x", Role: models.UserRoleCustomer}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	return user
+}
+
+// invoiceWebhook returns the callback Xendit sends when the invoice of
+// reservation reaches status, with paidAmount paid
+func invoiceWebhook(reservation *models.Reservation, status string, paidAmount int) models.XenditWebhookPayload {
+	return models.XenditWebhookPayload{
+		ID:         reservation.PaymentID,
+		Amount:     int(reservation.TotalPrice),
+		PaidAmount: paidAmount,
+		Status:     status,
+	}
+}
+
+// reservationStatus reloads the status of a reservation
+func reservationStatus(t *testing.T, service *ReservationService, id uint) models.ReservationStatus {
+	t.Helper()
+
+	reservation, err := service.reservationRepo.GetReservationByID(context.Background(), id)
+	if err != nil {
+		t.Fatalf("GetReservationByID() error = %v", err)
+	}
+	return reservation.Status
+}
+
+// historyCount counts the status changes recorded for a reservation
+func historyCount(t *testing.T, service *ReservationService, id uint) int {
+	t.Helper()
+
+	history, err := service.GetStatusHistory(context.Background(), id)
+	if err != nil {
+		t.Fatalf("GetStatusHistory() error = %v", err)
+	}
+	return len(history)
+}
+
+func TestHandleInvoiceWebhookReplay(t *testing.T) {
+	db := testdb.Open(t)
+	service := newTestReservationService(t, db, newTestGateway())
+	reservation := bookTestSlot(t, db, service)
+	ctx := context.Background()
+
+	paid := invoiceWebhook(reservation, models.PaymentStatusPaid, int(reservation.TotalPrice))
+	if err := service.HandleInvoiceWebhook(ctx, reservation.ID, paid); err != nil {
+		t.Fatalf("HandleInvoiceWebhook() error = %v", err)
+	}
+	if err := service.HandleInvoiceWebhook(ctx, reservation.ID, paid); !errors.Is(err, repositories.ErrDuplicateEvent) {
+		t.Fatalf("HandleInvoiceWebhook() of a redelivery error = %v, want ErrDuplicateEvent", err)
+	}
+
+	if status := reservationStatus(t, service, reservation.ID); status != models.ReservationStatusPaid {
+		t.Fatalf("status = %q, want paid", status)
+	}
+	// Booking and payment, nothing for the redelivery
+	if count := historyCount(t, service, reservation.ID); count != 2 {
+		t.Fatalf("%d status changes recorded, want 2", count)
+	}
+}
+
+func TestHandleInvoiceWebhookOutOfOrder(t *testing.T) {
+	db := testdb.Open(t)
+	service := newTestReservationService(t, db, newTestGateway())
+	reservation := bookTestSlot(t, db, service)
+	ctx := context.Background()
+
+	paid := invoiceWebhook(reservation, models.PaymentStatusPaid, int(reservation.TotalPrice))
+	if err := service.HandleInvoiceWebhook(ctx, reservation.ID, paid); err != nil {
+		t.Fatalf("HandleInvoiceWebhook() error = %v", err)
+	}
+	expired := invoiceWebhook(reservation, models.PaymentStatusExpired, 0)
+	if err := service.HandleInvoiceWebhook(ctx, reservation.ID, expired); err != nil {
+		t.Fatalf("HandleInvoiceWebhook() of EXPIRED after PAID error = %v", err)
+	}
+
+	if status := reservationStatus(t, service, reservation.ID); status != models.ReservationStatusPaid {
+		t.Fatalf("status = %q after EXPIRED arrived late, want paid", status)
+	}
+	if count := historyCount(t, service, reservation.ID); count != 2 {
+		t.Fatalf("%d status changes recorded, want 2", count)
+	}
+
+	var event models.WebhookEvent
+	if err := db.Where("event_id = ?", reservation.PaymentID+":"+models.PaymentStatusExpired).First(&event).Error; err != nil {
+		t.Fatalf("EXPIRED event was not recorded: %v", err)
+	}
+	if event.Applied {
+		t.Fatal("EXPIRED event after PAID is recorded as applied, want not applied")
+	}
+}
+
+func TestHandleInvoiceWebhookAmountMismatch(t *testing.T) {
+	for _, status := range []string{models.PaymentStatusPaid, models.PaymentStatusSettled} {
+		t.Run(status, func(t *testing.T) {
+			db := testdb.Open(t)
+			service := newTestReservationService(t, db, newTestGateway())
+			reservation := bookTestSlot(t, db, service)
+
+			payload := invoiceWebhook(reservation, status, int(reservation.TotalPrice)-1000)
+			if err := service.HandleInvoiceWebhook(context.Background(), reservation.ID, payload); !errors.Is(err, ErrWebhookMismatch) {
+				t.Fatalf("HandleInvoiceWebhook() error = %v, want ErrWebhookMismatch", err)
+			}
+			if status := reservationStatus(t, service, reservation.ID); status != models.ReservationStatusPending {
+				t.Fatalf("status = %q after an underpaid callback, want pending", status)
+			}
+		})
+	}
+}
+
+func TestGetAvailabilityInvalidRange(t *testing.T) {
+	from := time.Date(2030, 6, 1, 0, 0, 0, 0, time.Local)
+	tests := []struct {
+		name string
+		to   time.Time
+	}{
+		{name: "reversed", to: from.AddDate(0, 0, -1)},
+		{name: "longer than 31 days", to: from.AddDate(0, 0, maxAvailabilityDays)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Rejected before the database is queried
+			service := &ReservationService{}
+			if _, err := service.GetAvailability(context.Background(), from, tt.to, nil); !errors.Is(err, ErrInvalidRange) {
+				t.Fatalf("GetAvailability() error = %v, want ErrInvalidRange", err)
+			}
+		})
+	}
+}
+
+func TestCreateReservationUnscheduledSlot(t *testing.T) {
+	db := testdb.Open(t)
+	service := newTestReservationService(t, db, newTestGateway())
+	court, timeslot := testdb.CreateSlot(t, db)
+
+	_, _, err := service.CreateReservation(context.Background(), nil, court.ID, timeslot.ID, testdb.Date(), testCustomer, "")
+	if !errors.Is(err, ErrSlotNotScheduled) {
+		t.Fatalf("CreateReservation() of a slot without a schedule error = %v, want ErrSlotNotScheduled", err)
+	}
+}
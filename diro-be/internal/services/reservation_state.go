@@ -0,0 +1,125 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"diro-be/internal/models"
+)
+
+// ErrInvalidTransition is matched by every TransitionError
+var ErrInvalidTransition = errors.New("invalid reservation status transition")
+
+// TransitionError is returned when a reservation cannot move between two statuses
+type TransitionError struct {
+	From models.ReservationStatus
+	To   models.ReservationStatus
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("invalid reservation status transition from %q to %q", e.From, e.To)
+}
+
+// Is makes errors.Is(err, ErrInvalidTransition) match any TransitionError
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
+
+// Transition triggers
+const (
+	TriggerBooking      = "booking"
+	TriggerWebhook      = "webhook"
+	TriggerHoldExpiry   = "hold_expiry"
+	TriggerCancellation = "cancellation"
+	TriggerInvoiceSweep = "invoice_sweep"
+	TriggerReconcile    = "reconciliation"
+	TriggerRefund       = "refund"
+)
+
+// Transition actors
+const (
+	ActorSystem = "system"
+	ActorXendit = "xendit"
+)
+
+// reservationTransitions lists the statuses each status may move to
+var reservationTransitions = map[models.ReservationStatus][]models.ReservationStatus{
+	models.ReservationStatusPending: {
+		models.ReservationStatusPaid,
+		models.ReservationStatusExpired,
+		models.ReservationStatusFailed,
+		models.ReservationStatusCancelled,
+	},
+	// A payment that arrives after the hold lapsed still books the slot if
+	// nobody else took it in the meantime
+	models.ReservationStatusExpired: {
+		models.ReservationStatusPaid,
+	},
+	models.ReservationStatusPaid: {
+		models.ReservationStatusCancelled,
+		models.ReservationStatusPartiallyRefunded,
+		models.ReservationStatusRefunded,
+	},
+	// Cancelling a partly refunded reservation refunds at most what is left
+	models.ReservationStatusPartiallyRefunded: {
+		models.ReservationStatusCancelled,
+		models.ReservationStatusRefunded,
+	},
+}
+
+// CanTransition reports whether a reservation may move from one status to another
+func CanTransition(from, to models.ReservationStatus) bool {
+	for _, allowed := range reservationTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition describes a requested status change and what triggered it
+type Transition struct {
+	To            models.ReservationStatus
+	PaymentStatus string // Left unchanged when empty
+	Trigger       string
+	Actor         string
+	Reason        string
+}
+
+// applyTransition moves the reservation to t.To and returns the history entry
+// recording it, or a TransitionError if the move is not allowed
+func applyTransition(reservation *models.Reservation, t Transition) (*models.ReservationStatusHistory, error) {
+	from := reservation.Status
+	if !CanTransition(from, t.To) {
+		return nil, &TransitionError{From: from, To: t.To}
+	}
+
+	reservation.Status = t.To
+	if t.PaymentStatus != "" {
+		reservation.PaymentStatus = t.PaymentStatus
+	}
+
+	return &models.ReservationStatusHistory{
+		ReservationID: reservation.ID,
+		FromStatus:    from,
+		ToStatus:      t.To,
+		PaymentStatus: reservation.PaymentStatus,
+		Trigger:       t.Trigger,
+		Actor:         t.Actor,
+		Reason:        t.Reason,
+	}, nil
+}
+
+// statusForPayment maps a gateway invoice status to the reservation status it
+// leads to. It returns false for statuses that do not change the reservation.
+func statusForPayment(paymentStatus string) (models.ReservationStatus, bool) {
+	switch paymentStatus {
+	case models.PaymentStatusPaid, models.PaymentStatusSettled:
+		return models.ReservationStatusPaid, true
+	case models.PaymentStatusExpired:
+		return models.ReservationStatusExpired, true
+	case models.PaymentStatusFailed:
+		return models.ReservationStatusFailed, true
+	}
+	return "", false
+}
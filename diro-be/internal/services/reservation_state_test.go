@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"diro-be/internal/models"
+	"diro-be/internal/testdb"
+)
+
+// allStatuses lists every reservation status
+var allStatuses = []models.ReservationStatus{
+	models.ReservationStatusPending,
+	models.ReservationStatusPaid,
+	models.ReservationStatusExpired,
+	models.ReservationStatusFailed,
+	models.ReservationStatusCancelled,
+	models.ReservationStatusPartiallyRefunded,
+	models.ReservationStatusRefunded,
+}
+
+func TestApplyTransition(t *testing.T) {
+	allowed := []struct {
+		from models.ReservationStatus
+		to   models.ReservationStatus
+	}{
+		{models.ReservationStatusPending, models.ReservationStatusPaid},
+		{models.ReservationStatusPending, models.ReservationStatusExpired},
+		{models.ReservationStatusPending, models.ReservationStatusFailed},
+		{models.ReservationStatusPending, models.ReservationStatusCancelled},
+		{models.ReservationStatusExpired, models.ReservationStatusPaid},
+		{models.ReservationStatusPaid, models.ReservationStatusCancelled},
+		{models.ReservationStatusPaid, models.ReservationStatusPartiallyRefunded},
+		{models.ReservationStatusPaid, models.ReservationStatusRefunded},
+		{models.ReservationStatusPartiallyRefunded, models.ReservationStatusCancelled},
+		{models.ReservationStatusPartiallyRefunded, models.ReservationStatusRefunded},
+	}
+	isAllowed := func(from, to models.ReservationStatus) bool {
+		for _, transition := range allowed {
+			if transition.from == from && transition.to == to {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, from := range allStatuses {
+		for _, to := range allStatuses {
+			want := isAllowed(from, to)
+			t.Run(string(from)+" to "+string(to), func(t *testing.T) {
+				if got := CanTransition(from, to); got != want {
+					t.Fatalf("CanTransition() = %v, want %v", got, want)
+				}
+
+				reservation := &models.Reservation{ID: 7, Status: from, PaymentStatus: models.PaymentStatusPending}
+				history, err := applyTransition(reservation, Transition{
+					To:            to,
+					PaymentStatus: models.PaymentStatusPaid,
+					Trigger:       TriggerWebhook,
+					Actor:         ActorXendit,
+				})
+
+				if !want {
+					var transitionErr *TransitionError
+					if !errors.As(err, &transitionErr) {
+						t.Fatalf("applyTransition() error = %v, want a *TransitionError", err)
+					}
+					if transitionErr.From != from || transitionErr.To != to {
+						t.Fatalf("TransitionError = %v, want from %q to %q", transitionErr, from, to)
+					}
+					if history != nil {
+						t.Fatal("applyTransition() returned a history entry for an illegal transition")
+					}
+					if reservation.Status != from || reservation.PaymentStatus != models.PaymentStatusPending {
+						t.Fatalf("reservation changed to %q, %q by an illegal transition", reservation.Status, reservation.PaymentStatus)
+					}
+					return
+				}
+
+				if err != nil {
+					t.Fatalf("applyTransition() error = %v", err)
+				}
+				if reservation.Status != to || reservation.PaymentStatus != models.PaymentStatusPaid {
+					t.Fatalf("reservation is %q, %q, want %q, PAID", reservation.Status, reservation.PaymentStatus, to)
+				}
+				if history.ReservationID != 7 || history.FromStatus != from || history.ToStatus != to || history.Trigger != TriggerWebhook {
+					t.Fatalf("history = %+v, want reservation 7 from %q to %q by webhook", history, from, to)
+				}
+			})
+		}
+	}
+}
+
+func TestTransitionIllegalWritesNoHistory(t *testing.T) {
+	db := testdb.Open(t)
+	service := newTestReservationService(t, db, newTestGateway())
+	reservation := bookTestSlot(t, db, service)
+
+	err := service.transition(context.Background(), reservation, Transition{
+		To:      models.ReservationStatusRefunded,
+		Trigger: TriggerRefund,
+		Actor:   ActorSystem,
+	})
+	var transitionErr *TransitionError
+	if !errors.As(err, &transitionErr) {
+		t.Fatalf("transition() from pending to refunded error = %v, want a *TransitionError", err)
+	}
+
+	if status := reservationStatus(t, service, reservation.ID); status != models.ReservationStatusPending {
+		t.Fatalf("status = %q, want pending", status)
+	}
+	if count := historyCount(t, service, reservation.ID); count != 1 {
+		t.Fatalf("%d status changes recorded, want only the booking", count)
+	}
+}
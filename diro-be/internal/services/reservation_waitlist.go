@@ -0,0 +1,289 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+
+	"diro-be/internal/models"
+	"diro-be/internal/repositories"
+)
+
+// ErrWaitlistEntryNotFound is returned when a waitlist entry does not exist or
+// belongs to another customer
+var ErrWaitlistEntryNotFound = errors.New("waitlist entry not found")
+
+// ErrInvalidWaitlistEntry is returned when joining the waitlist for a slot
+// that cannot be booked at all
+var ErrInvalidWaitlistEntry = errors.New("invalid waitlist entry")
+
+// ErrAlreadyWaitlisted is returned when a customer is already in line for a slot
+var ErrAlreadyWaitlisted = errors.New("already on the waitlist for this slot")
+
+// ErrSlotAvailable is returned when joining the waitlist for a slot that can
+// be booked right away
+var ErrSlotAvailable = errors.New("slot is available, book it instead")
+
+// ErrSlotOffered is returned when booking a slot offered to a waitlisted
+// customer
+var ErrSlotOffered = errors.New("slot is offered to a waitlisted customer")
+
+// ErrNoOpenOffer is returned when accepting a waitlist entry without an offer
+// that has not lapsed
+var ErrNoOpenOffer = errors.New("waitlist entry has no open offer")
+
+// JoinWaitlist puts a customer in line for a fully booked slot on a court, or
+// on any court at that time when courtID is nil
+func (s *ReservationService) JoinWaitlist(ctx context.Context, userID uint, courtID *uint, timeslotID uint, date time.Time) (*models.WaitlistEntry, error) {
+	if dayStart(date).Before(dayStart(time.Now())) {
+		return nil, fmt.Errorf("%w: date is in the past", ErrInvalidWaitlistEntry)
+	}
+
+	availability, err := s.GetAvailability(ctx, date, date, courtID)
+	if err != nil {
+		return nil, err
+	}
+	scheduled := false
+	for _, court := range availability.Days[0].Courts {
+		for _, slot := range court.Timeslots {
+			if slot.Timeslot.ID != timeslotID {
+				continue
+			}
+			if !slot.IsBooked && !slot.IsHeld && !slot.IsBlackedOut {
+				return nil, ErrSlotAvailable
+			}
+			scheduled = true
+		}
+	}
+	if !scheduled {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidWaitlistEntry, ErrSlotNotScheduled)
+	}
+
+	exists, err := s.waitlistRepo.HasOpenEntry(ctx, userID, courtID, timeslotID, date)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrAlreadyWaitlisted
+	}
+
+	entry := &models.WaitlistEntry{
+		UserID:     userID,
+		CourtID:    courtID,
+		TimeslotID: timeslotID,
+		Date:       date,
+		Status:     models.WaitlistStatusWaiting,
+	}
+	if err := s.waitlistRepo.CreateEntry(ctx, entry); err != nil {
+		return nil, err
+	}
+	return s.waitlistRepo.GetEntryByID(ctx, entry.ID)
+}
+
+// ListCustomerWaitlist returns the waitlist entries of a customer
+func (s *ReservationService) ListCustomerWaitlist(ctx context.Context, userID uint) ([]models.WaitlistEntry, error) {
+	return s.waitlistRepo.ListUserEntries(ctx, userID)
+}
+
+// LeaveWaitlist takes a customer out of line. A slot on offer to them is
+// offered to the next customer in line.
+func (s *ReservationService) LeaveWaitlist(ctx context.Context, userID uint, entryID uint) (*models.WaitlistEntry, error) {
+	entry, err := s.getCustomerWaitlistEntry(ctx, userID, entryID)
+	if err != nil {
+		return nil, err
+	}
+
+	from := entry.Status
+	if from != models.WaitlistStatusWaiting && from != models.WaitlistStatusOffered {
+		return nil, fmt.Errorf("%w: entry is %s", ErrInvalidWaitlistEntry, from)
+	}
+
+	entry.Status = models.WaitlistStatusCancelled
+	if err := s.waitlistRepo.UpdateEntry(ctx, entry, from); err != nil {
+		return nil, err
+	}
+
+	if from == models.WaitlistStatusOffered {
+		if err := s.offerSlot(ctx, *entry.OfferedCourtID, entry.TimeslotID, entry.Date); err != nil {
+			slog.ErrorContext(ctx, "failed to offer slot released by waitlist entry", "waitlist_entry_id", entry.ID, "error", err.Error())
+		}
+	}
+	return entry, nil
+}
+
+// AcceptWaitlistOffer books the slot offered to a customer on a single
+// invoice, like any other booking
+func (s *ReservationService) AcceptWaitlistOffer(ctx context.Context, userID uint, entryID uint, customer models.XenditCustomer, promoCode string) ([]models.Reservation, string, error) {
+	entry, err := s.getCustomerWaitlistEntry(ctx, userID, entryID)
+	if err != nil {
+		return nil, "", err
+	}
+	if entry.Status != models.WaitlistStatusOffered || !entry.OfferExpiresAt.After(time.Now()) {
+		return nil, "", ErrNoOpenOffer
+	}
+
+	return s.book(ctx, &userID, []BookingItem{
+		{CourtID: *entry.OfferedCourtID, TimeslotID: entry.TimeslotID, Date: entry.Date},
+	}, customer, promoCode, nil)
+}
+
+// ExpireLapsedOffers expires the offers that lapsed before now, offering each
+// slot to the next customer in line, and the entries for past dates. It
+// returns how many offers lapsed.
+func (s *ReservationService) ExpireLapsedOffers(ctx context.Context, now time.Time) (int, error) {
+	lapsed, err := s.waitlistRepo.FindLapsedOffers(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for i := range lapsed {
+		entry := &lapsed[i]
+		entry.Status = models.WaitlistStatusExpired
+		err := s.waitlistRepo.UpdateEntry(ctx, entry, models.WaitlistStatusOffered)
+		if errors.Is(err, repositories.ErrStaleWaitlistEntry) {
+			continue
+		}
+		if err != nil {
+			return expired, err
+		}
+		expired++
+
+		if err := s.offerSlot(ctx, *entry.OfferedCourtID, entry.TimeslotID, entry.Date); err != nil {
+			return expired, err
+		}
+	}
+
+	if _, err := s.waitlistRepo.ExpirePastEntries(ctx, now); err != nil {
+		return expired, err
+	}
+	return expired, nil
+}
+
+// slotsReleased offers the slots of cancelled, expired, failed and refunded
+// reservations to the customers waiting for them. Failing to make an offer
+// does not undo the status change, so errors are only logged.
+func (s *ReservationService) slotsReleased(ctx context.Context, reservations ...*models.Reservation) {
+	// The slots are released already, so they are offered even if the
+	// request that released them is cancelled
+	ctx = context.WithoutCancel(ctx)
+	for _, reservation := range reservations {
+		switch reservation.Status {
+		case models.ReservationStatusCancelled, models.ReservationStatusExpired, models.ReservationStatusFailed, models.ReservationStatusRefunded:
+		default:
+			continue
+		}
+		if err := s.offerSlot(ctx, reservation.CourtID, reservation.TimeslotID, reservation.Date); err != nil {
+			slog.ErrorContext(ctx, "failed to offer slot to the waitlist", "reservation_id", reservation.ID, "error", err.Error())
+		}
+	}
+}
+
+// offerSlot offers a free slot to the next customer in line for it, unless it
+// is already on offer or has started
+func (s *ReservationService) offerSlot(ctx context.Context, courtID, timeslotID uint, date time.Time) error {
+	now := time.Now()
+	offers, err := s.waitlistRepo.FindOpenOffers(ctx, courtID, timeslotID, date, now)
+	if err != nil || len(offers) > 0 {
+		return err
+	}
+
+	available, err := s.reservationRepo.CheckSlotAvailability(ctx, courtID, timeslotID, date)
+	if err != nil || !available {
+		return err
+	}
+
+	for {
+		entry, err := s.waitlistRepo.NextInLine(ctx, courtID, timeslotID, date)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		start, err := slotStart(&models.Reservation{Date: date, Timeslot: entry.Timeslot})
+		if err != nil || !start.After(now) {
+			return err
+		}
+
+		expiresAt := now.Add(s.offerTTL)
+		if start.Before(expiresAt) {
+			expiresAt = start
+		}
+		entry.Status = models.WaitlistStatusOffered
+		entry.OfferedCourtID = &courtID
+		entry.OfferExpiresAt = &expiresAt
+		err = s.waitlistRepo.UpdateEntry(ctx, entry, models.WaitlistStatusWaiting)
+		if errors.Is(err, repositories.ErrStaleWaitlistEntry) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		slog.InfoContext(ctx, "offered slot to waitlist entry", "waitlist_entry_id", entry.ID,
+			"court_id", courtID, "timeslot_id", timeslotID, "date", date.Format("2006-01-02"), "offer_expires_at", expiresAt)
+		return nil
+	}
+}
+
+// takeOffers refuses slots on offer to someone other than the customer and
+// returns the offers the customer is taking up
+func (s *ReservationService) takeOffers(ctx context.Context, userID *uint, reservations []*models.Reservation, now time.Time) ([]models.WaitlistEntry, error) {
+	var taken []models.WaitlistEntry
+	for _, reservation := range reservations {
+		offers, err := s.waitlistRepo.FindOpenOffers(ctx, reservation.CourtID, reservation.TimeslotID, reservation.Date, now)
+		if err != nil {
+			return nil, err
+		}
+		for _, offer := range offers {
+			if userID == nil || offer.UserID != *userID {
+				return nil, ErrSlotOffered
+			}
+			taken = append(taken, offer)
+		}
+	}
+	return taken, nil
+}
+
+// markOffersBooked records the reservations booked from waitlist offers
+func (s *ReservationService) markOffersBooked(ctx context.Context, offers []models.WaitlistEntry, reservations []*models.Reservation) {
+	for i := range offers {
+		offer := &offers[i]
+		for _, reservation := range reservations {
+			if reservation.CourtID != *offer.OfferedCourtID || reservation.TimeslotID != offer.TimeslotID || !sameDay(reservation.Date, offer.Date) {
+				continue
+			}
+			offer.Status = models.WaitlistStatusBooked
+			offer.ReservationID = &reservation.ID
+			if err := s.waitlistRepo.UpdateEntry(ctx, offer, models.WaitlistStatusOffered); err != nil {
+				slog.ErrorContext(ctx, "failed to mark waitlist entry booked", "waitlist_entry_id", offer.ID, "reservation_id", reservation.ID, "error", err.Error())
+			}
+		}
+	}
+}
+
+// getCustomerWaitlistEntry returns a waitlist entry if it belongs to the customer
+func (s *ReservationService) getCustomerWaitlistEntry(ctx context.Context, userID uint, entryID uint) (*models.WaitlistEntry, error) {
+	entry, err := s.waitlistRepo.GetEntryByID(ctx, entryID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrWaitlistEntryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if entry.UserID != userID {
+		return nil, ErrWaitlistEntryNotFound
+	}
+	return entry, nil
+}
+
+// sameDay reports whether two dates fall on the same calendar day
+func sameDay(a, b time.Time) bool {
+	return a.Format("2006-01-02") == b.Format("2006-01-02")
+}
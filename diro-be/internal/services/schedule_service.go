@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"diro-be/internal/models"
+	"diro-be/internal/repositories"
+)
+
+// ErrScheduleNotFound is returned when a court schedule does not exist
+var ErrScheduleNotFound = errors.New("schedule not found")
+
+// ErrInvalidSchedule is returned when a court schedule fails validation
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// ScheduleService handles court operating schedules managed by venue staff
+type ScheduleService struct {
+	scheduleRepo *repositories.ScheduleRepository
+	courtRepo    *repositories.CourtRepository
+	timeslotRepo *repositories.TimeslotRepository
+}
+
+// NewScheduleService creates a new schedule service
+func NewScheduleService(scheduleRepo *repositories.ScheduleRepository, courtRepo *repositories.CourtRepository, timeslotRepo *repositories.TimeslotRepository) *ScheduleService {
+	return &ScheduleService{
+		scheduleRepo: scheduleRepo,
+		courtRepo:    courtRepo,
+		timeslotRepo: timeslotRepo,
+	}
+}
+
+// ListCourtSchedules returns the schedules of a court
+func (s *ScheduleService) ListCourtSchedules(ctx context.Context, courtID uint) ([]models.CourtSchedule, error) {
+	if _, err := s.getCourt(ctx, courtID); err != nil {
+		return nil, err
+	}
+	return s.scheduleRepo.ListCourtSchedules(ctx, courtID)
+}
+
+// GetSchedule returns a schedule by ID
+func (s *ScheduleService) GetSchedule(ctx context.Context, id uint) (*models.CourtSchedule, error) {
+	schedule, err := s.scheduleRepo.GetScheduleByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrScheduleNotFound
+	}
+	return schedule, err
+}
+
+// CreateSchedule makes a timeslot bookable on a court on one weekday from
+// effectiveFrom until effectiveTo, or indefinitely if effectiveTo is nil
+func (s *ScheduleService) CreateSchedule(ctx context.Context, courtID uint, weekday int, timeslotID uint, effectiveFrom time.Time, effectiveTo *time.Time) (*models.CourtSchedule, error) {
+	if _, err := s.getCourt(ctx, courtID); err != nil {
+		return nil, err
+	}
+
+	schedule := &models.CourtSchedule{
+		CourtID:       courtID,
+		Weekday:       weekday,
+		TimeslotID:    timeslotID,
+		EffectiveFrom: effectiveFrom,
+		EffectiveTo:   effectiveTo,
+	}
+	if err := s.validateSchedule(ctx, schedule); err != nil {
+		return nil, err
+	}
+
+	if err := s.scheduleRepo.CreateSchedule(ctx, schedule); err != nil {
+		return nil, err
+	}
+	return s.GetSchedule(ctx, schedule.ID)
+}
+
+// UpdateSchedule replaces the weekday, timeslot and effective dates of a
+// schedule. Setting effectiveTo is how a schedule is ended.
+func (s *ScheduleService) UpdateSchedule(ctx context.Context, id uint, weekday int, timeslotID uint, effectiveFrom time.Time, effectiveTo *time.Time) (*models.CourtSchedule, error) {
+	schedule, err := s.GetSchedule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule.Weekday = weekday
+	schedule.TimeslotID = timeslotID
+	schedule.EffectiveFrom = effectiveFrom
+	schedule.EffectiveTo = effectiveTo
+	if err := s.validateSchedule(ctx, schedule); err != nil {
+		return nil, err
+	}
+
+	if err := s.scheduleRepo.UpdateSchedule(ctx, schedule); err != nil {
+		return nil, err
+	}
+	return s.GetSchedule(ctx, schedule.ID)
+}
+
+// DeleteSchedule deletes a schedule. Existing reservations are kept.
+func (s *ScheduleService) DeleteSchedule(ctx context.Context, id uint) error {
+	if _, err := s.GetSchedule(ctx, id); err != nil {
+		return err
+	}
+	return s.scheduleRepo.DeleteSchedule(ctx, id)
+}
+
+// getCourt returns a court that is not deleted
+func (s *ScheduleService) getCourt(ctx context.Context, courtID uint) (*models.Court, error) {
+	court, err := s.courtRepo.GetCourtByID(ctx, courtID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCourtNotFound
+	}
+	return court, err
+}
+
+// validateSchedule checks the weekday, the timeslot, the effective dates and
+// that the schedule does not overlap another schedule of the same court,
+// weekday and timeslot
+func (s *ScheduleService) validateSchedule(ctx context.Context, schedule *models.CourtSchedule) error {
+	if schedule.Weekday < int(time.Sunday) || schedule.Weekday > int(time.Saturday) {
+		return fmt.Errorf("%w: weekday must be between 0 (Sunday) and 6 (Saturday)", ErrInvalidSchedule)
+	}
+	if schedule.EffectiveTo != nil && schedule.EffectiveTo.Before(schedule.EffectiveFrom) {
+		return fmt.Errorf("%w: effective_to must not be before effective_from", ErrInvalidSchedule)
+	}
+
+	if _, err := s.timeslotRepo.GetTimeslotByID(ctx, schedule.TimeslotID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: timeslot %d does not exist", ErrInvalidSchedule, schedule.TimeslotID)
+		}
+		return err
+	}
+
+	schedules, err := s.scheduleRepo.ListCourtSchedules(ctx, schedule.CourtID)
+	if err != nil {
+		return err
+	}
+	for _, other := range schedules {
+		if other.ID == schedule.ID || other.Weekday != schedule.Weekday || other.TimeslotID != schedule.TimeslotID {
+			continue
+		}
+		if schedulesOverlap(schedule, &other) {
+			return fmt.Errorf("%w: overlaps schedule %d", ErrInvalidSchedule, other.ID)
+		}
+	}
+	return nil
+}
+
+// schedulesOverlap reports whether the effective date ranges of two
+// schedules share at least one day
+func schedulesOverlap(a, b *models.CourtSchedule) bool {
+	aEndsBeforeB := a.EffectiveTo != nil && a.EffectiveTo.Before(b.EffectiveFrom)
+	bEndsBeforeA := b.EffectiveTo != nil && b.EffectiveTo.Before(a.EffectiveFrom)
+	return !aEndsBeforeB && !bEndsBeforeA
+}
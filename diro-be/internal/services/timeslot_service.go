@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"gorm.io/gorm"
+
+	"diro-be/internal/models"
+	"diro-be/internal/repositories"
+)
+
+// ErrTimeslotNotFound is returned when a timeslot does not exist
+var ErrTimeslotNotFound = errors.New("timeslot not found")
+
+// ErrInvalidTimeslot is returned when timeslot times fail validation
+var ErrInvalidTimeslot = errors.New("invalid timeslot")
+
+// clockPattern matches a 24-hour "HH:MM" time
+var clockPattern = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+// TimeslotInUseError is returned when changing or retiring a timeslot that
+// still has paid or held reservations in the future
+type TimeslotInUseError struct {
+	TimeslotID   uint
+	Reservations []models.Reservation
+}
+
+func (e *TimeslotInUseError) Error() string {
+	return fmt.Sprintf("timeslot %d has %d future reservations", e.TimeslotID, len(e.Reservations))
+}
+
+// TimeslotService handles timeslot management by venue staff
+type TimeslotService struct {
+	timeslotRepo    *repositories.TimeslotRepository
+	reservationRepo *repositories.ReservationRepository
+}
+
+// NewTimeslotService creates a new timeslot service
+func NewTimeslotService(timeslotRepo *repositories.TimeslotRepository, reservationRepo *repositories.ReservationRepository) *TimeslotService {
+	return &TimeslotService{
+		timeslotRepo:    timeslotRepo,
+		reservationRepo: reservationRepo,
+	}
+}
+
+// ListTimeslots returns every timeslot, including retired ones
+func (s *TimeslotService) ListTimeslots(ctx context.Context) ([]models.Timeslot, error) {
+	return s.timeslotRepo.ListTimeslots(ctx, true)
+}
+
+// GetTimeslot returns a timeslot by ID
+func (s *TimeslotService) GetTimeslot(ctx context.Context, id uint) (*models.Timeslot, error) {
+	timeslot, err := s.timeslotRepo.GetTimeslotByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTimeslotNotFound
+	}
+	return timeslot, err
+}
+
+// CreateTimeslot creates a new active timeslot that must not overlap any
+// other active timeslot
+func (s *TimeslotService) CreateTimeslot(ctx context.Context, startTime, endTime string) (*models.Timeslot, error) {
+	timeslot := &models.Timeslot{
+		StartTime: startTime,
+		EndTime:   endTime,
+		IsActive:  true,
+	}
+	if err := s.validateTimeslot(ctx, timeslot); err != nil {
+		return nil, err
+	}
+
+	if err := s.timeslotRepo.CreateTimeslot(ctx, timeslot); err != nil {
+		return nil, err
+	}
+	return timeslot, nil
+}
+
+// UpdateTimeslot changes the times of a timeslot. Moving a timeslot with
+// future reservations fails with a TimeslotInUseError listing them unless
+// force is set, in which case they are returned as affected.
+func (s *TimeslotService) UpdateTimeslot(ctx context.Context, id uint, startTime, endTime string, force bool) (*models.Timeslot, []models.Reservation, error) {
+	timeslot, err := s.GetTimeslot(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	timeslot.StartTime = startTime
+	timeslot.EndTime = endTime
+	if err := s.validateTimeslot(ctx, timeslot); err != nil {
+		return nil, nil, err
+	}
+
+	affected, err := s.checkFutureReservations(ctx, timeslot.ID, force)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.timeslotRepo.UpdateTimeslot(ctx, timeslot); err != nil {
+		return nil, nil, err
+	}
+	return timeslot, affected, nil
+}
+
+// RetireTimeslot deactivates a timeslot so it can no longer be booked, with
+// the same future reservation check as UpdateTimeslot
+func (s *TimeslotService) RetireTimeslot(ctx context.Context, id uint, force bool) (*models.Timeslot, []models.Reservation, error) {
+	timeslot, err := s.GetTimeslot(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	affected, err := s.checkFutureReservations(ctx, timeslot.ID, force)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	timeslot.IsActive = false
+	if err := s.timeslotRepo.UpdateTimeslot(ctx, timeslot); err != nil {
+		return nil, nil, err
+	}
+	return timeslot, affected, nil
+}
+
+// validateTimeslot checks the time format, that the timeslot ends after it
+// starts and that an active timeslot does not overlap another active one
+func (s *TimeslotService) validateTimeslot(ctx context.Context, timeslot *models.Timeslot) error {
+	if !clockPattern.MatchString(timeslot.StartTime) || !clockPattern.MatchString(timeslot.EndTime) {
+		return fmt.Errorf("%w: times must use the HH:MM format", ErrInvalidTimeslot)
+	}
+	// Zero-padded HH:MM strings compare in chronological order
+	if timeslot.EndTime <= timeslot.StartTime {
+		return fmt.Errorf("%w: end time must be after start time", ErrInvalidTimeslot)
+	}
+
+	if !timeslot.IsActive {
+		return nil
+	}
+
+	timeslots, err := s.timeslotRepo.ListTimeslots(ctx, false)
+	if err != nil {
+		return err
+	}
+	for _, other := range timeslots {
+		if other.ID == timeslot.ID {
+			continue
+		}
+		if timeslot.StartTime < other.EndTime && other.StartTime < timeslot.EndTime {
+			return fmt.Errorf("%w: overlaps timeslot %d (%s-%s)", ErrInvalidTimeslot, other.ID, other.StartTime, other.EndTime)
+		}
+	}
+	return nil
+}
+
+// checkFutureReservations returns the timeslot's future reservations, or a
+// TimeslotInUseError if there are any and force is not set
+func (s *TimeslotService) checkFutureReservations(ctx context.Context, timeslotID uint, force bool) ([]models.Reservation, error) {
+	reservations, err := s.reservationRepo.FindFutureActiveReservationsForTimeslot(ctx, timeslotID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if len(reservations) > 0 && !force {
+		return nil, &TimeslotInUseError{TimeslotID: timeslotID, Reservations: reservations}
+	}
+	return reservations, nil
+}
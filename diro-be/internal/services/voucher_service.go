@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"diro-be/internal/models"
+	"diro-be/internal/repositories"
+)
+
+// ErrVoucherNotFound is returned when a voucher does not exist
+var ErrVoucherNotFound = errors.New("voucher not found")
+
+// ErrInvalidVoucher is returned when voucher details fail validation
+var ErrInvalidVoucher = errors.New("invalid voucher")
+
+// ErrInvalidPromoCode is returned when a promo code does not exist or does not
+// apply to the booking
+var ErrInvalidPromoCode = errors.New("invalid promo code")
+
+// VoucherService handles promo code vouchers
+type VoucherService struct {
+	voucherRepo  *repositories.VoucherRepository
+	courtRepo    *repositories.CourtRepository
+	timeslotRepo *repositories.TimeslotRepository
+}
+
+// NewVoucherService creates a new voucher service
+func NewVoucherService(voucherRepo *repositories.VoucherRepository, courtRepo *repositories.CourtRepository, timeslotRepo *repositories.TimeslotRepository) *VoucherService {
+	return &VoucherService{
+		voucherRepo:  voucherRepo,
+		courtRepo:    courtRepo,
+		timeslotRepo: timeslotRepo,
+	}
+}
+
+// ListVouchers returns every voucher
+func (s *VoucherService) ListVouchers(ctx context.Context) ([]models.Voucher, error) {
+	return s.voucherRepo.ListVouchers(ctx)
+}
+
+// GetVoucher returns a voucher by ID
+func (s *VoucherService) GetVoucher(ctx context.Context, id uint) (*models.Voucher, error) {
+	voucher, err := s.voucherRepo.GetVoucherByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrVoucherNotFound
+	}
+	return voucher, err
+}
+
+// CreateVoucher validates and creates a voucher
+func (s *VoucherService) CreateVoucher(ctx context.Context, voucher *models.Voucher) (*models.Voucher, error) {
+	voucher.ID = 0
+	if err := s.validateVoucher(ctx, voucher); err != nil {
+		return nil, err
+	}
+
+	if err := s.voucherRepo.CreateVoucher(ctx, voucher); err != nil {
+		return nil, err
+	}
+	return voucher, nil
+}
+
+// UpdateVoucher replaces the details of a voucher. Discounts already granted
+// on reservations are not affected.
+func (s *VoucherService) UpdateVoucher(ctx context.Context, id uint, voucher *models.Voucher) (*models.Voucher, error) {
+	existing, err := s.GetVoucher(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	voucher.ID = existing.ID
+	voucher.CreatedAt = existing.CreatedAt
+	if err := s.validateVoucher(ctx, voucher); err != nil {
+		return nil, err
+	}
+
+	if err := s.voucherRepo.UpdateVoucher(ctx, voucher); err != nil {
+		return nil, err
+	}
+	return voucher, nil
+}
+
+// ApplyPromoCode returns the voucher for a promo code and the discount it
+// grants on booking the slot at the given price. Usage caps are enforced when
+// the reservation is stored.
+func (s *VoucherService) ApplyPromoCode(ctx context.Context, code string, userID *uint, courtID, timeslotID uint, date time.Time, price float64) (*models.Voucher, float64, error) {
+	voucher, err := s.voucherRepo.GetVoucherByCode(ctx, normalizePromoCode(code))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, 0, ErrInvalidPromoCode
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	timeslot, err := s.timeslotRepo.GetTimeslotByID(ctx, timeslotID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	now := time.Now()
+	switch {
+	case !voucher.IsActive:
+		return nil, 0, ErrInvalidPromoCode
+	case voucher.ValidFrom != nil && now.Before(*voucher.ValidFrom):
+		return nil, 0, fmt.Errorf("%w: not valid yet", ErrInvalidPromoCode)
+	case voucher.ValidUntil != nil && now.After(*voucher.ValidUntil):
+		return nil, 0, fmt.Errorf("%w: expired", ErrInvalidPromoCode)
+	case voucher.MaxUsesPerCustomer > 0 && userID == nil:
+		return nil, 0, fmt.Errorf("%w: sign in to use this code", ErrInvalidPromoCode)
+	case voucher.CourtID != nil && *voucher.CourtID != courtID:
+		return nil, 0, fmt.Errorf("%w: not valid for this court", ErrInvalidPromoCode)
+	case voucher.StartTime != "" && timeslot.StartTime < voucher.StartTime,
+		voucher.EndTime != "" && timeslot.StartTime >= voucher.EndTime:
+		return nil, 0, fmt.Errorf("%w: not valid for this time", ErrInvalidPromoCode)
+	}
+
+	discount := voucher.DiscountValue
+	if voucher.DiscountType == models.DiscountTypePercentage {
+		discount = math.Round(price * voucher.DiscountValue / 100)
+		if voucher.MaxDiscount > 0 && discount > voucher.MaxDiscount {
+			discount = voucher.MaxDiscount
+		}
+	}
+	if discount >= price {
+		return nil, 0, fmt.Errorf("%w: discount exceeds the price", ErrInvalidPromoCode)
+	}
+
+	return voucher, discount, nil
+}
+
+// validateVoucher normalizes the code and checks the discount and restrictions
+func (s *VoucherService) validateVoucher(ctx context.Context, voucher *models.Voucher) error {
+	voucher.Code = normalizePromoCode(voucher.Code)
+	voucher.Description = strings.TrimSpace(voucher.Description)
+	if voucher.Code == "" {
+		return fmt.Errorf("%w: code is required", ErrInvalidVoucher)
+	}
+
+	switch voucher.DiscountType {
+	case models.DiscountTypePercentage:
+		if voucher.DiscountValue <= 0 || voucher.DiscountValue >= 100 {
+			return fmt.Errorf("%w: percentage discount must be between 0 and 100", ErrInvalidVoucher)
+		}
+	case models.DiscountTypeFixed:
+		if voucher.DiscountValue <= 0 {
+			return fmt.Errorf("%w: fixed discount must be positive", ErrInvalidVoucher)
+		}
+	default:
+		return fmt.Errorf("%w: discount_type must be %q or %q", ErrInvalidVoucher, models.DiscountTypePercentage, models.DiscountTypeFixed)
+	}
+
+	if voucher.MaxDiscount < 0 || voucher.MaxUses < 0 || voucher.MaxUsesPerCustomer < 0 {
+		return fmt.Errorf("%w: caps must not be negative", ErrInvalidVoucher)
+	}
+	if voucher.ValidFrom != nil && voucher.ValidUntil != nil && voucher.ValidUntil.Before(*voucher.ValidFrom) {
+		return fmt.Errorf("%w: valid_until must not be before valid_from", ErrInvalidVoucher)
+	}
+	if (voucher.StartTime != "" && !clockPattern.MatchString(voucher.StartTime)) || (voucher.EndTime != "" && !clockPattern.MatchString(voucher.EndTime)) {
+		return fmt.Errorf("%w: times must use the HH:MM format", ErrInvalidVoucher)
+	}
+	if voucher.StartTime != "" && voucher.EndTime != "" && voucher.EndTime <= voucher.StartTime {
+		return fmt.Errorf("%w: end time must be after start time", ErrInvalidVoucher)
+	}
+
+	if voucher.CourtID != nil {
+		if _, err := s.courtRepo.GetCourtByID(ctx, *voucher.CourtID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("%w: court %d does not exist", ErrInvalidVoucher, *voucher.CourtID)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// normalizePromoCode makes promo codes case insensitive
+func normalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"diro-be/internal/models"
+	"diro-be/internal/repositories"
+	"diro-be/internal/testdb"
+)
+
+func TestApplyPromoCode(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	court, timeslot := testdb.CreateSlot(t, db)
+	courtRepo := repositories.NewCourtRepository(db)
+	vouchers := NewVoucherService(repositories.NewVoucherRepository(db), courtRepo, repositories.NewTimeslotRepository(db))
+
+	stamp := time.Now().Format("150405.000000000")
+	now := time.Now()
+	later, earlier := now.Add(time.Hour), now.Add(-time.Hour)
+	otherCourt := court.ID + 1000000
+	userID := uint(1)
+
+	tests := []struct {
+		name         string
+		voucher      models.Voucher
+		userID       *uint
+		courtID      uint
+		wantDiscount float64
+		wantErr      bool
+	}{
+		{name: "fixed", voucher: models.Voucher{DiscountType: models.DiscountTypeFixed, DiscountValue: 10000, IsActive: true}, wantDiscount: 10000},
+		{name: "percentage", voucher: models.Voucher{DiscountType: models.DiscountTypePercentage, DiscountValue: 15, IsActive: true}, wantDiscount: 7500},
+		{name: "percentage capped", voucher: models.Voucher{DiscountType: models.DiscountTypePercentage, DiscountValue: 20, MaxDiscount: 5000, IsActive: true}, wantDiscount: 5000},
+		{name: "within validity", voucher: models.Voucher{DiscountType: models.DiscountTypeFixed, DiscountValue: 1000, ValidFrom: &earlier, ValidUntil: &later, IsActive: true}, wantDiscount: 1000},
+		{name: "inactive", voucher: models.Voucher{DiscountType: models.DiscountTypeFixed, DiscountValue: 1000, IsActive: false}, wantErr: true},
+		{name: "not valid yet", voucher: models.Voucher{DiscountType: models.DiscountTypeFixed, DiscountValue: 1000, ValidFrom: &later, IsActive: true}, wantErr: true},
+		{name: "expired", voucher: models.Voucher{DiscountType: models.DiscountTypeFixed, DiscountValue: 1000, ValidUntil: &earlier, IsActive: true}, wantErr: true},
+		{name: "per customer cap for a guest", voucher: models.Voucher{DiscountType: models.DiscountTypeFixed, DiscountValue: 1000, MaxUsesPerCustomer: 1, IsActive: true}, wantErr: true},
+		{name: "per customer cap for a customer", voucher: models.Voucher{DiscountType: models.DiscountTypeFixed, DiscountValue: 1000, MaxUsesPerCustomer: 1, IsActive: true}, userID: &userID, wantDiscount: 1000},
+		{name: "other court", voucher: models.Voucher{DiscountType: models.DiscountTypeFixed, DiscountValue: 1000, IsActive: true}, courtID: otherCourt, wantErr: true},
+		{name: "before its hours", voucher: models.Voucher{DiscountType: models.DiscountTypeFixed, DiscountValue: 1000, StartTime: "20:00", IsActive: true}, wantErr: true},
+		{name: "after its hours", voucher: models.Voucher{DiscountType: models.DiscountTypeFixed, DiscountValue: 1000, EndTime: "12:00", IsActive: true}, wantErr: true},
+		{name: "discount not below the price", voucher: models.Voucher{DiscountType: models.DiscountTypeFixed, DiscountValue: 50000, IsActive: true}, wantErr: true},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			voucher := tt.voucher
+			voucher.Code = "test" + stamp + "-" + string(rune('a'+i))
+			voucher.CourtID = &court.ID
+			if _, err := vouchers.CreateVoucher(ctx, &voucher); err != nil {
+				t.Fatalf("CreateVoucher() error = %v", err)
+			}
+			stored, err := vouchers.GetVoucher(ctx, voucher.ID)
+			if err != nil {
+				t.Fatalf("GetVoucher() error = %v", err)
+			}
+			if stored.IsActive != tt.voucher.IsActive {
+				t.Fatalf("voucher is stored with is_active %v, want %v", stored.IsActive, tt.voucher.IsActive)
+			}
+
+			courtID := court.ID
+			if tt.courtID != 0 {
+				courtID = tt.courtID
+			}
+			// Codes are case insensitive
+			applied, discount, err := vouchers.ApplyPromoCode(ctx, strings.ToLower(voucher.Code), tt.userID, courtID, timeslot.ID, testdb.Date(), 50000)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidPromoCode) {
+					t.Fatalf("ApplyPromoCode() error = %v, want ErrInvalidPromoCode", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplyPromoCode() error = %v", err)
+			}
+			if applied.ID != voucher.ID || discount != tt.wantDiscount {
+				t.Fatalf("ApplyPromoCode() = voucher %d with discount %v, want voucher %d with %v", applied.ID, discount, voucher.ID, tt.wantDiscount)
+			}
+		})
+	}
+}
+
+func TestPromoCodePerCustomerCap(t *testing.T) {
+	db := testdb.Open(t)
+	ctx := context.Background()
+	service := newTestReservationService(t, db, newTestGateway())
+	court, timeslot := testdb.CreateSlot(t, db)
+	testdb.ScheduleDaily(t, db, court, timeslot)
+
+	user := createTestUser(t, db)
+	stamp := time.Now().Format("20060102150405.000000000")
+	voucher, err := service.voucherService.CreateVoucher(ctx, &models.Voucher{
+		Code:               "ONCE" + stamp,
+		DiscountType:       models.DiscountTypeFixed,
+		DiscountValue:      5000,
+		MaxUsesPerCustomer: 1,
+		CourtID:            &court.ID,
+		IsActive:           true,
+	})
+	if err != nil {
+		t.Fatalf("CreateVoucher() error = %v", err)
+	}
+
+	date := testdb.Date()
+	first, _, err := service.CreateReservation(ctx, &user.ID, court.ID, timeslot.ID, date, testCustomer, voucher.Code)
+	if err != nil {
+		t.Fatalf("CreateReservation() error = %v", err)
+	}
+	if first.DiscountAmount != 5000 || first.VoucherID == nil || *first.VoucherID != voucher.ID {
+		t.Fatalf("reservation has a discount of %v, want 5000 from voucher %d", first.DiscountAmount, voucher.ID)
+	}
+
+	_, _, err = service.CreateReservation(ctx, &user.ID, court.ID, timeslot.ID, date.AddDate(0, 0, 1), testCustomer, voucher.Code)
+	if !errors.Is(err, repositories.ErrVoucherUsedUp) {
+		t.Fatalf("CreateReservation() using the code a second time error = %v, want ErrVoucherUsedUp", err)
+	}
+}
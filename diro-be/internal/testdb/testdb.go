@@ -0,0 +1,92 @@
+// Package testdb connects tests to a MySQL test database. Tests using it are
+// skipped unless TEST_DATABASE_DSN is set, for example to
+// root:@tcp(localhost:3306)/diro_test?charset=utf8mb4&parseTime=True&loc=Local
+package testdb
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	mysqlgorm "gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"diro-be/internal/database"
+	"diro-be/internal/models"
+)
+
+// DSNEnv is the environment variable holding the test database DSN
+const DSNEnv = "TEST_DATABASE_DSN"
+
+var (
+	migrateOnce sync.Once
+	migrateErr  error
+)
+
+// Open connects to the test database and migrates its schema, or skips the
+// test when no test database is configured. Tests share the database, so they
+// create the rows they need instead of relying on it being empty.
+func Open(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv(DSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", DSNEnv)
+	}
+
+	db, err := gorm.Open(mysqlgorm.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	migrateOnce.Do(func() { migrateErr = database.Migrate(db) })
+	if migrateErr != nil {
+		t.Fatalf("failed to migrate test database: %v", migrateErr)
+	}
+
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// CreateSlot creates a court and a timeslot of their own for a test
+func CreateSlot(t *testing.T, db *gorm.DB) (*models.Court, *models.Timeslot) {
+	t.Helper()
+
+	court := &models.Court{Name: "Test court " + time.Now().Format(time.RFC3339Nano), IsActive: true}
+	if err := db.Create(court).Error; err != nil {
+		t.Fatalf("failed to create court: %v", err)
+	}
+	timeslot := &models.Timeslot{StartTime: "19:00", EndTime: "20:00", IsActive: true}
+	if err := db.Create(timeslot).Error; err != nil {
+		t.Fatalf("failed to create timeslot: %v", err)
+	}
+	return court, timeslot
+}
+
+// ScheduleDaily makes the timeslot bookable on the court every day of the week
+func ScheduleDaily(t *testing.T, db *gorm.DB, court *models.Court, timeslot *models.Timeslot) {
+	t.Helper()
+
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		schedule := &models.CourtSchedule{
+			CourtID:       court.ID,
+			Weekday:       int(weekday),
+			TimeslotID:    timeslot.ID,
+			EffectiveFrom: time.Date(1970, 1, 1, 0, 0, 0, 0, time.Local),
+		}
+		if err := db.Omit("Timeslot").Create(schedule).Error; err != nil {
+			t.Fatalf("failed to create schedule: %v", err)
+		}
+	}
+}
+
+// Date returns a date far enough ahead that no hold or slot start interferes
+func Date() time.Time {
+	year, month, day := time.Now().AddDate(1, 0, 0).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+}
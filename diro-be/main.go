@@ -1,11 +1,14 @@
 package main
 
 import (
-	"log"
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 
@@ -13,10 +16,15 @@ import (
 
 	"diro-be/internal/config"
 	"diro-be/internal/database"
+	"diro-be/internal/logging"
 	"diro-be/internal/repositories"
 	"diro-be/internal/routes"
+	"diro-be/internal/services"
 )
 
+// readHeaderTimeout is how long a client may take to send the request headers
+const readHeaderTimeout = 10 * time.Second
+
 // @title Diro API
 // @version 1.0
 // @description API untuk sistem reservasi lapangan olahraga Diro
@@ -37,39 +45,101 @@ import (
 // @name Authorization
 
 func main() {
-	// Load .env file
-	if err := godotenv.Load(); err != nil {
-		log.Println("Warning: .env file not found or could not be loaded")
-	}
+	// Load .env.<APP_ENV> and .env files
+	envErr := config.LoadEnvFiles()
 
-	// Load configuration
+	// Load configuration, log structured lines and keep its secrets out of
+	// every log
 	cfg := config.LoadConfig()
+	logging.Setup(logging.Options{Level: cfg.LogLevel, Format: cfg.LogFormat, Secrets: cfg.Secrets()})
+	gin.DefaultWriter = logging.NewWriter(os.Stdout)
+	gin.DefaultErrorWriter = logging.NewWriter(os.Stderr)
+	if envErr != nil {
+		slog.Warn(".env file not found or could not be loaded")
+	}
+	if cfg.JWTSecret == "" {
+		logging.Fatal("JWT_SECRET must be set")
+	}
+	if err := cfg.Validate(); err != nil {
+		logging.Fatal(err.Error())
+	}
 
 	// Connect to database
 	if err := database.Connect(cfg); err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		logging.Fatal("failed to connect to database", "error", err.Error())
 	}
 	defer database.Close()
 
-	// Initialize Gin router
-	router := gin.Default()
+	// Initialize Gin router; SetupRoutes adds the request ID, logger and
+	// recovery middleware
+	router := gin.New()
 
 	// CORS middleware
 	router.Use(cors.Default())
 
 	// Initialize repositories
 	reservationRepo := repositories.NewReservationRepository(database.DB)
+	userRepo := repositories.NewUserRepository(database.DB)
+	courtRepo := repositories.NewCourtRepository(database.DB)
+	timeslotRepo := repositories.NewTimeslotRepository(database.DB)
+	scheduleRepo := repositories.NewScheduleRepository(database.DB)
+	pricingRepo := repositories.NewPricingRepository(database.DB)
+	voucherRepo := repositories.NewVoucherRepository(database.DB)
+	blackoutRepo := repositories.NewBlackoutRepository(database.DB)
+	waitlistRepo := repositories.NewWaitlistRepository(database.DB)
+	refundRepo := repositories.NewRefundRepository(database.DB)
+
+	// Initialize payment gateway
+	paymentSettings := services.PaymentSettings{
+		BaseURL:         cfg.XenditBaseURL,
+		APIVersion:      cfg.XenditAPIVersion,
+		Currency:        cfg.PaymentCurrency,
+		InvoiceDuration: cfg.SlotHoldDuration,
+		SuccessURL:      cfg.PaymentSuccessURL,
+		FailureURL:      cfg.PaymentFailureURL,
+		ItemURL:         cfg.FrontendBaseURL,
+		Timeout:         cfg.XenditTimeout,
+	}
+	var paymentGateway services.PaymentGateway
+	if cfg.PaymentGateway == "fake" {
+		slog.Info("Using the offline fake payment gateway")
+		paymentGateway = services.NewFakePaymentGateway(cfg.AppBaseURL, cfg.XenditCallbackToken, paymentSettings)
+	} else {
+		paymentGateway = services.NewPaymentService(cfg.XenditUsername, cfg.XenditPassword, paymentSettings)
+	}
+
+	// Initialize services
+	pricingService := services.NewPricingService(pricingRepo, courtRepo, timeslotRepo, cfg.DefaultSlotPrice)
+	voucherService := services.NewVoucherService(voucherRepo, courtRepo, timeslotRepo)
+	blackoutService := services.NewBlackoutService(blackoutRepo, courtRepo, timeslotRepo, reservationRepo)
+	reservationService := services.NewReservationService(reservationRepo, scheduleRepo, timeslotRepo, pricingService, voucherService, blackoutService, waitlistRepo, refundRepo, paymentGateway, services.CancellationPolicy{
+		FullRefundBefore:     cfg.CancelFullRefundBefore,
+		PartialRefundPercent: cfg.CancelPartialRefundPercent,
+	}, cfg.SlotHoldDuration, cfg.WaitlistOfferTTL)
+	authService := services.NewAuthService(userRepo, cfg.JWTSecret, cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
+	courtService := services.NewCourtService(courtRepo, reservationRepo)
+	timeslotService := services.NewTimeslotService(timeslotRepo, reservationRepo)
+	scheduleService := services.NewScheduleService(scheduleRepo, courtRepo, timeslotRepo)
 
 	// Setup routes
-	routes.SetupRoutes(router, cfg, reservationRepo)
+	routes.SetupRoutes(router, cfg, reservationService, authService, courtService, timeslotService, scheduleService, pricingService, voucherService, blackoutService, paymentGateway)
+
+	// Release slot holds that were not paid in time
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	services.NewHoldExpirer(reservationService, cfg.HoldExpiryInterval, cfg.SweepTimeout).Start(ctx)
 
 	// Swagger routes
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// Start server
-	log.Println("Server starting on port 8080...")
-	log.Println("Swagger documentation available at: http://localhost:8080/swagger/index.html")
-	if err := router.Run(":8080"); err != nil {
-		log.Fatal("Failed to start server:", err)
+	slog.Info("Server starting", "port", 8080, "swagger", "http://localhost:8080/swagger/index.html")
+	server := &http.Server{
+		Addr:              ":8080",
+		Handler:           router,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+	if err := server.ListenAndServe(); err != nil {
+		logging.Fatal("failed to start server", "error", err.Error())
 	}
 }
@@ -0,0 +1,12 @@
+-- Create users table
+CREATE TABLE users (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    email VARCHAR(191) NOT NULL,
+    mobile_number VARCHAR(50),
+    password_hash VARCHAR(255) NOT NULL, -- bcrypt hash
+    role VARCHAR(20) DEFAULT 'customer', -- customer, admin
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE INDEX idx_users_email (email)
+);
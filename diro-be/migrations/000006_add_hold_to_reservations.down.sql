@@ -0,0 +1,5 @@
+-- Migration: add_hold_to_reservations
+-- Created at:
+
+DROP INDEX idx_reservations_hold_expires_at ON reservations;
+ALTER TABLE reservations DROP COLUMN hold_expires_at;
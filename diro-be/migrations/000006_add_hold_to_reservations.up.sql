@@ -0,0 +1,10 @@
+-- Migration: add_hold_to_reservations
+-- Created at:
+
+-- Pending reservations hold their slot until hold_expires_at; expired holds
+-- are moved to the 'expired' status, so status can no longer be an ENUM
+ALTER TABLE reservations
+MODIFY COLUMN status VARCHAR(20) DEFAULT 'pending',
+ADD COLUMN hold_expires_at TIMESTAMP NULL DEFAULT NULL;
+
+CREATE INDEX idx_reservations_hold_expires_at ON reservations (hold_expires_at);
@@ -0,0 +1,6 @@
+-- Migration: add_slot_key_to_reservations
+-- Created at:
+
+DROP INDEX idx_reservations_slot_key ON reservations;
+ALTER TABLE reservations DROP COLUMN slot_key;
+DROP TABLE reservation_slot_conflicts;
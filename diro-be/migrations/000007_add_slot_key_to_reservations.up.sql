@@ -0,0 +1,39 @@
+-- Migration: add_slot_key_to_reservations
+-- Created at:
+
+-- slot_key is only set while a reservation is active (pending or paid), so the
+-- unique index allows at most one active reservation per court slot
+ALTER TABLE reservations
+ADD COLUMN slot_key VARCHAR(64) NULL DEFAULT NULL;
+
+UPDATE reservations
+SET slot_key = CONCAT(court_id, ':', timeslot_id, ':', DATE_FORMAT(date, '%Y-%m-%d'))
+WHERE status = 'paid' OR (status = 'pending' AND hold_expires_at > NOW());
+
+-- Existing data may already double book a slot. The reservation with the
+-- lowest id keeps the slot; the others are logged here for review and left
+-- without a slot_key, so the unique index can be created.
+CREATE TABLE reservation_slot_conflicts (
+    reservation_id INT PRIMARY KEY,
+    kept_reservation_id INT NOT NULL,
+    slot_key VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO reservation_slot_conflicts (reservation_id, kept_reservation_id, slot_key)
+SELECT reservations.id, duplicates.kept_id, reservations.slot_key
+FROM reservations
+JOIN (
+    SELECT slot_key, MIN(id) AS kept_id
+    FROM reservations
+    WHERE slot_key IS NOT NULL
+    GROUP BY slot_key
+    HAVING COUNT(*) > 1
+) AS duplicates ON duplicates.slot_key = reservations.slot_key
+WHERE reservations.id <> duplicates.kept_id;
+
+UPDATE reservations
+JOIN reservation_slot_conflicts ON reservation_slot_conflicts.reservation_id = reservations.id
+SET reservations.slot_key = NULL;
+
+CREATE UNIQUE INDEX idx_reservations_slot_key ON reservations (slot_key);
@@ -0,0 +1,2 @@
+-- Drop webhook_events table
+DROP TABLE webhook_events;
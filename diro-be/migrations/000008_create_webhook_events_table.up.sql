@@ -0,0 +1,14 @@
+-- Create webhook_events table
+CREATE TABLE webhook_events (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    event_id VARCHAR(191) NOT NULL,           -- Invoice ID and status, e.g. inv-123:PAID
+    invoice_id VARCHAR(191),
+    reservation_id INT,
+    status VARCHAR(50),
+    applied BOOLEAN DEFAULT FALSE,
+    payload TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE INDEX idx_webhook_events_event_id (event_id),
+    INDEX idx_webhook_events_invoice_id (invoice_id),
+    INDEX idx_webhook_events_reservation_id (reservation_id)
+);
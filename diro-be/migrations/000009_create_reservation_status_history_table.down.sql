@@ -0,0 +1,2 @@
+-- Drop reservation_status_history table
+DROP TABLE reservation_status_history;
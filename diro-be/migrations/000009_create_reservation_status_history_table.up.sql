@@ -0,0 +1,14 @@
+-- Create reservation_status_history table
+CREATE TABLE reservation_status_history (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    reservation_id INT NOT NULL,
+    from_status VARCHAR(20),
+    to_status VARCHAR(20) NOT NULL,
+    payment_status VARCHAR(50),
+    `trigger` VARCHAR(50) NOT NULL, -- What caused the transition, e.g. booking, webhook, hold_expiry
+    actor VARCHAR(100),             -- Who caused it, e.g. xendit, system, customer email
+    reason TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_reservation_status_history_reservation_id (reservation_id),
+    FOREIGN KEY (reservation_id) REFERENCES reservations(id)
+);
@@ -0,0 +1,4 @@
+-- Migration: make_reservation_user_optional
+-- Created at:
+
+ALTER TABLE reservations MODIFY COLUMN user_id INT NOT NULL;
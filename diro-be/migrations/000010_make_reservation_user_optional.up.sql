@@ -0,0 +1,5 @@
+-- Migration: make_reservation_user_optional
+-- Created at:
+
+-- Guests can still book without an account, so user_id is optional
+ALTER TABLE reservations MODIFY COLUMN user_id INT NULL;
@@ -0,0 +1,5 @@
+-- Migration: add_cancellation_fields_to_reservations
+-- Created at:
+
+ALTER TABLE reservations DROP COLUMN cancelled_at;
+ALTER TABLE reservations DROP COLUMN refund_amount;
@@ -0,0 +1,6 @@
+-- Migration: add_cancellation_fields_to_reservations
+-- Created at:
+
+ALTER TABLE reservations
+ADD COLUMN refund_amount DECIMAL(10,2) DEFAULT 0.00,
+ADD COLUMN cancelled_at TIMESTAMP NULL DEFAULT NULL;
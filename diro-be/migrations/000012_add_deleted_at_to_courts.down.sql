@@ -0,0 +1,5 @@
+-- Migration: add_deleted_at_to_courts
+-- Created at:
+
+DROP INDEX idx_courts_deleted_at ON courts;
+ALTER TABLE courts DROP COLUMN deleted_at;
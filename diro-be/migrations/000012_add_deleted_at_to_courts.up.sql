@@ -0,0 +1,5 @@
+-- Migration: add_deleted_at_to_courts
+-- Created at:
+
+ALTER TABLE courts ADD COLUMN deleted_at TIMESTAMP NULL DEFAULT NULL;
+CREATE INDEX idx_courts_deleted_at ON courts (deleted_at);
@@ -0,0 +1,2 @@
+-- Drop court_schedules table
+DROP TABLE court_schedules;
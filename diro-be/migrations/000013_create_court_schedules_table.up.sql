@@ -0,0 +1,26 @@
+-- Create court_schedules table
+CREATE TABLE court_schedules (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    court_id INT NOT NULL,
+    weekday TINYINT NOT NULL,       -- 0 is Sunday
+    timeslot_id INT NOT NULL,
+    effective_from DATE NOT NULL,
+    effective_to DATE NULL,         -- Open ended when NULL
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_court_schedules_court_weekday (court_id, weekday),
+    FOREIGN KEY (court_id) REFERENCES courts(id),
+    FOREIGN KEY (timeslot_id) REFERENCES timeslots(id)
+);
+
+-- Keep existing courts bookable on every day with every timeslot, including
+-- dates before the migration that already have reservations
+INSERT INTO court_schedules (court_id, weekday, timeslot_id, effective_from)
+SELECT courts.id, weekdays.weekday, timeslots.id, '1970-01-01'
+FROM courts
+CROSS JOIN timeslots
+CROSS JOIN (
+    SELECT 0 AS weekday UNION ALL SELECT 1 UNION ALL SELECT 2 UNION ALL SELECT 3
+    UNION ALL SELECT 4 UNION ALL SELECT 5 UNION ALL SELECT 6
+) AS weekdays
+WHERE courts.deleted_at IS NULL;
@@ -0,0 +1,2 @@
+-- Drop price_rules table
+DROP TABLE price_rules;
@@ -0,0 +1,18 @@
+-- Create price_rules table
+CREATE TABLE price_rules (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    court_id INT NULL,              -- All courts when NULL
+    weekday TINYINT NULL,           -- 0 is Sunday, every day when NULL
+    start_time VARCHAR(5) DEFAULT '',
+    end_time VARCHAR(5) DEFAULT '',
+    date_from DATE NULL,
+    date_to DATE NULL,
+    holiday_only BOOLEAN DEFAULT FALSE,
+    price DECIMAL(10,2) NOT NULL,
+    priority INT DEFAULT 0,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_price_rules_court_id (court_id)
+);
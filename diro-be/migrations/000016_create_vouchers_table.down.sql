@@ -0,0 +1,2 @@
+-- Drop vouchers table
+DROP TABLE vouchers;
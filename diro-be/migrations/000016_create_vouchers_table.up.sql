@@ -0,0 +1,20 @@
+-- Create vouchers table
+CREATE TABLE vouchers (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    code VARCHAR(64) NOT NULL,          -- Stored upper case
+    description TEXT,
+    discount_type VARCHAR(20) NOT NULL, -- percentage, fixed
+    discount_value DECIMAL(10,2) NOT NULL,
+    max_discount DECIMAL(10,2) DEFAULT 0.00,
+    valid_from TIMESTAMP NULL DEFAULT NULL,
+    valid_until TIMESTAMP NULL DEFAULT NULL,
+    max_uses INT DEFAULT 0,             -- 0 is unlimited
+    max_uses_per_customer INT DEFAULT 0,
+    court_id INT NULL,
+    start_time VARCHAR(5) DEFAULT '',
+    end_time VARCHAR(5) DEFAULT '',
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE INDEX idx_vouchers_code (code)
+);
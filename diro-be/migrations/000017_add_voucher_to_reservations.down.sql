@@ -0,0 +1,7 @@
+-- Migration: add_voucher_to_reservations
+-- Created at:
+
+DROP INDEX idx_reservations_voucher_id ON reservations;
+ALTER TABLE reservations DROP COLUMN discount_amount;
+ALTER TABLE reservations DROP COLUMN promo_code;
+ALTER TABLE reservations DROP COLUMN voucher_id;
@@ -0,0 +1,8 @@
+-- Migration: add_voucher_to_reservations
+-- Created at:
+
+ALTER TABLE reservations
+ADD COLUMN voucher_id INT NULL DEFAULT NULL,
+ADD COLUMN promo_code VARCHAR(64) DEFAULT '',
+ADD COLUMN discount_amount DECIMAL(10,2) DEFAULT 0.00;
+CREATE INDEX idx_reservations_voucher_id ON reservations (voucher_id);
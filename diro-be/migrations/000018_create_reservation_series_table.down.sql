@@ -0,0 +1,2 @@
+-- Drop reservation_series table
+DROP TABLE reservation_series;
@@ -0,0 +1,17 @@
+-- Create reservation_series table
+CREATE TABLE reservation_series (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    court_id INT NOT NULL,
+    timeslot_id INT NOT NULL,
+    frequency VARCHAR(20) NOT NULL,     -- weekly, biweekly
+    start_date DATE NOT NULL,
+    end_date DATE NOT NULL,             -- Date of the last occurrence
+    billing_mode VARCHAR(20) NOT NULL,  -- single, per_occurrence
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_reservation_series_user_id (user_id),
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (court_id) REFERENCES courts(id),
+    FOREIGN KEY (timeslot_id) REFERENCES timeslots(id)
+);
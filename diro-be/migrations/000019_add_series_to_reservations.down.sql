@@ -0,0 +1,5 @@
+-- Migration: add_series_to_reservations
+-- Created at:
+
+DROP INDEX idx_reservations_series_id ON reservations;
+ALTER TABLE reservations DROP COLUMN series_id;
@@ -0,0 +1,5 @@
+-- Migration: add_series_to_reservations
+-- Created at:
+
+ALTER TABLE reservations ADD COLUMN series_id INT NULL DEFAULT NULL;
+CREATE INDEX idx_reservations_series_id ON reservations (series_id);
@@ -0,0 +1,2 @@
+-- Drop blackouts table
+DROP TABLE blackouts;
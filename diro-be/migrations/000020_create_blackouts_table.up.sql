@@ -0,0 +1,12 @@
+-- Create blackouts table
+CREATE TABLE blackouts (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    court_id INT NULL,              -- Whole venue when NULL
+    starts_at DATETIME NOT NULL,
+    ends_at DATETIME NOT NULL,
+    reason VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_blackouts_court_id (court_id),
+    INDEX idx_blackouts_starts_at (starts_at)
+);
@@ -0,0 +1,2 @@
+-- Drop waitlist_entries table
+DROP TABLE waitlist_entries;
@@ -0,0 +1,19 @@
+-- Create waitlist_entries table
+CREATE TABLE waitlist_entries (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    court_id INT NULL,              -- Any court at this time when NULL
+    timeslot_id INT NOT NULL,
+    date DATE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'waiting',  -- waiting, offered, booked, expired, cancelled
+    offered_court_id INT NULL,
+    offer_expires_at TIMESTAMP NULL,
+    reservation_id INT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id),
+    FOREIGN KEY (court_id) REFERENCES courts(id),
+    FOREIGN KEY (timeslot_id) REFERENCES timeslots(id),
+    INDEX idx_waitlist_entries_user_id (user_id),
+    INDEX idx_waitlist_entries_slot (timeslot_id, date)
+);
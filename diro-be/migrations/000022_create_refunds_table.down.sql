@@ -0,0 +1,2 @@
+-- Drop refunds table
+DROP TABLE refunds;
@@ -0,0 +1,19 @@
+-- Create refunds table
+CREATE TABLE refunds (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    reservation_id INT NOT NULL,
+    gateway_refund_id VARCHAR(64) DEFAULT '',   -- Xendit refund ID
+    reference_id VARCHAR(100) NOT NULL,         -- Sent to Xendit to make the request idempotent
+    amount DECIMAL(10,2) NOT NULL,
+    reason VARCHAR(32) NOT NULL,                -- REQUESTED_BY_CUSTOMER, CANCELLATION, DUPLICATE, FRAUDULENT, OTHERS
+    note VARCHAR(255) DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING',  -- PENDING, SUCCEEDED, FAILED
+    failure_code VARCHAR(64) DEFAULT '',
+    actor VARCHAR(100),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (reservation_id) REFERENCES reservations(id),
+    UNIQUE INDEX idx_refunds_reference_id (reference_id),
+    INDEX idx_refunds_reservation_id (reservation_id),
+    INDEX idx_refunds_gateway_refund_id (gateway_refund_id)
+);
@@ -21,6 +21,8 @@ const ReservationForm: React.FC = () => {
   const [selectedCourt, setSelectedCourt] = useState<string>('');
   const [selectedCourtId, setSelectedCourtId] = useState<number | null>(null);
   const [selectedTimeslotId, setSelectedTimeslotId] = useState<number | null>(null);
+  const [selectedPrice, setSelectedPrice] = useState<number>(0);
+  const [promoCode, setPromoCode] = useState<string>('');
   const [step, setStep] = useState<ReservationStep>('schedule-selection');
   const [loading, setLoading] = useState(false);
   const [availability, setAvailability] = useState<AvailabilityResponse | null>(null);
@@ -61,13 +63,15 @@ const ReservationForm: React.FC = () => {
     setSelectedCourt('');
     setSelectedCourtId(null);
     setSelectedTimeslotId(null);
+    setSelectedPrice(0);
   };
 
-  const handleCourtTimeslotSelection = (courtId: number, courtName: string, timeslotId: number, timeslotStart: string) => {
+  const handleCourtTimeslotSelection = (courtId: number, courtName: string, timeslotId: number, timeslotStart: string, price: number) => {
     setSelectedCourt(courtName);
     setSelectedCourtId(courtId);
     setSelectedTimeslot(timeslotStart);
     setSelectedTimeslotId(timeslotId);
+    setSelectedPrice(price);
     setStep('user-details');
   };
 
@@ -99,6 +103,7 @@ const ReservationForm: React.FC = () => {
           email: userDetails.email,
           mobile_number: userDetails.phone,
         },
+        promo_code: promoCode.trim() || undefined,
       };
 
       const response: ReservationResponse = await createReservation(reservationData);
@@ -107,7 +112,7 @@ const ReservationForm: React.FC = () => {
       window.location.href = response.invoice_url;
     } catch (error) {
       console.error('Failed to create reservation:', error);
-      toast.error('Failed to create reservation. Please try again.');
+      toast.error(error instanceof Error ? error.message : 'Failed to create reservation. Please try again.');
     } finally {
       setLoading(false);
     }
@@ -216,10 +221,25 @@ const ReservationForm: React.FC = () => {
               <Separator className="my-4" />
               <div className="flex justify-between items-center pt-2">
                 <span className="text-lg font-bold text-slate-900">Total Pembayaran</span>
-                <span className="text-2xl font-bold bg-gradient-to-r from-blue-600 to-purple-600 bg-clip-text text-transparent">{formatCurrency(50000)}</span>
+                <span className="text-2xl font-bold bg-gradient-to-r from-blue-600 to-purple-600 bg-clip-text text-transparent">{formatCurrency(selectedPrice)}</span>
               </div>
             </div>
 
+            <div className="space-y-2">
+              <Label htmlFor="promo-code" className="text-base font-semibold">
+                Kode Promo
+              </Label>
+              <Input
+                id="promo-code"
+                type="text"
+                value={promoCode}
+                onChange={(e) => setPromoCode(e.target.value.toUpperCase())}
+                placeholder="Opsional"
+                className="h-12 text-base"
+              />
+              <p className="text-xs text-slate-500">Potongan harga akan tercantum di halaman pembayaran</p>
+            </div>
+
             <Alert className="bg-blue-50 border-blue-200">
               <AlertCircle className="h-4 w-4 text-blue-600" />
               <AlertDescription className="text-blue-800">
@@ -349,7 +369,7 @@ const ReservationForm: React.FC = () => {
                             <p className="text-sm text-slate-600 mt-1">{courtAvailability.court.description}</p>
                           </div>
                           <Badge variant="outline" className="bg-blue-50 border-blue-200 text-blue-700">
-                            {courtAvailability.timeslots.filter((ts) => !ts.is_booked).length} slot
+                            {courtAvailability.timeslots.filter((ts) => !ts.is_booked && !ts.is_held && !ts.is_blacked_out).length} slot
                           </Badge>
                         </div>
                         
@@ -362,7 +382,7 @@ const ReservationForm: React.FC = () => {
                           </p>
                           <div className="space-y-2 max-h-64 overflow-y-auto pr-2">
                             {courtAvailability.timeslots
-                              .filter((ts) => !ts.is_booked)
+                              .filter((ts) => !ts.is_booked && !ts.is_held && !ts.is_blacked_out)
                               .map((ts) => (
                               <button
                                 key={ts.timeslot.id}
@@ -370,7 +390,8 @@ const ReservationForm: React.FC = () => {
                                   courtAvailability.court.id,
                                   courtAvailability.court.name,
                                   ts.timeslot.id,
-                                  ts.timeslot.start_time
+                                  ts.timeslot.start_time,
+                                  ts.price
                                 )}
                                 className="w-full px-4 py-3 text-sm bg-gradient-to-r from-blue-50 to-purple-50 hover:from-blue-100 hover:to-purple-100 text-slate-800 font-medium rounded-lg border-2 border-blue-100 hover:border-blue-300 transition-all hover:shadow-md flex items-center justify-between group/button"
                               >
@@ -378,6 +399,7 @@ const ReservationForm: React.FC = () => {
                                   <Clock className="w-4 h-4 text-blue-600" />
                                   {ts.timeslot.start_time} - {ts.timeslot.end_time}
                                 </span>
+                                <span className="ml-auto mr-2 text-xs font-semibold text-slate-600">{formatCurrency(ts.price)}</span>
                                 <ArrowRight className="w-4 h-4 text-blue-600 opacity-0 group-hover/button:opacity-100 transition-opacity" />
                               </button>
                             ))}
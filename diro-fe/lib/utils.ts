@@ -1,6 +1,6 @@
 import { clsx, type ClassValue } from "clsx"
 import { twMerge } from "tailwind-merge"
-import { AvailabilityResponse, ReservationRequest, ReservationResponse } from "../types"
+import { AvailabilityRangeResponse, AvailabilityResponse, ReservationRequest, ReservationResponse } from "../types"
 
 export function cn(...inputs: ClassValue[]) {
   return twMerge(clsx(inputs))
@@ -21,6 +21,18 @@ export async function fetchAvailability(date: string): Promise<AvailabilityRespo
   return response.json()
 }
 
+export async function fetchAvailabilityRange(from: string, to: string, courtId?: number): Promise<AvailabilityRangeResponse> {
+  const params = new URLSearchParams({ from, to })
+  if (courtId) {
+    params.set('court_id', String(courtId))
+  }
+  const response = await fetch(`${process.env.NEXT_PUBLIC_API_BASE_URL}/reservations/availability?${params}`)
+  if (!response.ok) {
+    throw new Error('Failed to fetch availability')
+  }
+  return response.json()
+}
+
 export async function createReservation(data: ReservationRequest): Promise<ReservationResponse> {
   const response = await fetch(`${process.env.NEXT_PUBLIC_API_BASE_URL}/reservations`, {
     method: 'POST',
@@ -30,7 +42,8 @@ export async function createReservation(data: ReservationRequest): Promise<Reser
     body: JSON.stringify(data),
   })
   if (!response.ok) {
-    throw new Error('Failed to create reservation')
+    const body = await response.json().catch(() => null)
+    throw new Error(body?.error || 'Failed to create reservation')
   }
   return response.json()
 }
@@ -32,6 +32,10 @@ export interface CourtAvailability {
   timeslots: {
     timeslot: TimeslotData;
     is_booked: boolean;
+    is_held: boolean;
+    is_blacked_out: boolean;
+    blackout_reason?: string;
+    price: number;
   }[];
 }
 
@@ -40,6 +44,19 @@ export interface AvailabilityResponse {
   courts: CourtAvailability[];
 }
 
+export interface DaySummary {
+  date: string;
+  free_slots: number;
+  total_slots: number;
+}
+
+export interface AvailabilityRangeResponse {
+  from: string;
+  to: string;
+  summary: DaySummary[];
+  days: AvailabilityResponse[];
+}
+
 // New types for reservation API
 export interface ReservationRequest {
   court_id: number;
@@ -51,6 +68,7 @@ export interface ReservationRequest {
     email: string;
     mobile_number: string;
   };
+  promo_code?: string;
 }
 
 export interface ReservationResponse {
@@ -62,6 +80,8 @@ export interface ReservationResponse {
     date: string;
     status: string;
     total_price: number;
+    promo_code?: string;
+    discount_amount: number;
     payment_id: string;
     invoice_url: string;
     payment_status: string;